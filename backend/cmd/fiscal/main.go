@@ -0,0 +1,93 @@
+// Command fiscal verifies and exports the fiscal journal.
+//
+//	go run ./cmd/fiscal -verify
+//	go run ./cmd/fiscal -export journal.txt -from 2024-01-01 -to 2024-01-31
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"pos-backend/internal/database"
+	"pos-backend/internal/fiscal"
+	"pos-backend/internal/util"
+)
+
+func main() {
+	util.LoadEnv("")
+
+	verifyFlag := flag.Bool("verify", false, "Check the journal for gaps and modified entries")
+	exportFlag := flag.String("export", "", "Write the journal to this file as a flat file (- for stdout)")
+	fromFlag := flag.String("from", "", "First day to export (YYYY-MM-DD), defaults to the start of the journal")
+	toFlag := flag.String("to", "", "Last day to export (YYYY-MM-DD), defaults to today")
+	flag.Parse()
+
+	if !*verifyFlag && *exportFlag == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	db, err := database.Connect(database.Config{
+		Host:     util.FromEnv("DB_HOST", "localhost"),
+		Port:     util.FromEnv("DB_PORT", "5432"),
+		User:     util.FromEnv("DB_USER", "postgres"),
+		Password: util.FromEnv("DB_PASSWORD", ""),
+		DBName:   util.FromEnv("DB_NAME", "pos_system"),
+		SSLMode:  util.FromEnv("DB_SSLMODE", "disable"),
+	})
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	if *verifyFlag {
+		checked, problems, err := fiscal.Verify(db)
+		if err != nil {
+			log.Fatalf("Verification failed: %v", err)
+		}
+		for _, problem := range problems {
+			fmt.Printf("#%d %s: %s\n", problem.FiscalNumber, problem.Kind, problem.Detail)
+		}
+		if len(problems) > 0 {
+			fmt.Printf("%d entries checked, %d problem(s) found\n", checked, len(problems))
+			os.Exit(1)
+		}
+		fmt.Printf("%d entries checked, journal is intact\n", checked)
+	}
+
+	if *exportFlag != "" {
+		from := time.Time{}
+		to := time.Now()
+		if *fromFlag != "" {
+			if from, err = time.ParseInLocation("2006-01-02", *fromFlag, time.Local); err != nil {
+				log.Fatalf("Invalid -from date: %v", err)
+			}
+		}
+		if *toFlag != "" {
+			if to, err = time.ParseInLocation("2006-01-02", *toFlag, time.Local); err != nil {
+				log.Fatalf("Invalid -to date: %v", err)
+			}
+		}
+		end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.Local).AddDate(0, 0, 1)
+
+		var out io.Writer = os.Stdout
+		if *exportFlag != "-" {
+			file, err := os.Create(*exportFlag)
+			if err != nil {
+				log.Fatalf("Failed to create export file: %v", err)
+			}
+			defer file.Close()
+			out = file
+		}
+
+		count, err := fiscal.Export(db, out, from, end)
+		if err != nil {
+			log.Fatalf("Export failed: %v", err)
+		}
+		log.Printf("Exported %d journal entries", count)
+	}
+}
@@ -383,5 +383,5 @@ func (m *MigrationManager) findMigrationFile() (string, error) {
 		}
 	}
 
-	return "", fmt.Errorf("migration file not found for version %d")
+	return "", fmt.Errorf("migration file not found in %s", migrationsDir)
 }
@@ -0,0 +1,24 @@
+-- +migrate Up
+ALTER TABLE payments ALTER COLUMN payment_method TYPE VARCHAR(20);
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS reference_number VARCHAR(100);
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS processed_by UUID;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS provider VARCHAR(30);
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS failure_reason TEXT;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS refunded_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS refund_reason TEXT;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS refunded_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE payments ALTER COLUMN transaction_id TYPE VARCHAR(100);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_provider_transaction ON payments(provider, transaction_id) WHERE transaction_id IS NOT NULL;
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_payments_provider_transaction;
+ALTER TABLE payments DROP COLUMN IF EXISTS refunded_at;
+ALTER TABLE payments DROP COLUMN IF EXISTS refund_reason;
+ALTER TABLE payments DROP COLUMN IF EXISTS refunded_amount;
+ALTER TABLE payments DROP COLUMN IF EXISTS failure_reason;
+ALTER TABLE payments DROP COLUMN IF EXISTS provider;
+ALTER TABLE payments DROP COLUMN IF EXISTS processed_by;
+ALTER TABLE payments DROP COLUMN IF EXISTS reference_number;
+ALTER TABLE payments ALTER COLUMN transaction_id TYPE VARCHAR(50) USING LEFT(transaction_id, 50);
+ALTER TABLE payments ALTER COLUMN payment_method TYPE VARCHAR(10) USING LEFT(payment_method, 10);
@@ -0,0 +1,54 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS gift_cards (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+    code VARCHAR(32) UNIQUE NOT NULL,
+    balance DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (balance >= 0),
+    status VARCHAR(20) NOT NULL CHECK (status IN ('pending', 'active', 'disabled')) DEFAULT 'pending',
+    issued_by UUID,
+    activated_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_gift_cards_status ON gift_cards(status);
+
+CREATE TABLE IF NOT EXISTS gift_card_transactions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+    gift_card_id UUID NOT NULL REFERENCES gift_cards(id),
+    transaction_type VARCHAR(10) NOT NULL CHECK (transaction_type IN ('issue', 'reload', 'redeem', 'refund')),
+    amount DECIMAL(10,2) NOT NULL CHECK (amount > 0),
+    balance_after DECIMAL(10,2) NOT NULL,
+    order_id UUID,
+    order_item_id UUID UNIQUE,
+    payment_id UUID,
+    created_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_gift_card_transactions_gift_card_id ON gift_card_transactions(gift_card_id);
+CREATE INDEX IF NOT EXISTS idx_gift_card_transactions_created_at ON gift_card_transactions(created_at);
+
+-- The ledger is append-only
+CREATE OR REPLACE FUNCTION prevent_gift_card_transaction_change() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'gift card transactions are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER gift_card_transactions_immutable
+    BEFORE UPDATE OR DELETE ON gift_card_transactions
+    FOR EACH ROW EXECUTE FUNCTION prevent_gift_card_transaction_change();
+
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS item_type VARCHAR(20) NOT NULL DEFAULT 'product' CHECK (item_type IN ('product', 'gift_card'));
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS gift_card_id UUID;
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS gift_card_id UUID;
+CREATE INDEX IF NOT EXISTS idx_payments_gift_card_id ON payments(gift_card_id);
+
+-- +migrate Down
+ALTER TABLE payments DROP COLUMN IF EXISTS gift_card_id;
+ALTER TABLE order_items DROP COLUMN IF EXISTS gift_card_id;
+ALTER TABLE order_items DROP COLUMN IF EXISTS item_type;
+DROP TABLE IF EXISTS gift_card_transactions;
+DROP FUNCTION IF EXISTS prevent_gift_card_transaction_change();
+DROP TABLE IF EXISTS gift_cards;
@@ -0,0 +1,38 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS payment_refunds (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+    payment_id UUID NOT NULL,
+    amount DECIMAL(10,2) NOT NULL CHECK (amount > 0),
+    reason TEXT,
+    refunded_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_refunds_payment_id ON payment_refunds(payment_id);
+CREATE INDEX IF NOT EXISTS idx_payment_refunds_created_at ON payment_refunds(created_at);
+
+CREATE TABLE IF NOT EXISTS z_reports (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+    z_number INTEGER UNIQUE NOT NULL,
+    opened_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    closed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    closed_by UUID,
+    report JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- A closed business day can never be changed
+CREATE OR REPLACE FUNCTION prevent_z_report_change() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'z reports are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER z_reports_immutable
+    BEFORE UPDATE OR DELETE ON z_reports
+    FOR EACH ROW EXECUTE FUNCTION prevent_z_report_change();
+
+-- +migrate Down
+DROP TABLE IF EXISTS z_reports;
+DROP FUNCTION IF EXISTS prevent_z_report_change();
+DROP TABLE IF EXISTS payment_refunds;
@@ -0,0 +1,18 @@
+-- +migrate Up
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS tendered_amount DECIMAL(10,2);
+
+-- Every rendered receipt and kitchen ticket is recorded so reprints can be marked as copies
+CREATE TABLE IF NOT EXISTS receipt_prints (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+    order_id UUID NOT NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('receipt', 'kitchen')),
+    format VARCHAR(20) NOT NULL CHECK (format IN ('text', 'escpos', 'html')),
+    printed_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_receipt_prints_order_id ON receipt_prints(order_id, kind);
+
+-- +migrate Down
+DROP TABLE IF EXISTS receipt_prints;
+ALTER TABLE payments DROP COLUMN IF EXISTS tendered_amount;
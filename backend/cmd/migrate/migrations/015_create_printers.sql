@@ -0,0 +1,52 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS printers (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+    name VARCHAR(100) UNIQUE NOT NULL,
+    host VARCHAR(255) NOT NULL,
+    port INTEGER NOT NULL DEFAULT 9100 CHECK (port > 0 AND port < 65536),
+    role VARCHAR(20) NOT NULL CHECK (role IN ('receipt', 'kitchen', 'bar')),
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Kitchen and bar printers only receive items of their categories; a kitchen
+-- printer without categories receives everything not routed elsewhere
+CREATE TABLE IF NOT EXISTS printer_categories (
+    printer_id UUID NOT NULL,
+    category_id UUID NOT NULL,
+    PRIMARY KEY (printer_id, category_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_printer_categories_category_id ON printer_categories(category_id);
+
+CREATE TABLE IF NOT EXISTS print_jobs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+    printer_id UUID NOT NULL,
+    order_id UUID,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('receipt', 'kitchen', 'test')),
+    payload BYTEA NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN (
+        'pending',
+        'printing',
+        'completed',
+        'failed'
+    )) DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 5,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    created_by UUID,
+    printed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_print_jobs_pending ON print_jobs(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_print_jobs_order_id ON print_jobs(order_id);
+CREATE INDEX IF NOT EXISTS idx_print_jobs_printer_id ON print_jobs(printer_id);
+
+-- +migrate Down
+DROP TABLE IF EXISTS print_jobs;
+DROP TABLE IF EXISTS printer_categories;
+DROP TABLE IF EXISTS printers;
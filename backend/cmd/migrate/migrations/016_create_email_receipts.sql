@@ -0,0 +1,48 @@
+-- +migrate Up
+ALTER TABLE orders ALTER COLUMN customer_email TYPE VARCHAR(255);
+ALTER TABLE settings ADD COLUMN IF NOT EXISTS auto_email_receipts BOOLEAN DEFAULT false;
+
+-- Admin overrides of the built-in templates, keyed by template name
+CREATE TABLE IF NOT EXISTS email_templates (
+    name VARCHAR(50) PRIMARY KEY,
+    subject TEXT NOT NULL,
+    text_body TEXT NOT NULL,
+    html_body TEXT NOT NULL,
+    updated_by UUID,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS email_jobs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+    order_id UUID,
+    template VARCHAR(50) NOT NULL,
+    from_name VARCHAR(255) NOT NULL,
+    reply_to VARCHAR(255),
+    recipient VARCHAR(255) NOT NULL,
+    subject TEXT NOT NULL,
+    text_body TEXT NOT NULL,
+    html_body TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN (
+        'pending',
+        'sending',
+        'sent',
+        'failed'
+    )) DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 5,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    created_by UUID,
+    sent_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_jobs_pending ON email_jobs(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_email_jobs_order_id ON email_jobs(order_id);
+
+-- +migrate Down
+DROP TABLE IF EXISTS email_jobs;
+DROP TABLE IF EXISTS email_templates;
+ALTER TABLE settings DROP COLUMN IF EXISTS auto_email_receipts;
+ALTER TABLE orders ALTER COLUMN customer_email TYPE VARCHAR(30);
@@ -0,0 +1,40 @@
+-- +migrate Up
+-- Append-only journal of completed sales and refunds. Numbers are assigned
+-- without gaps and every row stores the SHA-256 hash of its content chained
+-- to the hash of the previous row.
+CREATE TABLE IF NOT EXISTS fiscal_journal (
+    fiscal_number BIGINT PRIMARY KEY,
+    entry_type VARCHAR(10) NOT NULL CHECK (entry_type IN ('sale', 'refund')),
+    order_id UUID NOT NULL,
+    order_number VARCHAR(50) NOT NULL,
+    payment_id UUID,
+    refund_id UUID,
+    amount DECIMAL(10,2) NOT NULL,
+    tax_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    currency VARCHAR(10) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    previous_hash CHAR(64) NOT NULL,
+    hash CHAR(64) UNIQUE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_fiscal_journal_order_id ON fiscal_journal(order_id);
+CREATE INDEX IF NOT EXISTS idx_fiscal_journal_created_at ON fiscal_journal(created_at);
+
+CREATE OR REPLACE FUNCTION prevent_fiscal_journal_change() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'fiscal journal is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER fiscal_journal_append_only
+    BEFORE UPDATE OR DELETE ON fiscal_journal
+    FOR EACH ROW EXECUTE FUNCTION prevent_fiscal_journal_change();
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS fiscal_number BIGINT;
+ALTER TABLE payment_refunds ADD COLUMN IF NOT EXISTS fiscal_number BIGINT;
+
+-- +migrate Down
+ALTER TABLE payment_refunds DROP COLUMN IF EXISTS fiscal_number;
+ALTER TABLE orders DROP COLUMN IF EXISTS fiscal_number;
+DROP TABLE IF EXISTS fiscal_journal;
+DROP FUNCTION IF EXISTS prevent_fiscal_journal_change();
@@ -0,0 +1,18 @@
+-- +migrate Up
+-- Order changes pushed to the kitchen, counter and server streams. Each row is
+-- announced with NOTIFY on the order_events channel; the serial id doubles as
+-- the SSE event id so reconnecting clients can resume where they left off.
+CREATE TABLE IF NOT EXISTS order_events (
+    id BIGSERIAL PRIMARY KEY,
+    event_type VARCHAR(50) NOT NULL,
+    order_id UUID NOT NULL,
+    user_id UUID,
+    payload JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_events_created_at ON order_events(created_at);
+CREATE INDEX IF NOT EXISTS idx_order_events_order_id ON order_events(order_id);
+
+-- +migrate Down
+DROP TABLE IF EXISTS order_events;
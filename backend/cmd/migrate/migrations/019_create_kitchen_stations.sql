@@ -0,0 +1,42 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS kitchen_stations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+    name VARCHAR(50) UNIQUE NOT NULL,
+    color VARCHAR(7),
+    sort_order INTEGER DEFAULT 0,
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Items are routed to the product's station, falling back to its category's.
+-- The station is copied onto the order item when the order is placed so that
+-- menu changes do not move tickets that are already in the kitchen.
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS station_id UUID;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS station_id UUID;
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS station_id UUID;
+
+CREATE INDEX IF NOT EXISTS idx_order_items_station_id ON order_items(station_id);
+
+INSERT INTO kitchen_stations (name, color, sort_order) VALUES
+('grill', '#E74C3C', 1),
+('fryer', '#F39C12', 2),
+('cold', '#3498DB', 3),
+('bar', '#9B59B6', 4)
+ON CONFLICT (name) DO NOTHING;
+
+UPDATE categories SET station_id = (SELECT id FROM kitchen_stations WHERE name = 'grill')
+WHERE name IN ('Main Courses', 'Pizza') AND station_id IS NULL;
+UPDATE categories SET station_id = (SELECT id FROM kitchen_stations WHERE name = 'fryer')
+WHERE name = 'Appetizers' AND station_id IS NULL;
+UPDATE categories SET station_id = (SELECT id FROM kitchen_stations WHERE name = 'cold')
+WHERE name IN ('Salads', 'Desserts') AND station_id IS NULL;
+UPDATE categories SET station_id = (SELECT id FROM kitchen_stations WHERE name = 'bar')
+WHERE name = 'Beverages' AND station_id IS NULL;
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_order_items_station_id;
+ALTER TABLE order_items DROP COLUMN IF EXISTS station_id;
+ALTER TABLE products DROP COLUMN IF EXISTS station_id;
+ALTER TABLE categories DROP COLUMN IF EXISTS station_id;
+DROP TABLE IF EXISTS kitchen_stations;
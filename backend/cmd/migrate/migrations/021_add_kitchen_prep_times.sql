@@ -0,0 +1,25 @@
+-- +migrate Up
+-- expected_prep_time is the product's preparation_time when the order was
+-- placed; started_at and ready_at are set by the kitchen status changes and
+-- late_at when the ticket first ran over its expected time.
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS expected_prep_time INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS started_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS ready_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS late_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE order_items oi
+SET expected_prep_time = COALESCE(p.preparation_time, 0)
+FROM products p
+WHERE oi.product_id = p.id AND oi.expected_prep_time = 0;
+
+CREATE INDEX IF NOT EXISTS idx_order_items_ready_at ON order_items(ready_at);
+CREATE INDEX IF NOT EXISTS idx_order_items_open ON order_items(created_at)
+    WHERE status IN ('pending', 'preparing') AND late_at IS NULL;
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_order_items_open;
+DROP INDEX IF EXISTS idx_order_items_ready_at;
+ALTER TABLE order_items DROP COLUMN IF EXISTS late_at;
+ALTER TABLE order_items DROP COLUMN IF EXISTS ready_at;
+ALTER TABLE order_items DROP COLUMN IF EXISTS started_at;
+ALTER TABLE order_items DROP COLUMN IF EXISTS expected_prep_time;
@@ -0,0 +1,29 @@
+-- +migrate Up
+-- Remakes are sent to the kitchen as new, free items pointing at the item they
+-- replace, so waste can be reported by product and reason.
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS is_remake BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS remake_of UUID;
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS remake_reason TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_order_items_remakes ON order_items(created_at) WHERE is_remake;
+
+-- Audit trail of tickets recalled after being bumped and of remakes
+CREATE TABLE IF NOT EXISTS kitchen_ticket_actions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+    order_id UUID NOT NULL,
+    action VARCHAR(20) NOT NULL CHECK (action IN ('recall', 'remake')),
+    item_ids UUID[] NOT NULL DEFAULT '{}',
+    reason TEXT,
+    performed_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_kitchen_ticket_actions_order_id ON kitchen_ticket_actions(order_id);
+CREATE INDEX IF NOT EXISTS idx_kitchen_ticket_actions_created_at ON kitchen_ticket_actions(created_at);
+
+-- +migrate Down
+DROP TABLE IF EXISTS kitchen_ticket_actions;
+DROP INDEX IF EXISTS idx_order_items_remakes;
+ALTER TABLE order_items DROP COLUMN IF EXISTS remake_reason;
+ALTER TABLE order_items DROP COLUMN IF EXISTS remake_of;
+ALTER TABLE order_items DROP COLUMN IF EXISTS is_remake;
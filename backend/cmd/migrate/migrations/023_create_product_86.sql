@@ -0,0 +1,26 @@
+-- +migrate Up
+-- Products taken off the menu ("86'd") by the kitchen. A row with a positive
+-- remaining_count is a limited run that becomes 86'd when it reaches zero; rows
+-- past restore_at no longer apply and are removed by the kitchen worker, which
+-- also clears the list at the daily reset.
+CREATE TABLE IF NOT EXISTS product_86 (
+    product_id UUID PRIMARY KEY,
+    remaining_count INTEGER,
+    restore_at TIMESTAMP WITH TIME ZONE,
+    reason TEXT,
+    marked_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_86_restore_at ON product_86(restore_at) WHERE restore_at IS NOT NULL;
+
+-- Menu availability changes share the live event stream with orders
+ALTER TABLE order_events ALTER COLUMN order_id DROP NOT NULL;
+ALTER TABLE order_events ADD COLUMN IF NOT EXISTS product_id UUID;
+
+-- +migrate Down
+DELETE FROM order_events WHERE order_id IS NULL;
+ALTER TABLE order_events DROP COLUMN IF EXISTS product_id;
+ALTER TABLE order_events ALTER COLUMN order_id SET NOT NULL;
+DROP TABLE IF EXISTS product_86;
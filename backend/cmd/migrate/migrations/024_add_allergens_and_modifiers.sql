@@ -0,0 +1,52 @@
+-- +migrate Up
+-- Allergen and dietary attributes on products
+ALTER TABLE products ADD COLUMN IF NOT EXISTS allergens TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE products ADD COLUMN IF NOT EXISTS dietary_tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_products_allergens ON products USING GIN (allergens);
+CREATE INDEX IF NOT EXISTS idx_products_dietary_tags ON products USING GIN (dietary_tags);
+
+-- Modifiers customize a product and may add to its price. Without a product
+-- they can be applied to any product.
+CREATE TABLE IF NOT EXISTS modifiers (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+    product_id UUID,
+    name VARCHAR(100) NOT NULL,
+    price DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    allergens TEXT[] NOT NULL DEFAULT '{}',
+    dietary_tags TEXT[] NOT NULL DEFAULT '{}',
+    is_available BOOLEAN DEFAULT true,
+    sort_order INTEGER DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_modifiers_product_id ON modifiers(product_id);
+
+-- Modifiers chosen for an order item, kept as they were when ordered
+CREATE TABLE IF NOT EXISTS order_item_modifiers (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+    order_item_id UUID NOT NULL,
+    modifier_id UUID,
+    name VARCHAR(100) NOT NULL,
+    price DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    allergens TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_item_modifiers_order_item_id ON order_item_modifiers(order_item_id);
+
+-- Allergens of the product and its modifiers at the time of the order, and the
+-- guest allergies the kitchen must check them against
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS allergens TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS guest_allergies TEXT[] NOT NULL DEFAULT '{}';
+
+-- +migrate Down
+ALTER TABLE orders DROP COLUMN IF EXISTS guest_allergies;
+ALTER TABLE order_items DROP COLUMN IF EXISTS allergens;
+DROP TABLE IF EXISTS order_item_modifiers;
+DROP TABLE IF EXISTS modifiers;
+DROP INDEX IF EXISTS idx_products_dietary_tags;
+DROP INDEX IF EXISTS idx_products_allergens;
+ALTER TABLE products DROP COLUMN IF EXISTS dietary_tags;
+ALTER TABLE products DROP COLUMN IF EXISTS allergens;
@@ -0,0 +1,30 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS reservations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+    guest_name VARCHAR(100) NOT NULL,
+    guest_phone VARCHAR(30),
+    party_size INTEGER NOT NULL CHECK (party_size > 0),
+    reserved_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    duration_minutes INTEGER NOT NULL DEFAULT 90 CHECK (duration_minutes > 0),
+    table_id UUID,
+    status VARCHAR(20) NOT NULL DEFAULT 'booked' CHECK (status IN ('booked', 'seated', 'no_show', 'cancelled')),
+    notes TEXT,
+    order_id UUID,
+    created_by UUID,
+    seated_at TIMESTAMP WITH TIME ZONE,
+    cancelled_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_reservations_reserved_at ON reservations(reserved_at);
+CREATE INDEX IF NOT EXISTS idx_reservations_table_id ON reservations(table_id, reserved_at) WHERE status IN ('booked', 'seated');
+CREATE INDEX IF NOT EXISTS idx_reservations_status ON reservations(status);
+
+-- The reservation a table is being held for. Only tables held by the
+-- reservation worker are released by it, so a table reserved by hand stays so.
+ALTER TABLE dining_tables ADD COLUMN IF NOT EXISTS reserved_for UUID;
+
+-- +migrate Down
+ALTER TABLE dining_tables DROP COLUMN IF EXISTS reserved_for;
+DROP TABLE IF EXISTS reservations;
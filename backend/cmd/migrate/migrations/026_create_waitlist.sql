@@ -0,0 +1,44 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS waitlist_entries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+    party_name VARCHAR(100) NOT NULL,
+    party_size INTEGER NOT NULL CHECK (party_size > 0),
+    phone VARCHAR(30),
+    notes TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'waiting' CHECK (status IN ('waiting', 'notified', 'seated', 'removed')),
+    quoted_wait_minutes INTEGER NOT NULL DEFAULT 0,
+    table_id UUID,
+    order_id UUID,
+    remove_reason VARCHAR(50),
+    notify_count INTEGER NOT NULL DEFAULT 0,
+    created_by UUID,
+    added_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    notified_at TIMESTAMP WITH TIME ZONE,
+    seated_at TIMESTAMP WITH TIME ZONE,
+    removed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_waitlist_entries_status ON waitlist_entries(status, added_at);
+CREATE INDEX IF NOT EXISTS idx_waitlist_entries_added_at ON waitlist_entries(added_at);
+
+-- Text messages sent to waiting parties
+CREATE TABLE IF NOT EXISTS waitlist_notifications (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+    entry_id UUID NOT NULL,
+    phone VARCHAR(30) NOT NULL,
+    body TEXT NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    provider_message_id VARCHAR(100),
+    status VARCHAR(20) NOT NULL CHECK (status IN ('sent', 'failed')),
+    error_message TEXT,
+    sent_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_waitlist_notifications_entry_id ON waitlist_notifications(entry_id);
+
+-- +migrate Down
+DROP TABLE IF EXISTS waitlist_notifications;
+DROP TABLE IF EXISTS waitlist_entries;
@@ -0,0 +1,56 @@
+-- +migrate Up
+-- One floor plan per location; tables are placed on it in plan units
+CREATE TABLE IF NOT EXISTS floor_plans (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+    location VARCHAR(20) UNIQUE NOT NULL CHECK (location IN ('main_floor', 'patio', 'private_room', 'outdoor', 'bar_counter', 'takeout_counter', 'window_side')),
+    name VARCHAR(100) NOT NULL,
+    width INTEGER NOT NULL DEFAULT 1000 CHECK (width > 0),
+    height INTEGER NOT NULL DEFAULT 1000 CHECK (height > 0),
+    background_url VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE dining_tables ADD COLUMN IF NOT EXISTS pos_x DECIMAL(8, 2) NOT NULL DEFAULT 0;
+ALTER TABLE dining_tables ADD COLUMN IF NOT EXISTS pos_y DECIMAL(8, 2) NOT NULL DEFAULT 0;
+ALTER TABLE dining_tables ADD COLUMN IF NOT EXISTS width DECIMAL(8, 2) NOT NULL DEFAULT 80;
+ALTER TABLE dining_tables ADD COLUMN IF NOT EXISTS height DECIMAL(8, 2) NOT NULL DEFAULT 80;
+ALTER TABLE dining_tables ADD COLUMN IF NOT EXISTS shape VARCHAR(20) NOT NULL DEFAULT 'square'
+    CHECK (shape IN ('square', 'rectangle', 'round', 'oval', 'booth'));
+ALTER TABLE dining_tables ADD COLUMN IF NOT EXISTS rotation INTEGER NOT NULL DEFAULT 0
+    CHECK (rotation >= 0 AND rotation < 360);
+
+-- A group of tables looked after by one server for a shift
+CREATE TABLE IF NOT EXISTS server_sections (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+    name VARCHAR(50) NOT NULL,
+    server_id UUID NOT NULL,
+    color VARCHAR(7),
+    shift_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    shift_end TIMESTAMP WITH TIME ZONE NOT NULL,
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (shift_end > shift_start)
+);
+
+CREATE TABLE IF NOT EXISTS server_section_tables (
+    section_id UUID NOT NULL,
+    table_id UUID NOT NULL,
+    PRIMARY KEY (section_id, table_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_server_sections_shift ON server_sections(shift_start, shift_end);
+CREATE INDEX IF NOT EXISTS idx_server_sections_server_id ON server_sections(server_id);
+CREATE INDEX IF NOT EXISTS idx_server_section_tables_table_id ON server_section_tables(table_id);
+
+-- +migrate Down
+DROP TABLE IF EXISTS server_section_tables;
+DROP TABLE IF EXISTS server_sections;
+ALTER TABLE dining_tables DROP COLUMN IF EXISTS rotation;
+ALTER TABLE dining_tables DROP COLUMN IF EXISTS shape;
+ALTER TABLE dining_tables DROP COLUMN IF EXISTS height;
+ALTER TABLE dining_tables DROP COLUMN IF EXISTS width;
+ALTER TABLE dining_tables DROP COLUMN IF EXISTS pos_y;
+ALTER TABLE dining_tables DROP COLUMN IF EXISTS pos_x;
+DROP TABLE IF EXISTS floor_plans;
@@ -0,0 +1,18 @@
+-- +migrate Up
+-- Tables now move available -> occupied -> needs_cleaning -> available with
+-- the orders seated at them
+ALTER TABLE dining_tables DROP CONSTRAINT IF EXISTS dining_tables_status_check;
+ALTER TABLE dining_tables ADD CONSTRAINT dining_tables_status_check
+    CHECK (status IN ('available', 'occupied', 'needs_cleaning', 'reserved', 'out_of_service'));
+ALTER TABLE dining_tables ADD COLUMN IF NOT EXISTS status_changed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP;
+
+-- Number of guests seated with a dine-in order
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS covers INTEGER CHECK (covers > 0);
+
+-- +migrate Down
+ALTER TABLE orders DROP COLUMN IF EXISTS covers;
+ALTER TABLE dining_tables DROP COLUMN IF EXISTS status_changed_at;
+UPDATE dining_tables SET status = 'available' WHERE status = 'needs_cleaning';
+ALTER TABLE dining_tables DROP CONSTRAINT IF EXISTS dining_tables_status_check;
+ALTER TABLE dining_tables ADD CONSTRAINT dining_tables_status_check
+    CHECK (status IN ('available', 'occupied', 'reserved', 'out_of_service'));
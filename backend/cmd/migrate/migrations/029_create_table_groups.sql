@@ -0,0 +1,29 @@
+-- +migrate Up
+-- Tables pushed together for a large party. The group is a dining table of
+-- its own while it lasts, so orders can be seated at it; its members point to
+-- it through joined_to until the group is split again.
+ALTER TABLE dining_tables ADD COLUMN IF NOT EXISTS is_group BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE dining_tables ADD COLUMN IF NOT EXISTS joined_to UUID;
+
+CREATE INDEX IF NOT EXISTS idx_dining_tables_joined_to ON dining_tables(joined_to) WHERE joined_to IS NOT NULL;
+
+-- Every group ever formed, kept after the split so orders seated at a group
+-- can still be traced back to its tables
+CREATE TABLE IF NOT EXISTS table_groups (
+    id UUID PRIMARY KEY,
+    table_number VARCHAR(20) NOT NULL,
+    location VARCHAR(20),
+    seating_capacity INTEGER NOT NULL,
+    member_ids UUID[] NOT NULL,
+    created_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    split_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_table_groups_member_ids ON table_groups USING GIN (member_ids);
+
+-- +migrate Down
+DROP TABLE IF EXISTS table_groups;
+DELETE FROM dining_tables WHERE is_group = true;
+ALTER TABLE dining_tables DROP COLUMN IF EXISTS joined_to;
+ALTER TABLE dining_tables DROP COLUMN IF EXISTS is_group;
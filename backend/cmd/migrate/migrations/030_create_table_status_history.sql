@@ -0,0 +1,39 @@
+-- +migrate Up
+-- Every status a table has been in, for turn time and occupancy analytics
+CREATE TABLE IF NOT EXISTS table_status_history (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+    table_id UUID NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_table_status_history_table_id ON table_status_history(table_id, changed_at);
+CREATE INDEX IF NOT EXISTS idx_table_status_history_changed_at ON table_status_history(changed_at);
+
+-- Status changes are recorded whichever code path makes them. The wall clock
+-- is used so several changes in one transaction keep their order.
+CREATE OR REPLACE FUNCTION record_table_status() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' OR NEW.status IS DISTINCT FROM OLD.status THEN
+        INSERT INTO table_status_history (table_id, status, changed_at)
+        VALUES (NEW.id, NEW.status, clock_timestamp());
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS dining_tables_status_history ON dining_tables;
+CREATE TRIGGER dining_tables_status_history
+    AFTER INSERT OR UPDATE OF status ON dining_tables
+    FOR EACH ROW EXECUTE FUNCTION record_table_status();
+
+-- Start the history with the status every table is in now
+INSERT INTO table_status_history (table_id, status, changed_at)
+SELECT t.id, t.status, COALESCE(t.status_changed_at, CURRENT_TIMESTAMP)
+FROM dining_tables t
+WHERE NOT EXISTS (SELECT 1 FROM table_status_history h WHERE h.table_id = t.id);
+
+-- +migrate Down
+DROP TRIGGER IF EXISTS dining_tables_status_history ON dining_tables;
+DROP FUNCTION IF EXISTS record_table_status();
+DROP TABLE IF EXISTS table_status_history;
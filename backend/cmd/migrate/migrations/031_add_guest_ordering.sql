@@ -0,0 +1,42 @@
+-- +migrate Up
+-- Guests order from their phone through a signed QR code on the table. The
+-- nonce is part of the signed token, so replacing it revokes printed codes.
+ALTER TABLE dining_tables ADD COLUMN IF NOT EXISTS qr_nonce VARCHAR(32) NOT NULL DEFAULT substr(md5(random()::text), 1, 16);
+ALTER TABLE dining_tables ADD COLUMN IF NOT EXISTS qr_rotated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP;
+
+-- Where an order was taken: by staff on the POS or by a guest at the table
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS channel VARCHAR(20) NOT NULL DEFAULT 'staff' CHECK (channel IN ('staff', 'guest'));
+CREATE INDEX IF NOT EXISTS idx_orders_channel ON orders(channel);
+
+-- Guest orders can wait for a server to approve them before the kitchen sees them
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_status_check;
+ALTER TABLE orders ADD CONSTRAINT orders_status_check CHECK (status IN (
+    'awaiting_approval',
+    'pending',
+    'confirmed',
+    'preparing',
+    'ready',
+    'served',
+    'completed',
+    'cancelled'
+));
+
+ALTER TABLE settings ADD COLUMN IF NOT EXISTS guest_order_approval BOOLEAN NOT NULL DEFAULT false;
+
+-- +migrate Down
+ALTER TABLE settings DROP COLUMN IF EXISTS guest_order_approval;
+UPDATE orders SET status = 'cancelled' WHERE status = 'awaiting_approval';
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_status_check;
+ALTER TABLE orders ADD CONSTRAINT orders_status_check CHECK (status IN (
+    'pending',
+    'confirmed',
+    'preparing',
+    'ready',
+    'served',
+    'completed',
+    'cancelled'
+));
+DROP INDEX IF EXISTS idx_orders_channel;
+ALTER TABLE orders DROP COLUMN IF EXISTS channel;
+ALTER TABLE dining_tables DROP COLUMN IF EXISTS qr_rotated_at;
+ALTER TABLE dining_tables DROP COLUMN IF EXISTS qr_nonce;
@@ -0,0 +1,15 @@
+-- +migrate Up
+-- Guests view and pay their check through a signed link. The nonce is part of
+-- the signed token, so replacing it revokes links handed out before.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS check_nonce VARCHAR(32) NOT NULL DEFAULT substr(md5(random()::text), 1, 16);
+
+-- Dine-in orders carry the service charge from settings.service_charge_rate
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS service_charge_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+-- Tips are charged with the payment but are not part of the order total
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS tip_amount DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (tip_amount >= 0);
+
+-- +migrate Down
+ALTER TABLE payments DROP COLUMN IF EXISTS tip_amount;
+ALTER TABLE orders DROP COLUMN IF EXISTS service_charge_amount;
+ALTER TABLE orders DROP COLUMN IF EXISTS check_nonce;
@@ -0,0 +1,61 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS menus (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+    name VARCHAR(50) UNIQUE NOT NULL,
+    description TEXT,
+    sort_order INTEGER DEFAULT 0,
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- A daypart is when a menu is served, in settings.timezone. Days are 0 (Sunday)
+-- to 6 (Saturday); a window ending before it starts runs past midnight.
+CREATE TABLE IF NOT EXISTS dayparts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+    menu_id UUID NOT NULL REFERENCES menus(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    days_of_week SMALLINT[] NOT NULL DEFAULT '{0,1,2,3,4,5,6}',
+    start_time TIME NOT NULL,
+    end_time TIME NOT NULL,
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_dayparts_menu_id ON dayparts(menu_id);
+
+-- Categories and products without dayparts are always on the menu. Otherwise
+-- they can only be ordered while one of their dayparts is active.
+CREATE TABLE IF NOT EXISTS category_dayparts (
+    category_id UUID NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    daypart_id UUID NOT NULL REFERENCES dayparts(id) ON DELETE CASCADE,
+    PRIMARY KEY (category_id, daypart_id)
+);
+
+CREATE TABLE IF NOT EXISTS product_dayparts (
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    daypart_id UUID NOT NULL REFERENCES dayparts(id) ON DELETE CASCADE,
+    PRIMARY KEY (product_id, daypart_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_category_dayparts_daypart_id ON category_dayparts(daypart_id);
+CREATE INDEX IF NOT EXISTS idx_product_dayparts_daypart_id ON product_dayparts(daypart_id);
+
+INSERT INTO menus (name, description, sort_order) VALUES
+('Breakfast', 'Served in the morning', 1),
+('Late night', 'Served after 10pm', 2)
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO dayparts (menu_id, name, start_time, end_time)
+SELECT id, 'Breakfast', '07:00', '11:00' FROM menus
+WHERE name = 'Breakfast' AND NOT EXISTS (SELECT 1 FROM dayparts d WHERE d.menu_id = menus.id);
+INSERT INTO dayparts (menu_id, name, start_time, end_time)
+SELECT id, 'Late night', '22:00', '02:00' FROM menus
+WHERE name = 'Late night' AND NOT EXISTS (SELECT 1 FROM dayparts d WHERE d.menu_id = menus.id);
+
+-- +migrate Down
+DROP TABLE IF EXISTS product_dayparts;
+DROP TABLE IF EXISTS category_dayparts;
+DROP TABLE IF EXISTS dayparts;
+DROP TABLE IF EXISTS menus;
@@ -0,0 +1,37 @@
+-- +migrate Up
+-- Price rules change product prices during a weekly window in settings.timezone,
+-- e.g. half-price beverages 16:00-18:00 on weekdays. A rule applies to one
+-- product, to a category, or to every product when neither is set.
+CREATE TABLE IF NOT EXISTS price_rules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+    name VARCHAR(50) NOT NULL,
+    days_of_week SMALLINT[] NOT NULL DEFAULT '{0,1,2,3,4,5,6}',
+    start_time TIME NOT NULL,
+    end_time TIME NOT NULL,
+    category_id UUID REFERENCES categories(id) ON DELETE CASCADE,
+    product_id UUID REFERENCES products(id) ON DELETE CASCADE,
+    adjustment_type VARCHAR(20) NOT NULL CHECK (adjustment_type IN ('percent_off', 'amount_off', 'fixed_price')),
+    adjustment_value DECIMAL(10,2) NOT NULL CHECK (adjustment_value >= 0),
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_rules_category_id ON price_rules(category_id);
+CREATE INDEX IF NOT EXISTS idx_price_rules_product_id ON price_rules(product_id);
+
+-- Items keep the list price next to the price charged and the rule that set it
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS original_unit_price DECIMAL(10,2);
+UPDATE order_items SET original_unit_price = unit_price WHERE original_unit_price IS NULL;
+ALTER TABLE order_items ALTER COLUMN original_unit_price SET NOT NULL;
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS price_rule_id UUID REFERENCES price_rules(id) ON DELETE SET NULL;
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS price_rule_name VARCHAR(50);
+
+CREATE INDEX IF NOT EXISTS idx_order_items_price_rule_id ON order_items(price_rule_id);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_order_items_price_rule_id;
+ALTER TABLE order_items DROP COLUMN IF EXISTS price_rule_name;
+ALTER TABLE order_items DROP COLUMN IF EXISTS price_rule_id;
+ALTER TABLE order_items DROP COLUMN IF EXISTS original_unit_price;
+DROP TABLE IF EXISTS price_rules;
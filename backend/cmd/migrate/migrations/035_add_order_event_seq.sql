@@ -0,0 +1,15 @@
+-- +migrate Up
+-- Events are numbered by the broker after their transaction commits, so seq
+-- follows commit order and streams can resume from the highest seq they have
+-- seen. The serial id is assigned at insert and can commit out of order.
+CREATE SEQUENCE IF NOT EXISTS order_events_seq;
+ALTER TABLE order_events ADD COLUMN IF NOT EXISTS seq BIGINT;
+UPDATE order_events SET seq = id WHERE seq IS NULL;
+SELECT setval('order_events_seq', COALESCE((SELECT MAX(seq) FROM order_events), 0) + 1, false);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_order_events_seq ON order_events(seq);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_order_events_seq;
+ALTER TABLE order_events DROP COLUMN IF EXISTS seq;
+DROP SEQUENCE IF EXISTS order_events_seq;
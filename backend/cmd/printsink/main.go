@@ -0,0 +1,90 @@
+// Command printsink is a stand-in for a network ESC/POS printer. It listens on a
+// raw TCP port like a real printer and prints every received job to stdout with
+// the control sequences stripped, optionally saving the raw bytes to a directory.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func main() {
+	addr := flag.String("addr", ":9100", "address to listen on")
+	dir := flag.String("dir", "", "directory to save raw jobs to (optional)")
+	fail := flag.Bool("fail", false, "close connections without reading to simulate an offline printer")
+	flag.Parse()
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatalf("Failed to listen on %s: %v", *addr, err)
+	}
+	log.Printf("Print sink listening on %s", listener.Addr())
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			log.Printf("Accept failed: %v", err)
+			continue
+		}
+		go handle(conn, *dir, *fail)
+	}
+}
+
+func handle(conn net.Conn, dir string, fail bool) {
+	defer conn.Close()
+
+	if fail {
+		log.Printf("Rejected job from %s", conn.RemoteAddr())
+		return
+	}
+
+	conn.SetReadDeadline(time.Now().Add(30 * time.Second))
+	payload, err := io.ReadAll(conn)
+	if err != nil {
+		log.Printf("Failed to read job from %s: %v", conn.RemoteAddr(), err)
+		return
+	}
+
+	log.Printf("Received %d bytes from %s", len(payload), conn.RemoteAddr())
+	fmt.Println(strings.Repeat("=", 48))
+	fmt.Print(stripESCPOS(payload))
+	fmt.Println(strings.Repeat("=", 48))
+
+	if dir != "" {
+		name := filepath.Join(dir, fmt.Sprintf("job-%d.bin", time.Now().UnixNano()))
+		if err := os.WriteFile(name, payload, 0o644); err != nil {
+			log.Printf("Failed to save job: %v", err)
+		}
+	}
+}
+
+// stripESCPOS removes the ESC and GS command sequences emitted by the receipt renderer
+func stripESCPOS(payload []byte) string {
+	var b strings.Builder
+	for i := 0; i < len(payload); i++ {
+		switch payload[i] {
+		case 0x1b: // ESC @ takes no argument; ESC a, ESC E and ESC d take one
+			if i+1 < len(payload) && payload[i+1] == '@' {
+				i++
+			} else {
+				i += 2
+			}
+		case 0x1d: // GS ! takes one argument; GS V takes one or two
+			if i+1 < len(payload) && payload[i+1] == 'V' && i+2 < len(payload) && payload[i+2] >= 'A' {
+				i += 3
+			} else {
+				i += 2
+			}
+		default:
+			b.WriteByte(payload[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,88 @@
+// Package allergens defines the allergen and dietary vocabularies shared by the
+// menu, orders and kitchen tickets, and how guest allergies conflict with items.
+package allergens
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Allergens are the allergens that can be declared on products and modifiers
+// and flagged for a guest
+var Allergens = map[string]bool{
+	"gluten":    true,
+	"nuts":      true,
+	"peanuts":   true,
+	"dairy":     true,
+	"eggs":      true,
+	"soy":       true,
+	"fish":      true,
+	"shellfish": true,
+	"molluscs":  true,
+	"sesame":    true,
+	"celery":    true,
+	"mustard":   true,
+	"lupin":     true,
+	"sulphites": true,
+}
+
+// DietaryTags are the dietary attributes that can be declared on products and modifiers
+var DietaryTags = map[string]bool{
+	"vegan":       true,
+	"vegetarian":  true,
+	"halal":       true,
+	"kosher":      true,
+	"gluten_free": true,
+	"dairy_free":  true,
+}
+
+// Normalize lowercases and de-duplicates values and checks them against the
+// vocabulary, returning them sorted. kind names the vocabulary in errors.
+func Normalize(values []string, vocabulary map[string]bool, kind string) ([]string, error) {
+	seen := map[string]bool{}
+	normalized := []string{}
+	for _, value := range values {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" || seen[value] {
+			continue
+		}
+		if !vocabulary[value] {
+			return nil, fmt.Errorf("unknown %s %q", kind, value)
+		}
+		seen[value] = true
+		normalized = append(normalized, value)
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// Conflicts returns the guest allergies an item contains
+func Conflicts(itemAllergens, guestAllergies []string) []string {
+	conflicts := []string{}
+	for _, allergy := range guestAllergies {
+		for _, allergen := range itemAllergens {
+			if allergen == allergy {
+				conflicts = append(conflicts, allergy)
+				break
+			}
+		}
+	}
+	return conflicts
+}
+
+// Merge returns the sorted union of allergen lists
+func Merge(lists ...[]string) []string {
+	seen := map[string]bool{}
+	merged := []string{}
+	for _, list := range lists {
+		for _, value := range list {
+			if !seen[value] {
+				seen[value] = true
+				merged = append(merged, value)
+			}
+		}
+	}
+	sort.Strings(merged)
+	return merged
+}
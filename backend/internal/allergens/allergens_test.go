@@ -0,0 +1,58 @@
+package allergens
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	got, err := Normalize([]string{" Nuts", "gluten", "", "NUTS", "dairy "}, Allergens, "allergen")
+	if err != nil {
+		t.Fatalf("Normalize: %v", err)
+	}
+	if want := []string{"dairy", "gluten", "nuts"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Normalize = %v, want %v", got, want)
+	}
+
+	got, err = Normalize(nil, DietaryTags, "dietary tag")
+	if err != nil || got == nil || len(got) != 0 {
+		t.Errorf("Normalize(nil) = %v, %v, want an empty list", got, err)
+	}
+}
+
+func TestNormalizeRejectsUnknownValues(t *testing.T) {
+	_, err := Normalize([]string{"vegan", "paleo"}, DietaryTags, "dietary tag")
+	if err == nil || err.Error() != `unknown dietary tag "paleo"` {
+		t.Errorf("Normalize error = %v", err)
+	}
+
+	// Dietary tags are not allergens
+	if _, err := Normalize([]string{"vegan"}, Allergens, "allergen"); err == nil {
+		t.Error("Normalize accepted a dietary tag as an allergen")
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	tests := []struct {
+		item, guest []string
+		want        []string
+	}{
+		{[]string{"dairy", "gluten", "nuts"}, []string{"nuts", "soy", "dairy"}, []string{"nuts", "dairy"}},
+		{[]string{"gluten"}, []string{"nuts"}, []string{}},
+		{nil, []string{"nuts"}, []string{}},
+		{[]string{"nuts"}, nil, []string{}},
+	}
+
+	for _, tt := range tests {
+		if got := Conflicts(tt.item, tt.guest); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Conflicts(%v, %v) = %v, want %v", tt.item, tt.guest, got, tt.want)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	got := Merge([]string{"nuts", "dairy"}, nil, []string{"dairy", "eggs"})
+	if want := []string{"dairy", "eggs", "nuts"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge = %v, want %v", got, want)
+	}
+}
@@ -2,26 +2,44 @@ package api
 
 import (
 	"database/sql"
+	"time"
 
+	"pos-backend/internal/events"
+	"pos-backend/internal/gateway"
+	"pos-backend/internal/guestlink"
 	"pos-backend/internal/handlers"
 	"pos-backend/internal/middleware"
+	"pos-backend/internal/sms"
 
 	"github.com/gin-gonic/gin"
 )
 
 // SetupRoutes configures all API routes
-func SetupRoutes(router *gin.RouterGroup, db *sql.DB, authMiddleware gin.HandlerFunc) {
+func SetupRoutes(router *gin.RouterGroup, db *sql.DB, broker *events.Broker, authMiddleware gin.HandlerFunc) {
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(db)
 	orderHandler := handlers.NewOrderHandler(db)
 	productHandler := handlers.NewProductHandler(db)
-	paymentHandler := handlers.NewPaymentHandler(db)
+	providers := gateway.NewDefaultRegistry()
+	paymentHandler := handlers.NewPaymentHandler(db, providers)
 	tableHandler := handlers.NewTableHandler(db)
 	dashboardHandler := handlers.NewDashboardHandler(db)
 	adminHandler := handlers.NewAdminHandler(db)
 	kitchenHandler := handlers.NewKitchenHandler(db)
 	serverHandler := handlers.NewServerHandler(db)
 	settingsHandler := handlers.NewSettingsHandler(db)
+	giftCardHandler := handlers.NewGiftCardHandler(db)
+	receiptHandler := handlers.NewReceiptHandler(db)
+	printerHandler := handlers.NewPrinterHandler(db)
+	emailHandler := handlers.NewEmailHandler(db)
+	fiscalHandler := handlers.NewFiscalHandler(db)
+	streamHandler := handlers.NewStreamHandler(db, broker)
+	stationHandler := handlers.NewStationHandler(db)
+	reservationHandler := handlers.NewReservationHandler(db)
+	waitlistHandler := handlers.NewWaitlistHandler(db, sms.NewDefaultProvider())
+	menuHandler := handlers.NewMenuHandler(db)
+	priceRuleHandler := handlers.NewPriceRuleHandler(db)
+	guestHandler := handlers.NewGuestHandler(db, guestlink.NewDefaultSigner(), providers)
 
 	// Public routes (no authentication required)
 	public := router.Group("/")
@@ -29,6 +47,18 @@ func SetupRoutes(router *gin.RouterGroup, db *sql.DB, authMiddleware gin.Handler
 		// Authentication routes
 		public.POST("/auth/login", authHandler.Login)
 		public.POST("/auth/logout", authHandler.Logout)
+
+		// Payment provider callbacks (authenticated by HMAC signature)
+		public.POST("/payments/webhook/:provider", paymentHandler.HandleWebhook)
+	}
+
+	// Guest self-ordering and pay-at-table (authenticated by the signed table or check token)
+	guest := router.Group("/guest")
+	{
+		guest.GET("/tables/:token/menu", middleware.RateLimit(60, time.Minute), guestHandler.GetMenu)
+		guest.POST("/tables/:token/orders", middleware.RateLimit(10, time.Minute), guestHandler.PlaceOrder)
+		guest.GET("/checks/:token", middleware.RateLimit(60, time.Minute), guestHandler.GetCheck)
+		guest.POST("/checks/:token/payments", middleware.RateLimit(10, time.Minute), guestHandler.PayCheck) // Tip, pay in full or a share
 	}
 
 	// Protected routes (authentication required)
@@ -39,25 +69,39 @@ func SetupRoutes(router *gin.RouterGroup, db *sql.DB, authMiddleware gin.Handler
 		protected.GET("/auth/me", authHandler.GetCurrentUser)
 
 		// Product routes
-		protected.GET("/products", productHandler.GetProducts)
+		protected.GET("/products", productHandler.GetProducts) // ?allergen_free=gluten,nuts&dietary=vegan; managers can add daypart=all
 		protected.GET("/products/:id", productHandler.GetProduct)
 		protected.GET("/categories", productHandler.GetCategories)
 		protected.GET("/categories/:id/products", productHandler.GetProductsByCategory)
+		protected.GET("/dayparts/current", menuHandler.GetCurrentDayparts)
 
 		// Table routes
-		protected.GET("/tables", tableHandler.GetTables)
+		protected.GET("/tables", tableHandler.GetTables) // ?location=&server_id=&section=mine|<id>
 		protected.GET("/tables/:id", tableHandler.GetTable)
 		protected.GET("/tables/by-location", tableHandler.GetTablesByLocation)
 		protected.GET("/tables/status", tableHandler.GetTableStatus)
+		protected.GET("/tables/floor", tableHandler.GetFloor) // Live floor; ?location=&section=mine|<id>
+		protected.POST("/tables/:id/clean", tableHandler.MarkTableClean)
+		protected.GET("/tables/groups", tableHandler.GetTableGroups)
+		protected.POST("/tables/groups", tableHandler.JoinTables) // Push tables together; splits when its order closes
+		protected.DELETE("/tables/groups/:id", tableHandler.SplitTableGroup)
+		protected.GET("/floor-plans", tableHandler.GetFloorPlans) // ?location=
 
 		// Order routes (general view for all roles)
 		protected.GET("/orders", orderHandler.GetOrders)
 		protected.GET("/orders/:id", orderHandler.GetOrder)
 		protected.PATCH("/orders/:id/status", orderHandler.UpdateOrderStatus)
+		protected.POST("/orders/:id/approve", middleware.RequireRoles([]string{"server", "counter", "manager", "admin"}), guestHandler.ApproveOrder) // Send a guest order to the kitchen; reject by cancelling
+		protected.PUT("/orders/:id/allergies", orderHandler.UpdateGuestAllergies)
+		protected.GET("/orders/:id/receipt", receiptHandler.GetOrderReceipt) // ?format=text|escpos|html
+		protected.POST("/orders/:id/print", printerHandler.PrintOrder)
+		protected.GET("/print-jobs/:id", printerHandler.GetPrintJob)
+		protected.POST("/orders/:id/email-receipt", emailHandler.SendOrderReceipt) // Send or resend
 
 		// Payment routes (counter/admin only)
 		protected.GET("/orders/:id/payments", paymentHandler.GetPayments)
 		protected.GET("/orders/:id/payment-summary", paymentHandler.GetPaymentSummary)
+		protected.GET("/orders/:id/check-link", guestHandler.GetCheckLink) // Signed link guests pay the check from
 	}
 
 	// Server routes (server role - dine-in orders only)
@@ -66,6 +110,7 @@ func SetupRoutes(router *gin.RouterGroup, db *sql.DB, authMiddleware gin.Handler
 	server.Use(middleware.RequireRole("server"))
 	{
 		server.POST("/orders", serverHandler.CreateDineInOrder) // Only dine-in orders
+		server.GET("/stream", streamHandler.ServerStream)       // Server-Sent Events for own orders
 	}
 
 	// Counter routes (counter role - all order types and payments)
@@ -75,6 +120,9 @@ func SetupRoutes(router *gin.RouterGroup, db *sql.DB, authMiddleware gin.Handler
 	{
 		counter.POST("/orders", orderHandler.CreateOrder)                   // All order types
 		counter.POST("/orders/:id/payments", paymentHandler.ProcessPayment) // Process payments
+		counter.GET("/gift-cards/:code", giftCardHandler.GetGiftCard)
+		counter.GET("/gift-cards/:code/transactions", giftCardHandler.GetGiftCardTransactions)
+		counter.GET("/stream", streamHandler.CounterStream) // Server-Sent Events for all orders
 	}
 
 	// Admin routes (admin/manager only)
@@ -87,9 +135,21 @@ func SetupRoutes(router *gin.RouterGroup, db *sql.DB, authMiddleware gin.Handler
 		admin.GET("/reports/sales", dashboardHandler.GetSalesReport)
 		admin.GET("/reports/orders", dashboardHandler.GetOrdersReport)
 		admin.GET("/reports/income", dashboardHandler.GetIncomeReport)
+		admin.GET("/reports/gift-card-liability", giftCardHandler.GetGiftCardLiability)
+		admin.GET("/reports/x", dashboardHandler.GetXReport)
+		admin.GET("/reports/z", dashboardHandler.GetZReports)
+		admin.POST("/reports/z", dashboardHandler.CloseBusinessDay)
+		admin.GET("/reports/z/:number", dashboardHandler.GetZReport)
+		admin.GET("/reports/kitchen", kitchenHandler.GetPrepTimeReport) // ?group_by=product|station|hour&from=&to=
+		admin.GET("/reports/waste", kitchenHandler.GetWasteReport)
+		admin.GET("/reports/waitlist", waitlistHandler.GetWaitlistMetrics) // ?from=&to=
+		admin.GET("/reports/tables", tableHandler.GetTableAnalytics)       // ?from=&to=&location=
+		admin.GET("/fiscal/journal", fiscalHandler.GetFiscalJournal)
+		admin.GET("/fiscal/verify", fiscalHandler.VerifyFiscalJournal)
+		admin.GET("/fiscal/export", fiscalHandler.ExportFiscalJournal) // ?from=YYYY-MM-DD&to=YYYY-MM-DD
 
 		// Menu management with pagination
-		admin.GET("/products", productHandler.GetProducts) // Use existing paginated handler
+		admin.GET("/products", productHandler.GetAllProducts) // Paginated; includes products outside the current dayparts
 		admin.GET("/categories", adminHandler.GetAdminCategories)
 		admin.POST("/categories", adminHandler.CreateCategory)
 		admin.PUT("/categories/:id", adminHandler.UpdateCategory)
@@ -97,12 +157,56 @@ func SetupRoutes(router *gin.RouterGroup, db *sql.DB, authMiddleware gin.Handler
 		admin.POST("/products", adminHandler.CreateProduct)
 		admin.PUT("/products/:id", adminHandler.UpdateProduct)
 		admin.DELETE("/products/:id", adminHandler.DeleteProduct)
+		admin.PUT("/categories/:id/dayparts", menuHandler.SetCategoryDayparts) // Empty list serves it all day
+		admin.PUT("/products/:id/dayparts", menuHandler.SetProductDayparts)
+
+		// Menus and the dayparts they are served in
+		admin.GET("/menus", menuHandler.GetMenus)
+		admin.POST("/menus", menuHandler.CreateMenu)
+		admin.PUT("/menus/:id", menuHandler.UpdateMenu)
+		admin.DELETE("/menus/:id", menuHandler.DeleteMenu)
+		admin.POST("/menus/:id/dayparts", menuHandler.CreateDaypart)
+		admin.PUT("/dayparts/:id", menuHandler.UpdateDaypart)
+		admin.DELETE("/dayparts/:id", menuHandler.DeleteDaypart)
+
+		// Bulk menu export and import of categories, products (by SKU) and modifiers
+		admin.GET("/menu/export", menuHandler.ExportMenu)  // ?format=csv|json
+		admin.POST("/menu/import", menuHandler.ImportMenu) // ?format=csv|json&dry_run=true; all or nothing
+
+		// Price rules, e.g. happy hour; adjustment_type is percent_off, amount_off or fixed_price
+		admin.GET("/price-rules", priceRuleHandler.GetPriceRules)
+		admin.POST("/price-rules", priceRuleHandler.CreatePriceRule)
+		admin.PUT("/price-rules/:id", priceRuleHandler.UpdatePriceRule)
+		admin.DELETE("/price-rules/:id", priceRuleHandler.DeletePriceRule)
+
+		// Modifiers; allergens and dietary_tags are also set on products
+		admin.GET("/modifiers", adminHandler.GetModifiers) // ?product_id=
+		admin.POST("/modifiers", adminHandler.CreateModifier)
+		admin.PUT("/modifiers/:id", adminHandler.UpdateModifier)
+		admin.DELETE("/modifiers/:id", adminHandler.DeleteModifier)
+
+		// Kitchen stations; categories and products are assigned with station_id
+		admin.GET("/kitchen-stations", stationHandler.GetStations)
+		admin.POST("/kitchen-stations", stationHandler.CreateStation)
+		admin.PUT("/kitchen-stations/:id", stationHandler.UpdateStation)
+		admin.DELETE("/kitchen-stations/:id", stationHandler.DeleteStation)
 
 		// Table management with pagination
 		admin.GET("/tables", adminHandler.GetAdminTables)
 		admin.POST("/tables", adminHandler.CreateTable)
 		admin.PUT("/tables/:id", adminHandler.UpdateTable)
 		admin.DELETE("/tables/:id", adminHandler.DeleteTable)
+		admin.GET("/tables/:id/qr", guestHandler.GetTableQRCode)
+		admin.POST("/tables/:id/qr/rotate", guestHandler.RotateTableQRCode) // Invalidates the printed code
+		admin.PUT("/floor-plans/:location", adminHandler.SaveFloorPlan)
+		admin.DELETE("/floor-plans/:location", adminHandler.DeleteFloorPlan)
+		admin.PUT("/floor-plans/:location/layout", adminHandler.UpdateFloorLayout) // Positions several tables at once
+
+		// Server sections
+		admin.GET("/sections", adminHandler.GetSections) // ?date=YYYY-MM-DD
+		admin.POST("/sections", adminHandler.CreateSection)
+		admin.PUT("/sections/:id", adminHandler.UpdateSection)
+		admin.DELETE("/sections/:id", adminHandler.DeleteSection)
 
 		// User management with pagination
 		admin.GET("/users", adminHandler.GetAdminUsers)
@@ -110,6 +214,21 @@ func SetupRoutes(router *gin.RouterGroup, db *sql.DB, authMiddleware gin.Handler
 		admin.PUT("/users/:id", adminHandler.UpdateUser)
 		admin.DELETE("/users/:id", adminHandler.DeleteUser)
 
+		// Printer management and print queue
+		admin.GET("/printers", printerHandler.GetPrinters)
+		admin.POST("/printers", printerHandler.CreatePrinter)
+		admin.PUT("/printers/:id", printerHandler.UpdatePrinter)
+		admin.DELETE("/printers/:id", printerHandler.DeletePrinter)
+		admin.POST("/printers/:id/test", printerHandler.TestPrinter)
+		admin.GET("/print-jobs", printerHandler.GetPrintJobs)
+		admin.POST("/print-jobs/:id/retry", printerHandler.RetryPrintJob)
+
+		// Email receipts
+		admin.GET("/email-jobs", emailHandler.GetEmailJobs)
+		admin.GET("/email-templates/:name", emailHandler.GetEmailTemplate)
+		admin.PUT("/email-templates/:name", emailHandler.UpdateEmailTemplate)
+		admin.DELETE("/email-templates/:name", emailHandler.ResetEmailTemplate)
+
 		// Restaurant settings management
 		admin.GET("/settings", settingsHandler.GetSettings)
 		admin.PUT("/settings", settingsHandler.UpdateSettings)
@@ -117,6 +236,38 @@ func SetupRoutes(router *gin.RouterGroup, db *sql.DB, authMiddleware gin.Handler
 		// Advanced order management
 		admin.POST("/orders", orderHandler.CreateOrder)                   // Admins can create any type of order
 		admin.POST("/orders/:id/payments", paymentHandler.ProcessPayment) // Admins can process payments
+		admin.POST("/payments/:id/void", paymentHandler.VoidPayment)
+		admin.POST("/payments/:id/refund", paymentHandler.RefundPayment)
+		admin.GET("/gift-cards/:code", giftCardHandler.GetGiftCard)
+		admin.GET("/gift-cards/:code/transactions", giftCardHandler.GetGiftCardTransactions)
+	}
+
+	// Reservation routes (front of house)
+	reservations := router.Group("/reservations")
+	reservations.Use(authMiddleware)
+	reservations.Use(middleware.RequireRoles([]string{"server", "counter", "admin", "manager"}))
+	{
+		reservations.GET("/day", reservationHandler.GetDayView)                  // ?date=YYYY-MM-DD
+		reservations.GET("/availability", reservationHandler.SearchAvailability) // ?date=&time=HH:MM&party_size=&duration=&location=
+		reservations.POST("", reservationHandler.CreateReservation)
+		reservations.GET("/:id", reservationHandler.GetReservation)
+		reservations.PUT("/:id", reservationHandler.UpdateReservation)
+		reservations.POST("/:id/cancel", reservationHandler.CancelReservation)
+		reservations.POST("/:id/no-show", reservationHandler.MarkNoShow)
+		reservations.POST("/:id/seat", reservationHandler.SeatReservation) // Marks the table occupied; table_id overrides the booked table
+	}
+
+	// Waitlist routes (front of house)
+	waitlist := router.Group("/waitlist")
+	waitlist.Use(authMiddleware)
+	waitlist.Use(middleware.RequireRoles([]string{"server", "counter", "admin", "manager"}))
+	{
+		waitlist.GET("", waitlistHandler.GetWaitlist)
+		waitlist.GET("/quote", waitlistHandler.QuoteWait) // ?party_size=
+		waitlist.POST("", waitlistHandler.AddToWaitlist)
+		waitlist.POST("/:id/notify", waitlistHandler.NotifyParty) // Texts the party through the SMS provider
+		waitlist.POST("/:id/seat", waitlistHandler.SeatParty)     // Marks table_id occupied
+		waitlist.POST("/:id/remove", waitlistHandler.RemoveParty) // reason: left, no_response, declined or other
 	}
 
 	// Kitchen routes (kitchen staff access)
@@ -124,7 +275,17 @@ func SetupRoutes(router *gin.RouterGroup, db *sql.DB, authMiddleware gin.Handler
 	kitchen.Use(authMiddleware)
 	kitchen.Use(middleware.RequireRoles([]string{"kitchen", "admin", "manager"}))
 	{
-		kitchen.GET("/orders", kitchenHandler.GetKitchenOrders)
+		kitchen.GET("/orders", kitchenHandler.GetKitchenOrders)       // ?station=<id|name>
+		kitchen.GET("/orders/bumped", kitchenHandler.GetBumpedOrders) // ?minutes=30&station=
+		kitchen.POST("/orders/:id/recall", kitchenHandler.RecallOrder)
+		kitchen.POST("/orders/:id/remake", kitchenHandler.RemakeItems)
+		kitchen.GET("/expo", kitchenHandler.GetExpoView)
+		kitchen.GET("/stations", stationHandler.GetStations)
+		kitchen.GET("/86", kitchenHandler.GetEightySixList)
+		kitchen.POST("/86", kitchenHandler.EightySixProduct) // remaining_count, restore_at or restore_in_minutes are optional
+		kitchen.DELETE("/86/:product_id", kitchenHandler.RestoreProduct)
 		kitchen.PATCH("/orders/:id/items/:item_id/status", kitchenHandler.UpdateOrderItemStatus)
+		kitchen.GET("/orders/:id/ticket", receiptHandler.GetKitchenTicket) // ?format=text|escpos|html
+		kitchen.GET("/stream", streamHandler.KitchenStream)                // Server-Sent Events; resume with Last-Event-ID
 	}
 }
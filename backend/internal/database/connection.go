@@ -18,13 +18,16 @@ type Config struct {
 	SSLMode  string
 }
 
-func Connect(config Config) (*sql.DB, error) {
-	dsn := fmt.Sprintf(
+// DSN returns the lib/pq connection string for the configuration
+func (config Config) DSN() string {
+	return fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		config.Host, config.Port, config.User, config.Password, config.DBName, config.SSLMode,
 	)
+}
 
-	db, err := sql.Open("postgres", dsn)
+func Connect(config Config) (*sql.DB, error) {
+	db, err := sql.Open("postgres", config.DSN())
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database connection: %w", err)
 	}
@@ -0,0 +1,87 @@
+// Package dayparts decides when a daypart of a menu is served, e.g. breakfast
+// from 7 to 11am. Windows are in the store's local time; a window that ends
+// before it starts runs past midnight, like 22:00-02:00 for a late-night menu.
+package dayparts
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Window is the days of the week and the time of day a daypart is served
+type Window struct {
+	Days  []int  // 0 = Sunday ... 6 = Saturday; empty means every day
+	Start string // "15:04"
+	End   string // "15:04"; the same as Start means all day
+}
+
+// ParseClock returns the minutes since midnight of a "15:04" or "15:04:05" time
+func ParseClock(value string) (int, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	minute, err := strconv.Atoi(parts[1])
+	if err != nil || minute < 0 || minute > 59 {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return hour*60 + minute, nil
+}
+
+// Validate checks the days and times of a window
+func (w Window) Validate() error {
+	for _, day := range w.Days {
+		if day < 0 || day > 6 {
+			return fmt.Errorf("invalid day of week %d, expected 0 (Sunday) to 6 (Saturday)", day)
+		}
+	}
+	if _, err := ParseClock(w.Start); err != nil {
+		return err
+	}
+	_, err := ParseClock(w.End)
+	return err
+}
+
+// Contains reports whether local, a time in the store's timezone, is in the
+// window. The hours after midnight of an overnight window belong to the day
+// it started on, so Friday 22:00-02:00 covers early Saturday morning.
+func (w Window) Contains(local time.Time) bool {
+	start, err := ParseClock(w.Start)
+	if err != nil {
+		return false
+	}
+	end, err := ParseClock(w.End)
+	if err != nil {
+		return false
+	}
+
+	day := int(local.Weekday())
+	minute := local.Hour()*60 + local.Minute()
+
+	switch {
+	case start == end:
+		return w.servedOn(day)
+	case start < end:
+		return w.servedOn(day) && minute >= start && minute < end
+	default:
+		return (w.servedOn(day) && minute >= start) || (w.servedOn((day+6)%7) && minute < end)
+	}
+}
+
+func (w Window) servedOn(day int) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
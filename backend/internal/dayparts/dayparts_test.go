@@ -0,0 +1,75 @@
+package dayparts
+
+import (
+	"testing"
+	"time"
+)
+
+// at returns a time on the week of Sunday 2024-05-05
+func at(weekday time.Weekday, clock string) time.Time {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		panic(err)
+	}
+	return time.Date(2024, 5, 5+int(weekday), t.Hour(), t.Minute(), 0, 0, time.UTC)
+}
+
+func TestWindowContains(t *testing.T) {
+	breakfast := Window{Start: "07:00", End: "11:00"}
+	weekendBrunch := Window{Days: []int{0, 6}, Start: "10:00", End: "14:00"}
+	lateNight := Window{Days: []int{5}, Start: "22:00", End: "02:00"} // Friday night
+	allDay := Window{Days: []int{1}, Start: "00:00", End: "00:00"}
+
+	tests := []struct {
+		name   string
+		window Window
+		time   time.Time
+		want   bool
+	}{
+		{"breakfast start", breakfast, at(time.Tuesday, "07:00"), true},
+		{"breakfast end is exclusive", breakfast, at(time.Tuesday, "11:00"), false},
+		{"before breakfast", breakfast, at(time.Tuesday, "06:59"), false},
+		{"brunch on Saturday", weekendBrunch, at(time.Saturday, "12:00"), true},
+		{"brunch on Sunday", weekendBrunch, at(time.Sunday, "12:00"), true},
+		{"no brunch on Monday", weekendBrunch, at(time.Monday, "12:00"), false},
+		{"late night before midnight", lateNight, at(time.Friday, "23:30"), true},
+		{"late night after midnight", lateNight, at(time.Saturday, "01:59"), true},
+		{"late night ends", lateNight, at(time.Saturday, "02:00"), false},
+		{"late night is not Saturday's", lateNight, at(time.Saturday, "23:00"), false},
+		{"early Friday belongs to Thursday", lateNight, at(time.Friday, "01:00"), false},
+		{"all day", allDay, at(time.Monday, "23:59"), true},
+		{"all day on other days", allDay, at(time.Tuesday, "12:00"), false},
+		{"overnight across the week", Window{Days: []int{6}, Start: "20:00", End: "03:00"}, at(time.Sunday, "02:00"), true},
+		{"invalid time", Window{Start: "25:00", End: "03:00"}, at(time.Sunday, "02:00"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.window.Contains(tt.time); got != tt.want {
+				t.Errorf("Contains(%s) = %v, want %v", tt.time.Format("Mon 15:04"), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	for value, want := range map[string]int{"00:00": 0, "07:30": 450, "23:59:59": 1439} {
+		if got, err := ParseClock(value); err != nil || got != want {
+			t.Errorf("ParseClock(%q) = %d, %v, want %d", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"", "7", "24:00", "12:60", "ab:cd", "1:2:3:4"} {
+		if _, err := ParseClock(value); err == nil {
+			t.Errorf("ParseClock(%q) succeeded", value)
+		}
+	}
+}
+
+func TestWindowValidate(t *testing.T) {
+	if err := (Window{Days: []int{0, 6}, Start: "22:00", End: "02:00"}).Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+	if err := (Window{Days: []int{7}, Start: "22:00", End: "02:00"}).Validate(); err == nil {
+		t.Error("Validate accepted day 7")
+	}
+}
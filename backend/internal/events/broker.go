@@ -0,0 +1,161 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// subscriberBuffer is how many events a slow client may fall behind before it is
+// disconnected; it then reconnects and catches up with Last-Event-ID
+const subscriberBuffer = 64
+
+// retention is how long events are kept for replay
+const retention = "1 day"
+
+// Broker fans out events from Postgres LISTEN/NOTIFY to the streams connected to
+// this backend instance. Every instance runs its own broker, so an event published
+// by any instance reaches every connected client.
+type Broker struct {
+	db       *sql.DB
+	listener *pq.Listener
+
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+	lastID      int64
+}
+
+// NewBroker creates a broker that listens on a dedicated connection opened with dsn
+func NewBroker(db *sql.DB, dsn string) *Broker {
+	b := &Broker{
+		db:          db,
+		subscribers: make(map[chan Event]struct{}),
+	}
+	b.listener = pq.NewListener(dsn, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("event broker: listener: %v", err)
+		}
+	})
+	return b
+}
+
+// Start begins listening and dispatching until ctx is cancelled
+func (b *Broker) Start(ctx context.Context) error {
+	if err := b.listener.Listen(Channel); err != nil {
+		return err
+	}
+
+	// Only events published after startup are dispatched live; older ones are
+	// served from the table when a client resumes
+	if err := sequence(b.db); err != nil {
+		return err
+	}
+	if err := b.db.QueryRow("SELECT COALESCE(MAX(seq), 0) FROM order_events").Scan(&b.lastID); err != nil {
+		return err
+	}
+
+	go b.run(ctx)
+	return nil
+}
+
+// Subscribe registers a new stream. The channel is closed when the stream falls
+// too far behind or the broker stops; cancel must be called when the client leaves.
+func (b *Broker) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (b *Broker) run(ctx context.Context) {
+	ping := time.NewTicker(90 * time.Second)
+	cleanup := time.NewTicker(time.Hour)
+	defer ping.Stop()
+	defer cleanup.Stop()
+	defer b.listener.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			b.closeAll()
+			return
+
+		case <-b.listener.Notify:
+			// A nil notification means the connection was re-established; either
+			// way the new events are numbered and read back from the table
+			b.catchUp()
+
+		case <-ping.C:
+			go b.listener.Ping()
+
+		case <-cleanup.C:
+			if _, err := b.db.Exec("DELETE FROM order_events WHERE created_at < CURRENT_TIMESTAMP - $1::interval", retention); err != nil {
+				log.Printf("event broker: failed to prune events: %v", err)
+			}
+		}
+	}
+}
+
+// catchUp numbers the events committed since the last notification and
+// dispatches every event newer than the last one dispatched
+func (b *Broker) catchUp() {
+	if err := sequence(b.db); err != nil {
+		log.Printf("event broker: failed to number events: %v", err)
+		return
+	}
+
+	b.mu.Lock()
+	lastID := b.lastID
+	b.mu.Unlock()
+
+	missed, err := Since(b.db, lastID, 1000)
+	if err != nil {
+		log.Printf("event broker: failed to catch up: %v", err)
+		return
+	}
+	for _, event := range missed {
+		b.dispatch(event)
+	}
+}
+
+func (b *Broker) dispatch(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if event.ID > b.lastID {
+		b.lastID = event.ID
+	}
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// Too slow: drop the subscriber so it reconnects and resumes
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (b *Broker) closeAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
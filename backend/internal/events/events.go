@@ -0,0 +1,161 @@
+package events
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Channel is the Postgres NOTIFY channel announcing new events
+const Channel = "order_events"
+
+// sequenceLock is the advisory lock held while committed events are numbered.
+// Only the brokers numbering events queue on it, never the publishers.
+const sequenceLock = 51072024
+
+// Event types
+const (
+	OrderCreated       = "order.created"
+	OrderStatusChanged = "order.status_changed"
+	OrderCancelled     = "order.cancelled"
+	OrderReady         = "order.ready"
+	ItemStatusChanged  = "order.item_status_changed"
+	OrderLate          = "order.late"
+	OrderRecalled      = "order.recalled"
+	ItemsRemade        = "order.items_remade"
+	AllergiesChanged   = "order.allergies_changed"
+	ProductEightySixed = "product.86"
+	ProductRestored    = "product.restored"
+)
+
+// Event is a change to an order or to a product's availability pushed to the
+// live views. IDs are the seq the event was numbered with after it committed;
+// they increase in commit order and are used as the SSE id for Last-Event-ID
+// resume.
+type Event struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	OrderID   *uuid.UUID      `json:"order_id,omitempty"`
+	ProductID *uuid.UUID      `json:"product_id,omitempty"`
+	UserID    *uuid.UUID      `json:"user_id"` // staff member who owns the order
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// IsOrderEvent reports whether the event concerns a single order rather than the menu
+func (e Event) IsOrderEvent() bool {
+	return e.OrderID != nil
+}
+
+// Execer is satisfied by *sql.DB and *sql.Tx
+type Execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Publish stores an event for an order and notifies every listening backend.
+// Inside a transaction the notification is only delivered on commit, so
+// subscribers never see events for changes that were rolled back.
+func Publish(q Execer, eventType string, orderID uuid.UUID, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	var id int64
+	err = q.QueryRow(`
+		INSERT INTO order_events (event_type, order_id, user_id, payload)
+		SELECT $1, id, user_id, $3
+		FROM orders
+		WHERE id = $2
+		RETURNING id
+	`, eventType, orderID, payload).Scan(&id)
+	if err != nil {
+		return err
+	}
+
+	return notify(q)
+}
+
+// PublishProduct stores a menu availability event for a product and notifies
+// every listening backend. Like Publish it is delivered on commit.
+func PublishProduct(q Execer, eventType string, productID uuid.UUID, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.Exec(`
+		INSERT INTO order_events (event_type, product_id, payload)
+		VALUES ($1, $2, $3)
+	`, eventType, productID, payload)
+	if err != nil {
+		return err
+	}
+
+	return notify(q)
+}
+
+func notify(q Execer) error {
+	_, err := q.Exec("SELECT pg_notify($1, '')", Channel)
+	return err
+}
+
+// sequence numbers the committed events that have no seq yet. Numbering runs
+// in one transaction at a time and after the events committed, so an event
+// whose transaction commits late still gets a higher seq than every event a
+// stream may already have seen.
+func sequence(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", sequenceLock); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		UPDATE order_events e SET seq = numbered.seq
+		FROM (
+			SELECT id, nextval('order_events_seq') AS seq
+			FROM (SELECT id FROM order_events WHERE seq IS NULL ORDER BY id) pending
+		) numbered
+		WHERE e.id = numbered.id
+	`)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Since returns up to limit numbered events newer than lastID, oldest first
+func Since(q Execer, lastID int64, limit int) ([]Event, error) {
+	rows, err := q.Query(`
+		SELECT seq, event_type, order_id, product_id, user_id, payload, created_at
+		FROM order_events
+		WHERE seq > $1
+		ORDER BY seq
+		LIMIT $2
+	`, lastID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var event Event
+		var payload []byte
+		if err := rows.Scan(&event.ID, &event.Type, &event.OrderID, &event.ProductID, &event.UserID, &payload, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		event.Data = payload
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,232 @@
+package fiscal
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Journal entry types
+const (
+	EntrySale   = "sale"
+	EntryRefund = "refund"
+)
+
+// GenesisHash is the previous hash of the first journal entry
+var GenesisHash = strings.Repeat("0", 64)
+
+// Entry is one line of the fiscal journal
+type Entry struct {
+	FiscalNumber int64      `json:"fiscal_number"`
+	EntryType    string     `json:"entry_type"`
+	OrderID      uuid.UUID  `json:"order_id"`
+	OrderNumber  string     `json:"order_number"`
+	PaymentID    *uuid.UUID `json:"payment_id"`
+	RefundID     *uuid.UUID `json:"refund_id"`
+	Amount       float64    `json:"amount"`
+	TaxAmount    float64    `json:"tax_amount"`
+	Currency     string     `json:"currency"`
+	CreatedAt    time.Time  `json:"created_at"`
+	PreviousHash string     `json:"previous_hash"`
+	Hash         string     `json:"hash"`
+}
+
+// Problem is an inconsistency found while verifying the journal
+type Problem struct {
+	FiscalNumber int64  `json:"fiscal_number"`
+	Kind         string `json:"kind"` // gap, broken_chain, modified
+	Detail       string `json:"detail"`
+}
+
+// Canonical returns the exact text that is hashed for an entry. Amounts are
+// fixed to two decimals and times to UTC microseconds, matching the column precision.
+func (e *Entry) Canonical() string {
+	return strings.Join([]string{
+		strconv.FormatInt(e.FiscalNumber, 10),
+		e.EntryType,
+		e.OrderID.String(),
+		e.OrderNumber,
+		optionalID(e.PaymentID),
+		optionalID(e.RefundID),
+		strconv.FormatFloat(e.Amount, 'f', 2, 64),
+		strconv.FormatFloat(e.TaxAmount, 'f', 2, 64),
+		e.Currency,
+		e.CreatedAt.UTC().Format("2006-01-02T15:04:05.000000Z"),
+	}, "|")
+}
+
+// ComputeHash chains the entry to the previous one
+func (e *Entry) ComputeHash() string {
+	sum := sha256.Sum256([]byte(e.PreviousHash + "|" + e.Canonical()))
+	return hex.EncodeToString(sum[:])
+}
+
+// Append assigns the next fiscal number to the entry and writes it to the journal.
+// It must run in the transaction that records the sale or refund: the table lock
+// serializes writers and a rollback releases the number, so numbers stay gapless.
+func Append(tx *sql.Tx, entry *Entry) error {
+	if _, err := tx.Exec("LOCK TABLE fiscal_journal IN EXCLUSIVE MODE"); err != nil {
+		return err
+	}
+
+	entry.FiscalNumber = 1
+	entry.PreviousHash = GenesisHash
+	err := tx.QueryRow(`
+		SELECT fiscal_number + 1, hash
+		FROM fiscal_journal
+		ORDER BY fiscal_number DESC
+		LIMIT 1
+	`).Scan(&entry.FiscalNumber, &entry.PreviousHash)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	entry.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	entry.Amount = round2(entry.Amount)
+	entry.TaxAmount = round2(entry.TaxAmount)
+	entry.Hash = entry.ComputeHash()
+
+	_, err = tx.Exec(`
+		INSERT INTO fiscal_journal (
+			fiscal_number, entry_type, order_id, order_number, payment_id, refund_id,
+			amount, tax_amount, currency, created_at, previous_hash, hash
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`, entry.FiscalNumber, entry.EntryType, entry.OrderID, entry.OrderNumber, entry.PaymentID, entry.RefundID,
+		entry.Amount, entry.TaxAmount, entry.Currency, entry.CreatedAt, entry.PreviousHash, entry.Hash)
+	return err
+}
+
+// Verify walks the whole journal and reports gaps in the numbering, entries that
+// do not point at their predecessor and entries whose content no longer matches
+// their hash. It returns the number of entries checked.
+func Verify(db *sql.DB) (int, []Problem, error) {
+	rows, err := db.Query(`
+		SELECT fiscal_number, entry_type, order_id, order_number, payment_id, refund_id,
+		       amount, tax_amount, currency, created_at, previous_hash, hash
+		FROM fiscal_journal
+		ORDER BY fiscal_number
+	`)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer rows.Close()
+
+	chain := newChain()
+	checked := 0
+	for rows.Next() {
+		entry, err := scanEntry(rows)
+		if err != nil {
+			return checked, nil, err
+		}
+		checked++
+		chain.check(entry)
+	}
+
+	return checked, chain.problems, rows.Err()
+}
+
+// chain follows the journal entry by entry, in fiscal number order
+type chain struct {
+	expectedNumber   int64
+	expectedPrevious string
+	problems         []Problem
+}
+
+func newChain() *chain {
+	return &chain{expectedNumber: 1, expectedPrevious: GenesisHash, problems: []Problem{}}
+}
+
+// check records the problems of the next entry
+func (c *chain) check(entry *Entry) {
+	if entry.FiscalNumber != c.expectedNumber {
+		c.problems = append(c.problems, Problem{
+			FiscalNumber: entry.FiscalNumber,
+			Kind:         "gap",
+			Detail:       fmt.Sprintf("expected fiscal number %d", c.expectedNumber),
+		})
+	}
+	if entry.PreviousHash != c.expectedPrevious {
+		c.problems = append(c.problems, Problem{
+			FiscalNumber: entry.FiscalNumber,
+			Kind:         "broken_chain",
+			Detail:       "previous hash does not match the preceding entry",
+		})
+	}
+	if computed := entry.ComputeHash(); computed != entry.Hash {
+		c.problems = append(c.problems, Problem{
+			FiscalNumber: entry.FiscalNumber,
+			Kind:         "modified",
+			Detail:       "stored hash does not match the entry content",
+		})
+	}
+
+	c.expectedNumber = entry.FiscalNumber + 1
+	c.expectedPrevious = entry.Hash
+}
+
+// Export writes the journal entries created in [from, to) as a pipe-delimited
+// flat file: one header line, then the canonical fields followed by both hashes.
+func Export(db *sql.DB, w io.Writer, from, to time.Time) (int, error) {
+	rows, err := db.Query(`
+		SELECT fiscal_number, entry_type, order_id, order_number, payment_id, refund_id,
+		       amount, tax_amount, currency, created_at, previous_hash, hash
+		FROM fiscal_journal
+		WHERE created_at >= $1 AND created_at < $2
+		ORDER BY fiscal_number
+	`, from, to)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "FISCAL_NUMBER|TYPE|ORDER_ID|ORDER_NUMBER|PAYMENT_ID|REFUND_ID|AMOUNT|TAX|CURRENCY|CREATED_AT|PREVIOUS_HASH|HASH")
+
+	count := 0
+	for rows.Next() {
+		entry, err := scanEntry(rows)
+		if err != nil {
+			return count, err
+		}
+		fmt.Fprintf(out, "%s|%s|%s\n", entry.Canonical(), entry.PreviousHash, entry.Hash)
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, err
+	}
+
+	return count, out.Flush()
+}
+
+func scanEntry(rows *sql.Rows) (*Entry, error) {
+	var entry Entry
+	err := rows.Scan(
+		&entry.FiscalNumber, &entry.EntryType, &entry.OrderID, &entry.OrderNumber,
+		&entry.PaymentID, &entry.RefundID, &entry.Amount, &entry.TaxAmount, &entry.Currency,
+		&entry.CreatedAt, &entry.PreviousHash, &entry.Hash,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func optionalID(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
+
+func round2(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,108 @@
+package fiscal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// journal builds n correctly chained sale entries
+func journal(n int) []*Entry {
+	entries := []*Entry{}
+	previous := GenesisHash
+	for i := 1; i <= n; i++ {
+		entry := &Entry{
+			FiscalNumber: int64(i),
+			EntryType:    EntrySale,
+			OrderID:      uuid.New(),
+			OrderNumber:  "ORD-1",
+			Amount:       12.5,
+			TaxAmount:    1.25,
+			Currency:     "USD",
+			CreatedAt:    time.Date(2024, 5, 1, 12, 0, i, 0, time.UTC),
+			PreviousHash: previous,
+		}
+		entry.Hash = entry.ComputeHash()
+		previous = entry.Hash
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func verify(entries []*Entry) []Problem {
+	chain := newChain()
+	for _, entry := range entries {
+		chain.check(entry)
+	}
+	return chain.problems
+}
+
+func TestVerifyIntactJournal(t *testing.T) {
+	if problems := verify(journal(3)); len(problems) != 0 {
+		t.Errorf("intact journal has problems: %+v", problems)
+	}
+}
+
+func TestVerifyFindsProblems(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func([]*Entry) []*Entry
+		number int64
+		kinds  []string
+	}{
+		{
+			name: "modified amount",
+			tamper: func(entries []*Entry) []*Entry {
+				entries[1].Amount = 1
+				return entries
+			},
+			number: 2,
+			kinds:  []string{"modified"},
+		},
+		{
+			name: "deleted entry",
+			tamper: func(entries []*Entry) []*Entry {
+				return append(entries[:1], entries[2:]...)
+			},
+			number: 3,
+			kinds:  []string{"gap", "broken_chain"},
+		},
+		{
+			name: "rehashed entry",
+			tamper: func(entries []*Entry) []*Entry {
+				entries[1].Amount = 1
+				entries[1].Hash = entries[1].ComputeHash()
+				return entries
+			},
+			number: 3,
+			kinds:  []string{"broken_chain"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := verify(tt.tamper(journal(3)))
+			if len(problems) != len(tt.kinds) {
+				t.Fatalf("got %d problems, want %v: %+v", len(problems), tt.kinds, problems)
+			}
+			for i, problem := range problems {
+				if problem.FiscalNumber != tt.number || problem.Kind != tt.kinds[i] {
+					t.Errorf("problem %d = %+v, want %s at %d", i, problem, tt.kinds[i], tt.number)
+				}
+			}
+		})
+	}
+}
+
+func TestCanonicalRoundsToColumnPrecision(t *testing.T) {
+	entry := journal(1)[0]
+	hash := entry.ComputeHash()
+
+	// Postgres hands back the amount and time at column precision
+	entry.CreatedAt = entry.CreatedAt.In(time.FixedZone("CEST", 2*60*60))
+	entry.Amount = 12.500000001
+	if entry.ComputeHash() != hash {
+		t.Error("hash changed with the time zone or beyond two decimals")
+	}
+}
@@ -0,0 +1,177 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Mock outcomes are selected by the cents of the charged amount, the same way
+// real gateways use magic test card numbers:
+//   - x.51 is declined during authorization
+//   - x.52 times out and is later reported as failed through the webhook
+//   - anything else is approved and reported as completed through the webhook
+const (
+	MockDeclineCents = 51
+	MockTimeoutCents = 52
+)
+
+// MockProvider is a local stand-in for a card processor. Captures are confirmed
+// asynchronously by posting a signed WebhookEvent to WebhookURL.
+type MockProvider struct {
+	WebhookURL string
+	Delay      time.Duration
+	registry   *Registry
+	client     *http.Client
+
+	mu           sync.Mutex
+	transactions map[string]float64
+}
+
+// NewMockProvider creates a mock provider that signs its webhooks with the registry secret
+func NewMockProvider(registry *Registry, webhookURL string, delay time.Duration) *MockProvider {
+	return &MockProvider{
+		WebhookURL:   webhookURL,
+		Delay:        delay,
+		registry:     registry,
+		client:       &http.Client{Timeout: 10 * time.Second},
+		transactions: make(map[string]float64),
+	}
+}
+
+func (p *MockProvider) Name() string {
+	return "mock"
+}
+
+func (p *MockProvider) Authorize(ctx context.Context, req PaymentRequest) (*PaymentResult, error) {
+	transactionID := "mock_" + uuid.New().String()
+
+	switch mockCents(req.Amount) {
+	case MockDeclineCents:
+		return &PaymentResult{
+			TransactionID: transactionID,
+			Status:        StatusFailed,
+			Message:       "Card declined by issuer",
+		}, ErrDeclined
+	case MockTimeoutCents:
+		p.deliver(WebhookEvent{
+			TransactionID: transactionID,
+			Status:        StatusFailed,
+			Amount:        req.Amount,
+			Message:       "Authorization timed out at issuer",
+		})
+		return &PaymentResult{
+			TransactionID: transactionID,
+			Status:        StatusProcessing,
+			Message:       "Awaiting issuer response",
+		}, ErrTimeout
+	}
+
+	p.mu.Lock()
+	p.transactions[transactionID] = req.Amount
+	p.mu.Unlock()
+
+	return &PaymentResult{
+		TransactionID: transactionID,
+		Status:        StatusProcessing,
+		Message:       "Authorized",
+	}, nil
+}
+
+func (p *MockProvider) Capture(ctx context.Context, transactionID string, amount float64) (*PaymentResult, error) {
+	p.mu.Lock()
+	authorized, ok := p.transactions[transactionID]
+	p.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown transaction %s", transactionID)
+	}
+	if amount > authorized {
+		return nil, fmt.Errorf("capture amount %.2f exceeds authorized %.2f", amount, authorized)
+	}
+
+	p.deliver(WebhookEvent{
+		TransactionID: transactionID,
+		Status:        StatusCompleted,
+		Amount:        amount,
+		Message:       "Captured",
+	})
+
+	return &PaymentResult{
+		TransactionID: transactionID,
+		Status:        StatusProcessing,
+		Message:       "Capture submitted",
+	}, nil
+}
+
+func (p *MockProvider) Void(ctx context.Context, transactionID string) (*PaymentResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.transactions[transactionID]; !ok {
+		return nil, fmt.Errorf("unknown transaction %s", transactionID)
+	}
+	delete(p.transactions, transactionID)
+
+	return &PaymentResult{
+		TransactionID: transactionID,
+		Status:        StatusFailed,
+		Message:       "Authorization voided",
+	}, nil
+}
+
+func (p *MockProvider) Refund(ctx context.Context, transactionID string, amount float64) (*PaymentResult, error) {
+	return &PaymentResult{
+		TransactionID: transactionID,
+		Status:        StatusRefunded,
+		Message:       fmt.Sprintf("Refunded %.2f", amount),
+	}, nil
+}
+
+// deliver posts the event to the webhook endpoint after the configured delay
+func (p *MockProvider) deliver(event WebhookEvent) {
+	if p.WebhookURL == "" {
+		return
+	}
+	event.Provider = p.Name()
+
+	go func() {
+		time.Sleep(p.Delay)
+
+		body, err := json.Marshal(event)
+		if err != nil {
+			log.Printf("mock gateway: failed to encode webhook: %v", err)
+			return
+		}
+
+		req, err := http.NewRequest(http.MethodPost, p.WebhookURL, bytes.NewReader(body))
+		if err != nil {
+			log.Printf("mock gateway: failed to build webhook request: %v", err)
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(SignatureHeader, p.registry.Sign(body))
+
+		resp, err := p.client.Do(req)
+		if err != nil {
+			log.Printf("mock gateway: webhook delivery failed: %v", err)
+			return
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode >= 300 {
+			log.Printf("mock gateway: webhook for %s rejected with status %d", event.TransactionID, resp.StatusCode)
+		}
+	}()
+}
+
+func mockCents(amount float64) int {
+	return int(math.Round(amount*100)) % 100
+}
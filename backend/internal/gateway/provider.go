@@ -0,0 +1,146 @@
+package gateway
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"pos-backend/internal/util"
+
+	"github.com/google/uuid"
+)
+
+// Payment statuses shared with the payments table
+const (
+	StatusPending    = "pending"
+	StatusProcessing = "processing"
+	StatusCompleted  = "completed"
+	StatusFailed     = "failed"
+	StatusRefunded   = "refunded"
+)
+
+// SignatureHeader carries the HMAC-SHA256 signature of a webhook body
+const SignatureHeader = "X-Payment-Signature"
+
+var (
+	// ErrDeclined is returned when the provider refuses the transaction
+	ErrDeclined = errors.New("payment declined")
+	// ErrTimeout is returned when the provider did not answer in time; the
+	// final outcome will arrive later through the webhook
+	ErrTimeout = errors.New("payment provider timeout")
+	// ErrNoProvider is returned when no provider is registered for a payment method
+	ErrNoProvider = errors.New("no payment provider for method")
+)
+
+// PaymentRequest describes a charge sent to a provider
+type PaymentRequest struct {
+	PaymentID uuid.UUID
+	OrderID   uuid.UUID
+	Method    string
+	Amount    float64
+	Currency  string
+	Reference *string
+}
+
+// PaymentResult is the provider's answer to an operation
+type PaymentResult struct {
+	TransactionID string
+	Status        string // processing, completed, failed, refunded
+	Message       string
+}
+
+// WebhookEvent is the payload providers post back to report asynchronous status changes
+type WebhookEvent struct {
+	Provider      string  `json:"provider"`
+	TransactionID string  `json:"transaction_id"`
+	Status        string  `json:"status"`
+	Amount        float64 `json:"amount"`
+	Message       string  `json:"message"`
+}
+
+// PaymentProvider is implemented by every card/wallet processor integration
+type PaymentProvider interface {
+	// Name identifies the provider in the payments table and webhook URLs
+	Name() string
+	// Authorize reserves the amount on the customer's instrument
+	Authorize(ctx context.Context, req PaymentRequest) (*PaymentResult, error)
+	// Capture settles a previously authorized transaction
+	Capture(ctx context.Context, transactionID string, amount float64) (*PaymentResult, error)
+	// Void cancels an authorization that has not been captured yet
+	Void(ctx context.Context, transactionID string) (*PaymentResult, error)
+	// Refund returns all or part of a captured amount
+	Refund(ctx context.Context, transactionID string, amount float64) (*PaymentResult, error)
+}
+
+// Registry maps payment methods to the provider that processes them
+type Registry struct {
+	byMethod map[string]PaymentProvider
+	byName   map[string]PaymentProvider
+	secret   []byte
+}
+
+// NewRegistry creates an empty registry; secret is used to sign and verify webhooks
+func NewRegistry(secret string) *Registry {
+	return &Registry{
+		byMethod: make(map[string]PaymentProvider),
+		byName:   make(map[string]PaymentProvider),
+		secret:   []byte(secret),
+	}
+}
+
+// NewDefaultRegistry builds the registry used by the API. Until a real processor
+// is configured, card and wallet methods are routed to the mock provider.
+func NewDefaultRegistry() *Registry {
+	registry := NewRegistry(util.FromEnv("PAYMENT_WEBHOOK_SECRET", "change-this-webhook-secret-in-production"))
+
+	webhookURL := util.FromEnv("PAYMENT_WEBHOOK_URL", "http://localhost:"+util.FromEnv("PORT", "8080")+"/api/v1/payments/webhook/mock")
+	mock := NewMockProvider(registry, webhookURL, 2*time.Second)
+	registry.Register(mock, "credit_card", "debit_card", "digital_wallet")
+
+	return registry
+}
+
+// Register assigns a provider to one or more payment methods
+func (r *Registry) Register(provider PaymentProvider, methods ...string) {
+	r.byName[provider.Name()] = provider
+	for _, method := range methods {
+		r.byMethod[method] = provider
+	}
+}
+
+// ForMethod returns the provider that handles the given payment method
+func (r *Registry) ForMethod(method string) (PaymentProvider, error) {
+	provider, ok := r.byMethod[method]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoProvider, method)
+	}
+	return provider, nil
+}
+
+// ByName returns a provider by its registered name
+func (r *Registry) ByName(name string) (PaymentProvider, bool) {
+	provider, ok := r.byName[name]
+	return provider, ok
+}
+
+// Sign returns the hex encoded HMAC-SHA256 signature of body
+func (r *Registry) Sign(body []byte) string {
+	return Sign(r.secret, body)
+}
+
+// Verify checks a webhook signature in constant time
+func (r *Registry) Verify(body []byte, signature string) bool {
+	expected := Sign(r.secret, body)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// Sign returns the hex encoded HMAC-SHA256 signature of body using secret
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
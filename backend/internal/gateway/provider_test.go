@@ -0,0 +1,31 @@
+package gateway
+
+import "testing"
+
+func TestRegistryVerify(t *testing.T) {
+	registry := NewRegistry("secret")
+	body := []byte(`{"transaction_id":"tx-1","status":"completed"}`)
+	signature := registry.Sign(body)
+
+	if !registry.Verify(body, signature) {
+		t.Fatal("signature of the same body was rejected")
+	}
+	if registry.Verify([]byte(`{"transaction_id":"tx-1","status":"failed"}`), signature) {
+		t.Error("signature of a changed body was accepted")
+	}
+	if NewRegistry("other").Verify(body, signature) {
+		t.Error("signature made with another secret was accepted")
+	}
+	if registry.Verify(body, "") {
+		t.Error("empty signature was accepted")
+	}
+}
+
+func TestSign(t *testing.T) {
+	// RFC 4231 test case 2
+	got := Sign([]byte("Jefe"), []byte("what do ya want for nothing?"))
+	want := "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"
+	if got != want {
+		t.Errorf("Sign = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,79 @@
+// Package guestlink signs the links guests open from their phone, such as the
+// QR code on a table. A link carries the ID it is for and a nonce stored with
+// that record, so replacing the nonce revokes every link handed out before.
+// Besides the table QR code there are check links guests pay their order from.
+package guestlink
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"pos-backend/internal/util"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidToken is returned for tokens that are malformed or not signed by us
+var ErrInvalidToken = errors.New("guest link token is invalid")
+
+// Kinds of links; the kind is signed so a token cannot be used for another purpose
+const (
+	KindTable = "table"
+	KindCheck = "check"
+)
+
+// Signer creates and checks guest link tokens
+type Signer struct {
+	secret []byte
+}
+
+// NewSigner creates a signer using secret
+func NewSigner(secret string) *Signer {
+	return &Signer{secret: []byte(secret)}
+}
+
+// NewDefaultSigner creates the signer used by the API, keyed by GUEST_LINK_SECRET
+func NewDefaultSigner() *Signer {
+	return NewSigner(util.FromEnv("GUEST_LINK_SECRET", "change-this-guest-link-secret-in-production"))
+}
+
+// NewNonce returns a fresh random nonce for a record guests can link to
+func NewNonce() string {
+	return strings.ReplaceAll(uuid.New().String(), "-", "")[:16]
+}
+
+// Sign returns the token for a link of the given kind to id
+func (s *Signer) Sign(kind string, id uuid.UUID, nonce string) string {
+	return encodeID(id) + "." + nonce + "." + s.signature(kind, id, nonce)
+}
+
+// Parse checks a token of the given kind and returns the ID and nonce it
+// carries. Callers must still compare the nonce with the one on record.
+func (s *Signer) Parse(kind, token string) (uuid.UUID, string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[1] == "" {
+		return uuid.Nil, "", ErrInvalidToken
+	}
+	id, err := uuid.Parse(parts[0])
+	if err != nil {
+		return uuid.Nil, "", ErrInvalidToken
+	}
+	if !hmac.Equal([]byte(s.signature(kind, id, parts[1])), []byte(parts[2])) {
+		return uuid.Nil, "", ErrInvalidToken
+	}
+	return id, parts[1], nil
+}
+
+func (s *Signer) signature(kind string, id uuid.UUID, nonce string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(kind + ":" + id.String() + ":" + nonce))
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// encodeID shortens the ID for the QR code; uuid.Parse reads it back
+func encodeID(id uuid.UUID) string {
+	return hex.EncodeToString(id[:])
+}
@@ -0,0 +1,60 @@
+package guestlink
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestSignParse(t *testing.T) {
+	signer := NewSigner("secret")
+	id := uuid.New()
+	nonce := NewNonce()
+
+	token := signer.Sign(KindTable, id, nonce)
+	gotID, gotNonce, err := signer.Parse(KindTable, token)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if gotID != id || gotNonce != nonce {
+		t.Errorf("Parse = %s, %s, want %s, %s", gotID, gotNonce, id, nonce)
+	}
+	if strings.Contains(token, "-") {
+		t.Errorf("token %q is not URL friendly", token)
+	}
+}
+
+func TestParseRejectsInvalidTokens(t *testing.T) {
+	signer := NewSigner("secret")
+	id := uuid.New()
+	token := signer.Sign(KindTable, id, "abc123")
+	parts := strings.Split(token, ".")
+
+	tests := map[string]string{
+		"other kind":     signer.Sign(KindCheck, id, "abc123"),
+		"other secret":   NewSigner("other").Sign(KindTable, id, "abc123"),
+		"changed id":     encodeID(uuid.New()) + "." + parts[1] + "." + parts[2],
+		"changed nonce":  parts[0] + ".abc124." + parts[2],
+		"empty nonce":    parts[0] + ".." + signer.signature(KindTable, id, ""),
+		"missing part":   parts[0] + "." + parts[1],
+		"extra part":     token + ".x",
+		"malformed id":   "not-an-id." + parts[1] + "." + parts[2],
+		"empty":          "",
+		"no signature":   parts[0] + "." + parts[1] + ".",
+		"truncated hmac": parts[0] + "." + parts[1] + "." + parts[2][:31],
+	}
+
+	for name, bad := range tests {
+		if _, _, err := signer.Parse(KindTable, bad); err != ErrInvalidToken {
+			t.Errorf("%s: Parse error = %v, want ErrInvalidToken", name, err)
+		}
+	}
+}
+
+func TestNewNonce(t *testing.T) {
+	a, b := NewNonce(), NewNonce()
+	if len(a) != 16 || a == b {
+		t.Errorf("NewNonce = %q, %q, want two different 16 character nonces", a, b)
+	}
+}
@@ -8,7 +8,11 @@ import (
 	"strings"
 	"time"
 
+	"pos-backend/internal/allergens"
+	"pos-backend/internal/models"
+
 	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -115,7 +119,7 @@ func (h *AdminHandler) GetAdminCategories(c *gin.Context) {
 
 	// Get categories with pagination
 	query := `
-		SELECT id, name, description, color, sort_order, is_active, created_at, updated_at
+		SELECT id, name, description, color, sort_order, station_id, is_active, created_at, updated_at
 		FROM categories 
 		ORDER BY sort_order, name 
 		LIMIT $1 OFFSET $2
@@ -140,12 +144,13 @@ func (h *AdminHandler) GetAdminCategories(c *gin.Context) {
 			description *string
 			color       *string
 			sortOrder   int
+			stationID   *string
 			isActive    bool
 			createdAt   time.Time
 			updatedAt   time.Time
 		)
 		err := rows.Scan(
-			&id, &name, &description, &color, &sortOrder, &isActive, &createdAt, &updatedAt,
+			&id, &name, &description, &color, &sortOrder, &stationID, &isActive, &createdAt, &updatedAt,
 		)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
@@ -162,6 +167,7 @@ func (h *AdminHandler) GetAdminCategories(c *gin.Context) {
 			"description": description,
 			"color":       color,
 			"sort_order":  sortOrder,
+			"station_id":  stationID,
 			"is_active":   isActive,
 			"created_at":  createdAt,
 			"updated_at":  updatedAt,
@@ -190,14 +196,16 @@ func (h *AdminHandler) GetAdminTables(c *gin.Context) {
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
 	offset := (page - 1) * limit
 
-	// Get total count
+	// Get total count; table groups only exist while tables are pushed together
 	var totalCount int
-	h.db.QueryRow("SELECT COUNT(*) FROM dining_tables").Scan(&totalCount)
+	h.db.QueryRow("SELECT COUNT(*) FROM dining_tables WHERE is_group = false").Scan(&totalCount)
 
 	// Get tables with pagination
 	query := `
-		SELECT id, table_number, seating_capacity, location, status, created_at, updated_at
+		SELECT id, table_number, seating_capacity, location, status,
+		       pos_x, pos_y, width, height, shape, rotation, created_at, updated_at
 		FROM dining_tables 
+		WHERE is_group = false
 		ORDER BY location, table_number 
 		LIMIT $1 OFFSET $2
 	`
@@ -221,11 +229,14 @@ func (h *AdminHandler) GetAdminTables(c *gin.Context) {
 			seatingCapacity int
 			location    *string
 			status      string
+			layout      models.TableLayout
 			createdAt   time.Time
 			updatedAt   time.Time
 		)
 		err := rows.Scan(
-			&id, &tableNumber, &seatingCapacity, &location, &status, &createdAt, &updatedAt,
+			&id, &tableNumber, &seatingCapacity, &location, &status,
+			&layout.PosX, &layout.PosY, &layout.Width, &layout.Height, &layout.Shape, &layout.Rotation,
+			&createdAt, &updatedAt,
 		)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
@@ -242,6 +253,7 @@ func (h *AdminHandler) GetAdminTables(c *gin.Context) {
 			"capacity": seatingCapacity,
 			"location":     location,
 			"status":       status,
+			"layout":       layout,
 			"created_at":   createdAt,
 			"updated_at":   updatedAt,
 		}
@@ -270,6 +282,7 @@ func (h *AdminHandler) CreateCategory(c *gin.Context) {
 		Description *string `json:"description"`
 		Color       *string `json:"color"`
 		SortOrder   int     `json:"sort_order"`
+		StationID   *string `json:"station_id"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -283,10 +296,10 @@ func (h *AdminHandler) CreateCategory(c *gin.Context) {
 
 	var categoryID string
 	err := h.db.QueryRow(`
-		INSERT INTO categories (name, description, color, sort_order)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO categories (name, description, color, sort_order, station_id)
+		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id
-	`, req.Name, req.Description, req.Color, req.SortOrder).Scan(&categoryID)
+	`, req.Name, req.Description, req.Color, req.SortOrder, stationArg(req.StationID)).Scan(&categoryID)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -313,6 +326,7 @@ func (h *AdminHandler) UpdateCategory(c *gin.Context) {
 		Description *string `json:"description"`
 		Color       *string `json:"color"`
 		SortOrder   *int    `json:"sort_order"`
+		StationID   *string `json:"station_id"` // empty string clears the station
 		IsActive    *bool   `json:"is_active"`
 	}
 
@@ -350,6 +364,11 @@ func (h *AdminHandler) UpdateCategory(c *gin.Context) {
 		args = append(args, *req.SortOrder)
 		argCount++
 	}
+	if req.StationID != nil {
+		updates = append(updates, fmt.Sprintf("station_id = $%d", argCount))
+		args = append(args, stationArg(req.StationID))
+		argCount++
+	}
 	if req.IsActive != nil {
 		updates = append(updates, fmt.Sprintf("is_active = $%d", argCount))
 		args = append(args, *req.IsActive)
@@ -443,15 +462,18 @@ func (h *AdminHandler) DeleteCategory(c *gin.Context) {
 // CreateProduct creates a new product
 func (h *AdminHandler) CreateProduct(c *gin.Context) {
 	var req struct {
-		CategoryID      *string `json:"category_id"`
-		Name            string  `json:"name" binding:"required"`
-		Description     *string `json:"description"`
-		Price           float64 `json:"price" binding:"required"`
-		ImageURL        *string `json:"image_url"`
-		Barcode         *string `json:"barcode"`
-		SKU             *string `json:"sku"`
-		PreparationTime int     `json:"preparation_time"`
-		SortOrder       int     `json:"sort_order"`
+		CategoryID      *string  `json:"category_id"`
+		Name            string   `json:"name" binding:"required"`
+		Description     *string  `json:"description"`
+		Price           float64  `json:"price" binding:"required"`
+		ImageURL        *string  `json:"image_url"`
+		Barcode         *string  `json:"barcode"`
+		SKU             *string  `json:"sku"`
+		PreparationTime int      `json:"preparation_time"`
+		SortOrder       int      `json:"sort_order"`
+		StationID       *string  `json:"station_id"`
+		Allergens       []string `json:"allergens"`
+		DietaryTags     []string `json:"dietary_tags"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -463,13 +485,25 @@ func (h *AdminHandler) CreateProduct(c *gin.Context) {
 		return
 	}
 
+	productAllergens, dietaryTags, err := normalizeMenuAttributes(req.Allergens, req.DietaryTags)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"error":   "invalid_menu_attribute",
+		})
+		return
+	}
+
 	var productID string
-	err := h.db.QueryRow(`
-		INSERT INTO products (category_id, name, description, price, image_url, barcode, sku, preparation_time, sort_order)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	err = h.db.QueryRow(`
+		INSERT INTO products (category_id, name, description, price, image_url, barcode, sku, preparation_time, sort_order, station_id,
+		                      allergens, dietary_tags)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		RETURNING id
 	`, req.CategoryID, req.Name, req.Description, req.Price, req.ImageURL,
-		req.Barcode, req.SKU, req.PreparationTime, req.SortOrder).Scan(&productID)
+		req.Barcode, req.SKU, req.PreparationTime, req.SortOrder, stationArg(req.StationID),
+		pq.Array(productAllergens), pq.Array(dietaryTags)).Scan(&productID)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -501,6 +535,9 @@ func (h *AdminHandler) UpdateProduct(c *gin.Context) {
 		SKU             *string  `json:"sku"`
 		PreparationTime *int     `json:"preparation_time"`
 		SortOrder       *int     `json:"sort_order"`
+		StationID       *string  `json:"station_id"` // empty string falls back to the category's station
+		Allergens       []string `json:"allergens"`
+		DietaryTags     []string `json:"dietary_tags"`
 		IsActive        *bool    `json:"is_active"`
 	}
 
@@ -563,6 +600,39 @@ func (h *AdminHandler) UpdateProduct(c *gin.Context) {
 		args = append(args, *req.SortOrder)
 		argCount++
 	}
+	if req.StationID != nil {
+		updates = append(updates, fmt.Sprintf("station_id = $%d", argCount))
+		args = append(args, stationArg(req.StationID))
+		argCount++
+	}
+	if req.Allergens != nil {
+		values, err := allergens.Normalize(req.Allergens, allergens.Allergens, "allergen")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": err.Error(),
+				"error":   "invalid_menu_attribute",
+			})
+			return
+		}
+		updates = append(updates, fmt.Sprintf("allergens = $%d", argCount))
+		args = append(args, pq.Array(values))
+		argCount++
+	}
+	if req.DietaryTags != nil {
+		values, err := allergens.Normalize(req.DietaryTags, allergens.DietaryTags, "dietary tag")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": err.Error(),
+				"error":   "invalid_menu_attribute",
+			})
+			return
+		}
+		updates = append(updates, fmt.Sprintf("dietary_tags = $%d", argCount))
+		args = append(args, pq.Array(values))
+		argCount++
+	}
 	if req.IsActive != nil {
 		updates = append(updates, fmt.Sprintf("is_active = $%d", argCount))
 		args = append(args, *req.IsActive)
@@ -647,6 +717,16 @@ func (h *AdminHandler) DeleteProduct(c *gin.Context) {
 		return
 	}
 
+	// Modifiers of the product can no longer be ordered
+	if _, err := h.db.Exec("DELETE FROM modifiers WHERE product_id = $1", productID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to delete product modifiers",
+			"error":   err.Error(),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Product deleted successfully",
@@ -656,10 +736,11 @@ func (h *AdminHandler) DeleteProduct(c *gin.Context) {
 // CreateTable creates a new table
 func (h *AdminHandler) CreateTable(c *gin.Context) {
 	var req struct {
-		TableNumber string `json:"table_number" binding:"required"`
-		Capacity    int    `json:"seat_capacity" binding:"required"`
-		Location    string `json:"location" binding:"required"`
-		Status      string `json:"status" binding:"required"`
+		TableNumber string           `json:"table_number" binding:"required"`
+		Capacity    int              `json:"seat_capacity" binding:"required"`
+		Location    string           `json:"location" binding:"required"`
+		Status      string           `json:"status" binding:"required"`
+		Layout      tableLayoutInput `json:"layout"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -679,12 +760,24 @@ func (h *AdminHandler) CreateTable(c *gin.Context) {
 		return
 	}
 
+	if message := req.Layout.validate(); message != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": message,
+			"error":   "invalid_layout",
+		})
+		return
+	}
+
 	var tableID string
 	err := h.db.QueryRow(`
-		INSERT INTO dining_tables (table_number, seating_capacity, location, status)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO dining_tables (table_number, seating_capacity, location, status,
+		                           pos_x, pos_y, width, height, shape, rotation)
+		VALUES ($1, $2, $3, $4, COALESCE($5, 0), COALESCE($6, 0), COALESCE($7, 80), COALESCE($8, 80),
+		        COALESCE($9, 'square'), COALESCE($10, 0))
 		RETURNING id
-	`, req.TableNumber, req.Capacity, req.Location, req.Status).Scan(&tableID)
+	`, req.TableNumber, req.Capacity, req.Location, req.Status, req.Layout.PosX, req.Layout.PosY,
+		req.Layout.Width, req.Layout.Height, req.Layout.Shape, req.Layout.Rotation).Scan(&tableID)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -707,10 +800,11 @@ func (h *AdminHandler) UpdateTable(c *gin.Context) {
 	tableID := c.Param("id")
 
 	var req struct {
-		TableNumber *string `json:"table_number"`
-		Capacity    *int    `json:"capacity"`
-		Location    *string `json:"location"`
-		Status    	*string   `json:"status"`
+		TableNumber *string           `json:"table_number"`
+		Capacity    *int              `json:"capacity"`
+		Location    *string           `json:"location"`
+		Status      *string           `json:"status"`
+		Layout      *tableLayoutInput `json:"layout"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -747,6 +841,17 @@ func (h *AdminHandler) UpdateTable(c *gin.Context) {
 		args = append(args, *req.Status)
 		argCount++
 	}
+	if req.Layout != nil {
+		if message := req.Layout.validate(); message != "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": message,
+				"error":   "invalid_layout",
+			})
+			return
+		}
+		updates, args, argCount = req.Layout.updates(updates, args, argCount)
+	}
 
 	if len(updates) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -794,6 +899,18 @@ func (h *AdminHandler) UpdateTable(c *gin.Context) {
 func (h *AdminHandler) DeleteTable(c *gin.Context) {
 	tableID := c.Param("id")
 
+	// Tables pushed together are deleted once their group is split
+	var joined bool
+	h.db.QueryRow("SELECT is_group OR joined_to IS NOT NULL FROM dining_tables WHERE id = $1", tableID).Scan(&joined)
+	if joined {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Cannot delete a table that is part of a table group",
+			"error":   "table_joined",
+		})
+		return
+	}
+
 	// Check if table has orders
 	var orderCount int
 	h.db.QueryRow("SELECT COUNT(*) FROM orders WHERE table_id = $1", tableID).Scan(&orderCount)
@@ -2,11 +2,24 @@ package handlers
 
 import (
 	"database/sql"
+	"encoding/json"
 	"net/http"
+	"strconv"
+	"time"
+
+	"pos-backend/internal/middleware"
+	"pos-backend/internal/models"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
+// queryer is satisfied by both *sql.DB and *sql.Tx
+type queryer interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
 type DashboardHandler struct {
 	db *sql.DB
 }
@@ -31,8 +44,8 @@ func (h *DashboardHandler) GetDashboardStats(c *gin.Context) {
 	// Today's revenue
 	var todayRevenue float64
 	h.db.QueryRow(`
-		SELECT COALESCE(SUM(total_amount), 0) 
-		FROM orders 
+		SELECT COALESCE(SUM(` + orderRevenue + `), 0) 
+		FROM orders o
 		WHERE DATE(created_at) = CURRENT_DATE AND status = 'completed'
 	`).Scan(&todayRevenue)
 
@@ -49,7 +62,7 @@ func (h *DashboardHandler) GetDashboardStats(c *gin.Context) {
 	h.db.QueryRow(`
 		SELECT COUNT(*) 
 		FROM dining_tables 
-		WHERE is_occupied = true
+		WHERE status = 'occupied'
 	`).Scan(&occupiedTables)
 
 	stats["today_orders"] = todayOrders
@@ -72,24 +85,24 @@ func (h *DashboardHandler) GetSalesReport(c *gin.Context) {
 	switch period {
 	case "week":
 		query = `
-			SELECT DATE(created_at) as date, COUNT(*) as order_count, SUM(total_amount) as revenue
-			FROM orders 
+			SELECT DATE(created_at) as date, COUNT(*) as order_count, SUM(` + orderRevenue + `) as revenue
+			FROM orders o
 			WHERE created_at >= CURRENT_DATE - INTERVAL '7 days' AND status = 'completed'
 			GROUP BY DATE(created_at)
 			ORDER BY date DESC
 		`
 	case "month":
 		query = `
-			SELECT DATE(created_at) as date, COUNT(*) as order_count, SUM(total_amount) as revenue
-			FROM orders 
+			SELECT DATE(created_at) as date, COUNT(*) as order_count, SUM(` + orderRevenue + `) as revenue
+			FROM orders o
 			WHERE created_at >= CURRENT_DATE - INTERVAL '30 days' AND status = 'completed'
 			GROUP BY DATE(created_at)
 			ORDER BY date DESC
 		`
 	default: // today
 		query = `
-			SELECT DATE_TRUNC('hour', created_at) as hour, COUNT(*) as order_count, SUM(total_amount) as revenue
-			FROM orders 
+			SELECT DATE_TRUNC('hour', created_at) as hour, COUNT(*) as order_count, SUM(` + orderRevenue + `) as revenue
+			FROM orders o
 			WHERE DATE(created_at) = CURRENT_DATE AND status = 'completed'
 			GROUP BY DATE_TRUNC('hour', created_at)
 			ORDER BY hour DESC
@@ -144,8 +157,8 @@ func (h *DashboardHandler) GetOrdersReport(c *gin.Context) {
 		SELECT 
 			status,
 			COUNT(*) as count,
-			AVG(total_amount) as avg_amount
-		FROM orders 
+			AVG(` + orderRevenue + `) as avg_amount
+		FROM orders o
 		WHERE DATE(created_at) = CURRENT_DATE
 		GROUP BY status
 	`
@@ -191,71 +204,29 @@ func (h *DashboardHandler) GetOrdersReport(c *gin.Context) {
 	})
 }
 
+// incomeReportPeriods maps the income report periods to how far back they go
+// and the unit they are broken down by
+var incomeReportPeriods = map[string]struct {
+	years, days int
+	unit        string
+}{
+	"today": {0, 0, "hour"},
+	"week":  {0, 7, "day"},
+	"month": {0, 30, "day"},
+	"year":  {1, 0, "month"},
+}
+
 // GetIncomeReport returns income report data
 func (h *DashboardHandler) GetIncomeReport(c *gin.Context) {
 	period := c.DefaultQuery("period", "today") // today, week, month, year
-
-	var query string
-	switch period {
-	case "week":
-		query = `
-			SELECT 
-				DATE_TRUNC('day', created_at) as period,
-				COUNT(*) as total_orders,
-				SUM(total_amount) as gross_income,
-				SUM(tax_amount) as tax_collected,
-				SUM(total_amount - tax_amount) as net_income
-			FROM orders 
-			WHERE created_at >= CURRENT_DATE - INTERVAL '7 days' 
-				AND status = 'completed'
-			GROUP BY DATE_TRUNC('day', created_at)
-			ORDER BY period DESC
-		`
-	case "month":
-		query = `
-			SELECT 
-				DATE_TRUNC('day', created_at) as period,
-				COUNT(*) as total_orders,
-				SUM(total_amount) as gross_income,
-				SUM(tax_amount) as tax_collected,
-				SUM(total_amount - tax_amount) as net_income
-			FROM orders 
-			WHERE created_at >= CURRENT_DATE - INTERVAL '30 days' 
-				AND status = 'completed'
-			GROUP BY DATE_TRUNC('day', created_at)
-			ORDER BY period DESC
-		`
-	case "year":
-		query = `
-			SELECT 
-				DATE_TRUNC('month', created_at) as period,
-				COUNT(*) as total_orders,
-				SUM(total_amount) as gross_income,
-				SUM(tax_amount) as tax_collected,
-				SUM(total_amount - tax_amount) as net_income
-			FROM orders 
-			WHERE created_at >= CURRENT_DATE - INTERVAL '1 year' 
-				AND status = 'completed'
-			GROUP BY DATE_TRUNC('month', created_at)
-			ORDER BY period DESC
-		`
-	default: // today
-		query = `
-			SELECT 
-				DATE_TRUNC('hour', created_at) as period,
-				COUNT(*) as total_orders,
-				SUM(total_amount) as gross_income,
-				SUM(tax_amount) as tax_collected,
-				SUM(total_amount - tax_amount) as net_income
-			FROM orders 
-			WHERE DATE(created_at) = CURRENT_DATE 
-				AND status = 'completed'
-			GROUP BY DATE_TRUNC('hour', created_at)
-			ORDER BY period DESC
-		`
+	window, ok := incomeReportPeriods[period]
+	if !ok {
+		period, window = "today", incomeReportPeriods["today"]
 	}
 
-	rows, err := h.db.Query(query)
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(-window.years, 0, -window.days)
+	sales, err := aggregateSales(h.db, start, now, window.unit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -264,55 +235,552 @@ func (h *DashboardHandler) GetIncomeReport(c *gin.Context) {
 		})
 		return
 	}
-	defer rows.Close()
 
-	var report []map[string]interface{}
-	var totalGross, totalTax, totalNet float64
-	var totalOrders int
+	report := []map[string]interface{}{}
+	for _, s := range sales {
+		report = append(report, map[string]interface{}{
+			"period": s.Period,
+			"orders": s.Orders,
+			"gross":  s.Revenue,
+			"tax":    s.Tax,
+			"net":    s.Revenue - s.Tax,
+		})
+	}
 
-	for rows.Next() {
-		var period interface{}
-		var orders int
-		var gross, tax, net float64
+	total := totalSales(sales)
+	result := map[string]interface{}{
+		"summary": map[string]interface{}{
+			"total_orders":  total.Orders,
+			"gross_income":  total.Revenue,
+			"tax_collected": total.Tax,
+			"net_income":    total.Revenue - total.Tax,
+		},
+		"breakdown": report,
+		"period":    period,
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Income report retrieved successfully",
+		"data":    result,
+	})
+}
+
+// GetXReport returns a mid-shift snapshot of the current business day without closing it
+func (h *DashboardHandler) GetXReport(c *gin.Context) {
+	start, err := businessDayStart(h.db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to determine business day",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	report, err := buildShiftReport(h.db, start, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to build X report",
+			"error":   err.Error(),
+		})
+		return
+	}
+	report.ReportType = "X"
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "X report retrieved successfully",
+		"data":    report,
+	})
+}
+
+// CloseBusinessDay finalizes the current business day into the next numbered Z report
+func (h *DashboardHandler) CloseBusinessDay(c *gin.Context) {
+	userID, _, _, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "Authentication required",
+			"error":   "auth_required",
+		})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to start transaction",
+			"error":   err.Error(),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	// Serialize closes so two managers cannot produce the same Z number
+	if _, err := tx.Exec("LOCK TABLE z_reports IN EXCLUSIVE MODE"); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to lock Z reports",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	start, err := businessDayStart(tx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to determine business day",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	var zNumber int
+	if err := tx.QueryRow("SELECT COALESCE(MAX(z_number), 0) + 1 FROM z_reports").Scan(&zNumber); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to allocate Z number",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	closedAt := time.Now()
+	report, err := buildShiftReport(tx, start, closedAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to build Z report",
+			"error":   err.Error(),
+		})
+		return
+	}
+	report.ReportType = "Z"
+	report.ZNumber = &zNumber
+
+	reportJSON, err := json.Marshal(report)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to encode Z report",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	zReport := models.ZReport{
+		ID:       uuid.New(),
+		ZNumber:  zNumber,
+		OpenedAt: start,
+		ClosedAt: closedAt,
+		ClosedBy: &userID,
+		Report:   *report,
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO z_reports (id, z_number, opened_at, closed_at, closed_by, report)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING created_at
+	`, zReport.ID, zNumber, start, closedAt, userID, reportJSON).Scan(&zReport.CreatedAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to save Z report",
+			"error":   err.Error(),
+		})
+		return
+	}
 
-		err := rows.Scan(&period, &orders, &gross, &tax, &net)
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to commit Z report",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Business day closed successfully",
+		"data":    zReport,
+	})
+}
+
+// GetZReports lists persisted Z reports, newest first
+func (h *DashboardHandler) GetZReports(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "30"))
+	if limit <= 0 || limit > 365 {
+		limit = 30
+	}
+
+	rows, err := h.db.Query(`
+		SELECT id, z_number, opened_at, closed_at, closed_by, report, created_at
+		FROM z_reports
+		ORDER BY z_number DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to fetch Z reports",
+			"error":   err.Error(),
+		})
+		return
+	}
+	defer rows.Close()
+
+	var reports []models.ZReport
+	for rows.Next() {
+		zReport, err := scanZReport(rows)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"success": false,
-				"message": "Failed to scan income data",
+				"message": "Failed to scan Z report",
 				"error":   err.Error(),
 			})
 			return
 		}
+		reports = append(reports, *zReport)
+	}
 
-		totalOrders += orders
-		totalGross += gross
-		totalTax += tax
-		totalNet += net
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Z reports retrieved successfully",
+		"data":    reports,
+	})
+}
 
-		report = append(report, map[string]interface{}{
-			"period": period,
-			"orders": orders,
-			"gross":  gross,
-			"tax":    tax,
-			"net":    net,
+// GetZReport returns a single persisted Z report by number
+func (h *DashboardHandler) GetZReport(c *gin.Context) {
+	zNumber, err := strconv.Atoi(c.Param("number"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid Z report number",
+			"error":   "invalid_z_number",
 		})
+		return
 	}
 
-	result := map[string]interface{}{
-		"summary": map[string]interface{}{
-			"total_orders":  totalOrders,
-			"gross_income":  totalGross,
-			"tax_collected": totalTax,
-			"net_income":    totalNet,
-		},
-		"breakdown": report,
-		"period":    period,
+	row := h.db.QueryRow(`
+		SELECT id, z_number, opened_at, closed_at, closed_by, report, created_at
+		FROM z_reports
+		WHERE z_number = $1
+	`, zNumber)
+
+	zReport, err := scanZReport(row)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "Z report not found",
+			"error":   "z_report_not_found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to fetch Z report",
+			"error":   err.Error(),
+		})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Income report retrieved successfully",
-		"data":    result,
+		"message": "Z report retrieved successfully",
+		"data":    zReport,
 	})
-}
\ No newline at end of file
+}
+
+// Helper functions
+
+// orderRevenue is what the order o brought in: its total less the gift cards
+// sold on it, which are stored value owed to the holder until redeemed
+const orderRevenue = `(o.total_amount - COALESCE((
+	SELECT SUM(gc.total_price) FROM order_items gc WHERE gc.order_id = o.id AND gc.item_type = 'gift_card'
+), 0))`
+
+// businessDayStart returns when the current business day opened: the close of the
+// last Z report, or the first order ever taken if no day has been closed yet
+func businessDayStart(q queryer) (time.Time, error) {
+	var start time.Time
+	err := q.QueryRow(`
+		SELECT COALESCE(
+			(SELECT MAX(closed_at) FROM z_reports),
+			(SELECT MIN(created_at) FROM orders),
+			CURRENT_TIMESTAMP
+		)
+	`).Scan(&start)
+	return start, err
+}
+
+// salesPeriod is what the orders completed in a period sold. Tax is collected
+// on the product subtotal; gift cards sold are not revenue.
+type salesPeriod struct {
+	Period    time.Time
+	Orders    int
+	Subtotal  float64
+	Discounts float64
+	Tax       float64
+	Revenue   float64
+}
+
+// aggregateSales totals the orders completed in [start, end) per unit of time
+// (hour, day or month), latest first. It is shared by the income report and
+// the X and Z reports so they always agree.
+func aggregateSales(q queryer, start, end time.Time, unit string) ([]salesPeriod, error) {
+	rows, err := q.Query(`
+		SELECT DATE_TRUNC($3, o.completed_at) AS period,
+		       COUNT(*),
+		       COALESCE(SUM(o.subtotal), 0),
+		       COALESCE(SUM(o.discount_amount), 0),
+		       COALESCE(SUM(o.tax_amount), 0),
+		       COALESCE(SUM(`+orderRevenue+`), 0)
+		FROM orders o
+		WHERE o.status = 'completed' AND o.completed_at >= $1 AND o.completed_at < $2
+		GROUP BY period
+		ORDER BY period DESC
+	`, start, end, unit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sales := []salesPeriod{}
+	for rows.Next() {
+		var s salesPeriod
+		if err := rows.Scan(&s.Period, &s.Orders, &s.Subtotal, &s.Discounts, &s.Tax, &s.Revenue); err != nil {
+			return nil, err
+		}
+		sales = append(sales, s)
+	}
+	return sales, rows.Err()
+}
+
+// totalSales adds up the periods of aggregateSales
+func totalSales(sales []salesPeriod) salesPeriod {
+	var total salesPeriod
+	for _, s := range sales {
+		total.Orders += s.Orders
+		total.Subtotal += s.Subtotal
+		total.Discounts += s.Discounts
+		total.Tax += s.Tax
+		total.Revenue += s.Revenue
+	}
+	return total
+}
+
+// buildShiftReport aggregates orders, payments and refunds in [start, end)
+func buildShiftReport(q queryer, start, end time.Time) (*models.ShiftReport, error) {
+	report := &models.ShiftReport{
+		PeriodStart:    start,
+		PeriodEnd:      end,
+		TaxByRate:      []models.TaxRateTotal{},
+		PaymentMethods: []models.PaymentMethodTotal{},
+		OrderTypes:     []models.OrderTypeTotal{},
+		Channels:       []models.ChannelTotal{},
+		PriceRules:     []models.PriceRuleTotal{},
+	}
+
+	// Sales totals, the same as the income report's
+	sales, err := aggregateSales(q, start, end, "day")
+	if err != nil {
+		return nil, err
+	}
+	total := totalSales(sales)
+	report.OrderCount, report.GrossSales, report.Discounts, report.TaxCollected = total.Orders, total.Subtotal, total.Discounts, total.Tax
+
+	err = q.QueryRow(`
+		SELECT COALESCE(SUM(oi.total_price), 0)
+		FROM order_items oi
+		JOIN orders o ON oi.order_id = o.id
+		WHERE oi.item_type = 'gift_card' AND o.status = 'completed'
+		  AND o.completed_at >= $1 AND o.completed_at < $2
+	`, start, end).Scan(&report.GiftCardsSold)
+	if err != nil {
+		return nil, err
+	}
+
+	err = q.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(amount), 0)
+		FROM payment_refunds
+		WHERE created_at >= $1 AND created_at < $2
+	`, start, end).Scan(&report.RefundCount, &report.Refunds)
+	if err != nil {
+		return nil, err
+	}
+
+	report.NetSales = report.GrossSales - report.Discounts - report.Refunds
+
+	// Orders do not store their tax rate, so it is derived from the amounts
+	rows, err := q.Query(`
+		SELECT 
+			COALESCE(ROUND(tax_amount / NULLIF(subtotal - COALESCE(discount_amount, 0), 0) * 100, 2), 0) as rate,
+			COALESCE(SUM(subtotal - COALESCE(discount_amount, 0)), 0),
+			COALESCE(SUM(tax_amount), 0)
+		FROM orders
+		WHERE status = 'completed' AND completed_at >= $1 AND completed_at < $2
+		GROUP BY rate
+		ORDER BY rate
+	`, start, end)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var total models.TaxRateTotal
+		if err := rows.Scan(&total.Rate, &total.TaxableAmount, &total.TaxAmount); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		report.TaxByRate = append(report.TaxByRate, total)
+	}
+	rows.Close()
+
+	err = q.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(total_amount), 0)
+		FROM orders
+		WHERE status = 'cancelled' AND updated_at >= $1 AND updated_at < $2
+	`, start, end).Scan(&report.Voids.Count, &report.Voids.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	// Refunds are attributed to the day they were given, whenever the payment was taken
+	rows, err = q.Query(`
+		SELECT payment_method, SUM(payment_count), COALESCE(SUM(amount), 0), COALESCE(SUM(refunded), 0)
+		FROM (
+			SELECT payment_method, 1 as payment_count, amount, 0 as refunded
+			FROM payments
+			WHERE status IN ('completed', 'refunded') AND processed_at >= $1 AND processed_at < $2
+			UNION ALL
+			SELECT p.payment_method, 0, 0, r.amount
+			FROM payment_refunds r
+			JOIN payments p ON r.payment_id = p.id
+			WHERE r.created_at >= $1 AND r.created_at < $2
+		) as method_totals
+		GROUP BY payment_method
+		ORDER BY payment_method
+	`, start, end)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var total models.PaymentMethodTotal
+		if err := rows.Scan(&total.PaymentMethod, &total.Count, &total.Amount, &total.Refunded); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		report.TotalCollected += total.Amount - total.Refunded
+		report.PaymentMethods = append(report.PaymentMethods, total)
+	}
+	rows.Close()
+
+	rows, err = q.Query(`
+		SELECT order_type, COUNT(*), COALESCE(SUM(`+orderRevenue+`), 0)
+		FROM orders o
+		WHERE status = 'completed' AND completed_at >= $1 AND completed_at < $2
+		GROUP BY order_type
+		ORDER BY order_type
+	`, start, end)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var total models.OrderTypeTotal
+		if err := rows.Scan(&total.OrderType, &total.Count, &total.Amount); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		report.OrderTypes = append(report.OrderTypes, total)
+	}
+	rows.Close()
+
+	rows, err = q.Query(`
+		SELECT channel, COUNT(*), COALESCE(SUM(`+orderRevenue+`), 0)
+		FROM orders o
+		WHERE status = 'completed' AND completed_at >= $1 AND completed_at < $2
+		GROUP BY channel
+		ORDER BY channel
+	`, start, end)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var total models.ChannelTotal
+		if err := rows.Scan(&total.Channel, &total.Count, &total.Amount); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		report.Channels = append(report.Channels, total)
+	}
+	rows.Close()
+
+	// Happy-hour sales are the items charged under a price rule, grouped by the
+	// rule's name when sold so deleted rules still show up
+	rows, err = q.Query(`
+		SELECT oi.price_rule_name, COALESCE(SUM(oi.quantity), 0), COALESCE(SUM(oi.total_price), 0),
+		       COALESCE(SUM((oi.original_unit_price - oi.unit_price) * oi.quantity), 0)
+		FROM order_items oi
+		JOIN orders o ON oi.order_id = o.id
+		WHERE oi.price_rule_name IS NOT NULL AND o.status = 'completed'
+		  AND o.completed_at >= $1 AND o.completed_at < $2
+		GROUP BY oi.price_rule_name
+		ORDER BY oi.price_rule_name
+	`, start, end)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var total models.PriceRuleTotal
+		if err := rows.Scan(&total.Name, &total.Quantity, &total.Revenue, &total.Discount); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		report.HappyHour.Quantity += total.Quantity
+		report.HappyHour.Revenue += total.Revenue
+		report.HappyHour.Discount += total.Discount
+		report.PriceRules = append(report.PriceRules, total)
+	}
+	rows.Close()
+
+	err = q.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(total_amount), 0)
+		FROM orders
+		WHERE status NOT IN ('completed', 'cancelled') AND created_at < $1
+	`, end).Scan(&report.OpenOrders.Count, &report.OpenOrders.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanZReport(row rowScanner) (*models.ZReport, error) {
+	var zReport models.ZReport
+	var reportJSON []byte
+
+	err := row.Scan(
+		&zReport.ID, &zReport.ZNumber, &zReport.OpenedAt, &zReport.ClosedAt,
+		&zReport.ClosedBy, &reportJSON, &zReport.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(reportJSON, &zReport.Report); err != nil {
+		return nil, err
+	}
+
+	return &zReport, nil
+}
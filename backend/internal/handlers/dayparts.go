@@ -0,0 +1,743 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"pos-backend/internal/dayparts"
+	"pos-backend/internal/middleware"
+	"pos-backend/internal/models"
+	"pos-backend/internal/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// allDaypartsKey marks requests that list the whole menu, whatever is being served now
+const allDaypartsKey = "all_dayparts"
+
+type MenuHandler struct {
+	db *sql.DB
+}
+
+func NewMenuHandler(db *sql.DB) *MenuHandler {
+	return &MenuHandler{db: db}
+}
+
+// loadDayparts returns every daypart with the categories and products assigned to it
+func loadDayparts(q queryer) ([]models.Daypart, error) {
+	rows, err := q.Query(`
+		SELECT d.id, d.menu_id, d.name, d.days_of_week, to_char(d.start_time, 'HH24:MI'), to_char(d.end_time, 'HH24:MI'),
+		       d.is_active AND m.is_active, d.created_at, d.updated_at,
+		       ARRAY(SELECT category_id::text FROM category_dayparts WHERE daypart_id = d.id),
+		       ARRAY(SELECT product_id::text FROM product_dayparts WHERE daypart_id = d.id)
+		FROM dayparts d
+		JOIN menus m ON d.menu_id = m.id
+		ORDER BY m.sort_order, m.name, d.start_time, d.name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []models.Daypart{}
+	for rows.Next() {
+		var daypart models.Daypart
+		var categoryIDs, productIDs []string
+		err := rows.Scan(&daypart.ID, &daypart.MenuID, &daypart.Name, pq.Array(&daypart.DaysOfWeek),
+			&daypart.StartTime, &daypart.EndTime, &daypart.IsActive, &daypart.CreatedAt, &daypart.UpdatedAt,
+			pq.Array(&categoryIDs), pq.Array(&productIDs))
+		if err != nil {
+			return nil, err
+		}
+		daypart.CategoryIDs = parseUUIDs(categoryIDs)
+		daypart.ProductIDs = parseUUIDs(productIDs)
+		result = append(result, daypart)
+	}
+	return result, rows.Err()
+}
+
+// parseUUIDs converts IDs read as text arrays
+func parseUUIDs(values []string) []uuid.UUID {
+	ids := []uuid.UUID{}
+	for _, value := range values {
+		if id, err := uuid.Parse(value); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// daypartWindow returns the serving window of a daypart
+func daypartWindow(daypart models.Daypart) dayparts.Window {
+	days := make([]int, len(daypart.DaysOfWeek))
+	for i, day := range daypart.DaysOfWeek {
+		days[i] = int(day)
+	}
+	return dayparts.Window{Days: days, Start: daypart.StartTime, End: daypart.EndTime}
+}
+
+// markCurrentDayparts flags the dayparts being served at now, in the store's timezone
+func markCurrentDayparts(q queryer, all []models.Daypart, now time.Time) error {
+	location, err := util.StoreLocation(q)
+	if err != nil {
+		return err
+	}
+
+	local := now.In(location)
+	for i := range all {
+		all[i].IsCurrent = all[i].IsActive && daypartWindow(all[i]).Contains(local)
+	}
+	return nil
+}
+
+// currentDayparts returns the IDs of the dayparts being served at now
+func currentDayparts(q queryer, now time.Time) ([]string, error) {
+	all, err := loadDayparts(q)
+	if err != nil {
+		return nil, err
+	}
+	if err := markCurrentDayparts(q, all, now); err != nil {
+		return nil, err
+	}
+
+	current := []string{}
+	for _, daypart := range all {
+		if daypart.IsCurrent {
+			current = append(current, daypart.ID.String())
+		}
+	}
+	return current, nil
+}
+
+// daypartCategoryCondition is true for the category in column when it has no
+// dayparts or one of them is in the dayparts passed as param
+func daypartCategoryCondition(column, param string) string {
+	return `(NOT EXISTS (SELECT 1 FROM category_dayparts cd WHERE cd.category_id = ` + column + `)
+		     OR EXISTS (SELECT 1 FROM category_dayparts cd WHERE cd.category_id = ` + column + ` AND cd.daypart_id = ANY(` + param + `::uuid[])))`
+}
+
+// daypartProductCondition is true for the product p when both it and its
+// category are on the menu in the dayparts passed as param
+func daypartProductCondition(param string) string {
+	return `(NOT EXISTS (SELECT 1 FROM product_dayparts pd WHERE pd.product_id = p.id)
+		     OR EXISTS (SELECT 1 FROM product_dayparts pd WHERE pd.product_id = p.id AND pd.daypart_id = ANY(` + param + `::uuid[])))
+		AND ` + daypartCategoryCondition("p.category_id", param)
+}
+
+// isManager reports whether the user of the request can override the daypart
+func isManager(c *gin.Context) bool {
+	_, _, role, ok := middleware.GetUserFromContext(c)
+	return ok && (role == "admin" || role == "manager")
+}
+
+// daypartOverride reports whether a menu listing should ignore the active
+// daypart: on admin routes, or when a manager asks for ?daypart=all
+func daypartOverride(c *gin.Context) bool {
+	return c.GetBool(allDaypartsKey) || (c.Query("daypart") == "all" && isManager(c))
+}
+
+// outOfDaypart returns the name of an ordered product that is not on the menu
+// in the current dayparts, or "" when all of them can be ordered
+func outOfDaypart(q queryer, items []models.CreateOrderItem, current []string) (string, error) {
+	productIDs := make([]string, len(items))
+	for i, item := range items {
+		productIDs[i] = item.ProductID.String()
+	}
+
+	var name string
+	err := q.QueryRow(`
+		SELECT p.name
+		FROM products p
+		WHERE p.id = ANY($1::uuid[]) AND NOT (`+daypartProductCondition("$2")+`)
+		ORDER BY p.name
+		LIMIT 1
+	`, pq.Array(productIDs), pq.Array(current)).Scan(&name)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return name, err
+}
+
+// GetMenus returns the menus with their dayparts, flagging the ones being served now
+func (h *MenuHandler) GetMenus(c *gin.Context) {
+	rows, err := h.db.Query(`
+		SELECT id, name, description, sort_order, is_active, created_at, updated_at
+		FROM menus
+		ORDER BY sort_order, name
+	`)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch menus",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	defer rows.Close()
+
+	menus := []models.Menu{}
+	byID := map[uuid.UUID]int{}
+	for rows.Next() {
+		var menu models.Menu
+		err := rows.Scan(&menu.ID, &menu.Name, &menu.Description, &menu.SortOrder, &menu.IsActive, &menu.CreatedAt, &menu.UpdatedAt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to scan menu",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+		menu.Dayparts = []models.Daypart{}
+		byID[menu.ID] = len(menus)
+		menus = append(menus, menu)
+	}
+	rows.Close()
+
+	all, err := loadDayparts(h.db)
+	if err == nil {
+		err = markCurrentDayparts(h.db, all, time.Now())
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch dayparts",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	for _, daypart := range all {
+		if i, ok := byID[daypart.MenuID]; ok {
+			menus[i].Dayparts = append(menus[i].Dayparts, daypart)
+		}
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Menus retrieved successfully",
+		Data:    menus,
+	})
+}
+
+// GetCurrentDayparts returns the dayparts being served right now
+func (h *MenuHandler) GetCurrentDayparts(c *gin.Context) {
+	all, err := loadDayparts(h.db)
+	if err == nil {
+		err = markCurrentDayparts(h.db, all, time.Now())
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch dayparts",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	current := []models.Daypart{}
+	for _, daypart := range all {
+		if daypart.IsCurrent {
+			current = append(current, daypart)
+		}
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Current dayparts retrieved successfully",
+		Data:    current,
+	})
+}
+
+// CreateMenu adds a menu
+func (h *MenuHandler) CreateMenu(c *gin.Context) {
+	var req models.CreateMenuRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Menu name is required",
+			Error:   stringPtr("invalid_menu"),
+		})
+		return
+	}
+
+	var menuID uuid.UUID
+	err := h.db.QueryRow(`
+		INSERT INTO menus (name, description, sort_order, is_active)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, name, req.Description, req.SortOrder, getBoolValue(req.IsActive, true)).Scan(&menuID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to create menu",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Menu created successfully",
+		Data:    map[string]interface{}{"id": menuID},
+	})
+}
+
+// UpdateMenu updates a menu
+func (h *MenuHandler) UpdateMenu(c *gin.Context) {
+	menuID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid menu ID",
+			Error:   stringPtr("invalid_uuid"),
+		})
+		return
+	}
+
+	var req models.UpdateMenuRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	// Build dynamic update query
+	updates := []string{}
+	args := []interface{}{}
+	argCount := 1
+
+	if req.Name != nil {
+		updates = append(updates, fmt.Sprintf("name = $%d", argCount))
+		args = append(args, strings.TrimSpace(*req.Name))
+		argCount++
+	}
+	if req.Description != nil {
+		updates = append(updates, fmt.Sprintf("description = $%d", argCount))
+		args = append(args, *req.Description)
+		argCount++
+	}
+	if req.SortOrder != nil {
+		updates = append(updates, fmt.Sprintf("sort_order = $%d", argCount))
+		args = append(args, *req.SortOrder)
+		argCount++
+	}
+	if req.IsActive != nil {
+		updates = append(updates, fmt.Sprintf("is_active = $%d", argCount))
+		args = append(args, *req.IsActive)
+		argCount++
+	}
+
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "No fields to update",
+			Error:   stringPtr("no_updates"),
+		})
+		return
+	}
+
+	updates = append(updates, "updated_at = CURRENT_TIMESTAMP")
+	args = append(args, menuID)
+
+	query := fmt.Sprintf("UPDATE menus SET %s WHERE id = $%d", strings.Join(updates, ", "), argCount)
+	result, err := h.db.Exec(query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to update menu",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Menu not found",
+			Error:   stringPtr("menu_not_found"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Menu updated successfully",
+	})
+}
+
+// DeleteMenu removes a menu and its dayparts. Categories and products that
+// were only served in them go back on the menu all day.
+func (h *MenuHandler) DeleteMenu(c *gin.Context) {
+	menuID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid menu ID",
+			Error:   stringPtr("invalid_uuid"),
+		})
+		return
+	}
+
+	result, err := h.db.Exec("DELETE FROM menus WHERE id = $1", menuID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to delete menu",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Menu not found",
+			Error:   stringPtr("menu_not_found"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Menu deleted successfully",
+	})
+}
+
+// CreateDaypart adds a serving window to a menu
+func (h *MenuHandler) CreateDaypart(c *gin.Context) {
+	menuID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid menu ID",
+			Error:   stringPtr("invalid_uuid"),
+		})
+		return
+	}
+
+	var req models.CreateDaypartRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	window := dayparts.Window{Days: req.DaysOfWeek, Start: req.StartTime, End: req.EndTime}
+	if err := window.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: err.Error(),
+			Error:   stringPtr("invalid_daypart"),
+		})
+		return
+	}
+
+	var daypartID uuid.UUID
+	err = h.db.QueryRow(`
+		INSERT INTO dayparts (menu_id, name, days_of_week, start_time, end_time, is_active)
+		SELECT id, $2, $3, $4, $5, $6 FROM menus WHERE id = $1
+		RETURNING id
+	`, menuID, strings.TrimSpace(req.Name), pq.Array(daysOfWeek(req.DaysOfWeek)), req.StartTime, req.EndTime,
+		getBoolValue(req.IsActive, true)).Scan(&daypartID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Menu not found",
+			Error:   stringPtr("menu_not_found"),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to create daypart",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Daypart created successfully",
+		Data:    map[string]interface{}{"id": daypartID},
+	})
+}
+
+// daysOfWeek returns the days a daypart is served; none means every day
+func daysOfWeek(days []int) []int {
+	if len(days) == 0 {
+		return []int{0, 1, 2, 3, 4, 5, 6}
+	}
+	return days
+}
+
+// UpdateDaypart updates the name, days or times of a daypart
+func (h *MenuHandler) UpdateDaypart(c *gin.Context) {
+	daypartID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid daypart ID",
+			Error:   stringPtr("invalid_uuid"),
+		})
+		return
+	}
+
+	var req models.UpdateDaypartRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	var daypart models.Daypart
+	err = h.db.QueryRow(`
+		SELECT name, days_of_week, to_char(start_time, 'HH24:MI'), to_char(end_time, 'HH24:MI'), is_active
+		FROM dayparts
+		WHERE id = $1
+	`, daypartID).Scan(&daypart.Name, pq.Array(&daypart.DaysOfWeek), &daypart.StartTime, &daypart.EndTime, &daypart.IsActive)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Daypart not found",
+			Error:   stringPtr("daypart_not_found"),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch daypart",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	window := daypartWindow(daypart)
+	if req.DaysOfWeek != nil {
+		window.Days = daysOfWeek(req.DaysOfWeek)
+	}
+	if req.StartTime != nil {
+		window.Start = *req.StartTime
+	}
+	if req.EndTime != nil {
+		window.End = *req.EndTime
+	}
+	if err := window.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: err.Error(),
+			Error:   stringPtr("invalid_daypart"),
+		})
+		return
+	}
+
+	_, err = h.db.Exec(`
+		UPDATE dayparts
+		SET name = $2, days_of_week = $3, start_time = $4, end_time = $5, is_active = $6, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, daypartID, strings.TrimSpace(getStringValue(req.Name, daypart.Name)), pq.Array(window.Days), window.Start, window.End,
+		getBoolValue(req.IsActive, daypart.IsActive))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to update daypart",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Daypart updated successfully",
+	})
+}
+
+// DeleteDaypart removes a daypart; what was assigned to it goes back on the
+// menu all day unless it has other dayparts
+func (h *MenuHandler) DeleteDaypart(c *gin.Context) {
+	daypartID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid daypart ID",
+			Error:   stringPtr("invalid_uuid"),
+		})
+		return
+	}
+
+	result, err := h.db.Exec("DELETE FROM dayparts WHERE id = $1", daypartID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to delete daypart",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Daypart not found",
+			Error:   stringPtr("daypart_not_found"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Daypart deleted successfully",
+	})
+}
+
+// SetCategoryDayparts replaces the dayparts a category is served in
+func (h *MenuHandler) SetCategoryDayparts(c *gin.Context) {
+	h.assignDayparts(c, "categories", "category_dayparts", "category_id", "Category")
+}
+
+// SetProductDayparts replaces the dayparts a product is served in
+func (h *MenuHandler) SetProductDayparts(c *gin.Context) {
+	h.assignDayparts(c, "products", "product_dayparts", "product_id", "Product")
+}
+
+func (h *MenuHandler) assignDayparts(c *gin.Context, table, joinTable, column, label string) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid " + strings.ToLower(label) + " ID",
+			Error:   stringPtr("invalid_uuid"),
+		})
+		return
+	}
+
+	var req models.AssignDaypartsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to start transaction",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM "+table+" WHERE id = $1)", id).Scan(&exists); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch " + strings.ToLower(label),
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: label + " not found",
+			Error:   stringPtr(strings.ToLower(label) + "_not_found"),
+		})
+		return
+	}
+
+	daypartIDs := []string{}
+	seen := map[uuid.UUID]bool{}
+	for _, daypartID := range req.DaypartIDs {
+		if !seen[daypartID] {
+			seen[daypartID] = true
+			daypartIDs = append(daypartIDs, daypartID.String())
+		}
+	}
+
+	var found int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM dayparts WHERE id = ANY($1::uuid[])", pq.Array(daypartIDs)).Scan(&found); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch dayparts",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	if found != len(daypartIDs) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "One or more dayparts were not found",
+			Error:   stringPtr("daypart_not_found"),
+		})
+		return
+	}
+
+	if _, err := tx.Exec("DELETE FROM "+joinTable+" WHERE "+column+" = $1", id); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to clear dayparts",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO `+joinTable+` (`+column+`, daypart_id)
+		SELECT $1, unnest($2::uuid[])
+	`, id, pq.Array(daypartIDs))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to assign dayparts",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to commit transaction",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: label + " dayparts updated successfully",
+	})
+}
@@ -0,0 +1,419 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"pos-backend/internal/events"
+	"pos-backend/internal/middleware"
+	"pos-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// eightySixJoin joins the product p to its entry on the 86 list as e86, while that entry is in effect
+const eightySixJoin = `LEFT JOIN product_86 e86 ON e86.product_id = p.id AND (e86.restore_at IS NULL OR e86.restore_at > CURRENT_TIMESTAMP)`
+
+// eightySixedCondition is true when the entry joined by eightySixJoin has the product sold out
+const eightySixedCondition = `(e86.product_id IS NOT NULL AND COALESCE(e86.remaining_count, 0) <= 0)`
+
+// GetEightySixList returns the products currently 86'd or limited to a remaining count
+func (h *KitchenHandler) GetEightySixList(c *gin.Context) {
+	rows, err := h.db.Query(`
+		SELECT e.product_id, p.name, COALESCE(e.remaining_count, 0) <= 0, e.remaining_count,
+		       e.restore_at, e.reason, e.marked_by, e.created_at, e.updated_at
+		FROM product_86 e
+		JOIN products p ON p.id = e.product_id
+		WHERE e.restore_at IS NULL OR e.restore_at > CURRENT_TIMESTAMP
+		ORDER BY p.name
+	`)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to fetch 86 list",
+			"error":   err.Error(),
+		})
+		return
+	}
+	defer rows.Close()
+
+	products := []models.EightySixedProduct{}
+	for rows.Next() {
+		var product models.EightySixedProduct
+		err := rows.Scan(
+			&product.ProductID, &product.ProductName, &product.SoldOut, &product.RemainingCount,
+			&product.RestoreAt, &product.Reason, &product.MarkedBy, &product.CreatedAt, &product.UpdatedAt,
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to scan 86 list",
+				"error":   err.Error(),
+			})
+			return
+		}
+		products = append(products, product)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "86 list retrieved successfully",
+		"data":    products,
+	})
+}
+
+// EightySixProduct takes a product off the menu, or limits it to a remaining
+// count. Marking a product that is already on the list replaces its entry.
+func (h *KitchenHandler) EightySixProduct(c *gin.Context) {
+	userID, _, _, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "Authentication required",
+			"error":   "auth_required",
+		})
+		return
+	}
+
+	var req models.EightySixRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if req.RemainingCount != nil && *req.RemainingCount < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Remaining count cannot be negative",
+			"error":   "invalid_remaining_count",
+		})
+		return
+	}
+
+	restoreAt := req.RestoreAt
+	if req.RestoreInMinutes != nil {
+		if *req.RestoreInMinutes <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Restore time must be in the future",
+				"error":   "invalid_restore_time",
+			})
+			return
+		}
+		at := time.Now().Add(time.Duration(*req.RestoreInMinutes) * time.Minute)
+		restoreAt = &at
+	}
+	if restoreAt != nil && !restoreAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Restore time must be in the future",
+			"error":   "invalid_restore_time",
+		})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to start transaction",
+			"error":   err.Error(),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	var productName string
+	err = tx.QueryRow("SELECT name FROM products WHERE id = $1", req.ProductID).Scan(&productName)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "Product not found",
+			"error":   "product_not_found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to fetch product",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO product_86 (product_id, remaining_count, restore_at, reason, marked_by)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (product_id) DO UPDATE
+		SET remaining_count = EXCLUDED.remaining_count, restore_at = EXCLUDED.restore_at,
+		    reason = EXCLUDED.reason, marked_by = EXCLUDED.marked_by,
+		    created_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+	`, req.ProductID, req.RemainingCount, restoreAt, req.Reason, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to 86 product",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	soldOut := req.RemainingCount == nil || *req.RemainingCount == 0
+	err = events.PublishProduct(tx, events.ProductEightySixed, req.ProductID, map[string]interface{}{
+		"product_name":    productName,
+		"sold_out":        soldOut,
+		"remaining_count": req.RemainingCount,
+		"restore_at":      restoreAt,
+		"reason":          req.Reason,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to publish 86 event",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to commit transaction",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	message := productName + " 86'd"
+	if !soldOut {
+		message = fmt.Sprintf("%s limited to %d remaining", productName, *req.RemainingCount)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"data": gin.H{
+			"product_id":      req.ProductID,
+			"sold_out":        soldOut,
+			"remaining_count": req.RemainingCount,
+			"restore_at":      restoreAt,
+		},
+	})
+}
+
+// RestoreProduct takes a product off the 86 list
+func (h *KitchenHandler) RestoreProduct(c *gin.Context) {
+	productID, err := uuid.Parse(c.Param("product_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid product ID",
+			"error":   "invalid_uuid",
+		})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to start transaction",
+			"error":   err.Error(),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM product_86 WHERE product_id = $1", productID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to restore product",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "Product is not on the 86 list",
+			"error":   "product_not_86d",
+		})
+		return
+	}
+
+	err = events.PublishProduct(tx, events.ProductRestored, productID, map[string]interface{}{
+		"trigger": "manual",
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to publish restore event",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to commit transaction",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Product restored successfully",
+	})
+}
+
+// claimEightySixed checks the products of a new order against the 86 list and
+// uses up limited portions, publishing an 86 event for any product that sells
+// out. When a product cannot be sold it returns the error code and message for
+// the client. Entries are locked in product order so concurrent orders cannot
+// deadlock or oversell.
+func claimEightySixed(tx *sql.Tx, items []models.CreateOrderItem) (string, string, error) {
+	quantities := map[uuid.UUID]int{}
+	productIDs := []uuid.UUID{}
+	for _, item := range items {
+		if _, ok := quantities[item.ProductID]; !ok {
+			productIDs = append(productIDs, item.ProductID)
+		}
+		quantities[item.ProductID] += item.Quantity
+	}
+	sort.Slice(productIDs, func(i, j int) bool {
+		return productIDs[i].String() < productIDs[j].String()
+	})
+
+	for _, productID := range productIDs {
+		var productName string
+		var remaining sql.NullInt64
+		err := tx.QueryRow(`
+			SELECT p.name, e.remaining_count
+			FROM product_86 e
+			JOIN products p ON p.id = e.product_id
+			WHERE e.product_id = $1 AND (e.restore_at IS NULL OR e.restore_at > CURRENT_TIMESTAMP)
+			FOR UPDATE OF e
+		`, productID).Scan(&productName, &remaining)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return "", "", err
+		}
+
+		if !remaining.Valid || remaining.Int64 <= 0 {
+			return "product_86d", productName + " is 86'd (sold out)", nil
+		}
+		quantity := int64(quantities[productID])
+		if quantity > remaining.Int64 {
+			return "insufficient_remaining", fmt.Sprintf("Only %d %s left", remaining.Int64, productName), nil
+		}
+
+		left := remaining.Int64 - quantity
+		_, err = tx.Exec(`
+			UPDATE product_86 SET remaining_count = $2, updated_at = CURRENT_TIMESTAMP
+			WHERE product_id = $1
+		`, productID, left)
+		if err != nil {
+			return "", "", err
+		}
+
+		if left == 0 {
+			err = events.PublishProduct(tx, events.ProductEightySixed, productID, map[string]interface{}{
+				"product_name":    productName,
+				"sold_out":        true,
+				"remaining_count": 0,
+			})
+			if err != nil {
+				return "", "", err
+			}
+		}
+	}
+
+	return "", "", nil
+}
+
+// releaseEightySixed gives the limited portions a cancelled order used up back
+// to the 86 list, publishing an 86 event for products back on sale. Counts set
+// after the order was placed already reflect what is left and are not touched.
+func releaseEightySixed(tx *sql.Tx, orderID uuid.UUID) error {
+	// Lock the entries in product order, like claimEightySixed
+	_, err := tx.Exec(`
+		SELECT e.product_id
+		FROM product_86 e
+		WHERE e.product_id IN (SELECT product_id FROM order_items WHERE order_id = $1)
+		ORDER BY e.product_id
+		FOR UPDATE
+	`, orderID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(`
+		UPDATE product_86 e
+		SET remaining_count = e.remaining_count + ordered.quantity, updated_at = CURRENT_TIMESTAMP
+		FROM (
+			SELECT oi.product_id, o.created_at, SUM(oi.quantity) AS quantity
+			FROM order_items oi
+			JOIN orders o ON o.id = oi.order_id
+			WHERE oi.order_id = $1 AND oi.product_id IS NOT NULL AND NOT oi.is_remake
+			GROUP BY oi.product_id, o.created_at
+		) ordered, products p
+		WHERE e.product_id = ordered.product_id AND p.id = e.product_id
+		  AND e.remaining_count IS NOT NULL
+		  AND e.created_at <= ordered.created_at
+		  AND (e.restore_at IS NULL OR e.restore_at > CURRENT_TIMESTAMP)
+		RETURNING e.product_id, p.name, e.remaining_count, ordered.quantity
+	`, orderID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type release struct {
+		productID   uuid.UUID
+		productName string
+		remaining   int64
+		quantity    int64
+	}
+	releases := []release{}
+	for rows.Next() {
+		var r release
+		if err := rows.Scan(&r.productID, &r.productName, &r.remaining, &r.quantity); err != nil {
+			return err
+		}
+		releases = append(releases, r)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	for _, r := range releases {
+		if r.remaining != r.quantity {
+			continue
+		}
+		err = events.PublishProduct(tx, events.ProductEightySixed, r.productID, map[string]interface{}{
+			"product_name":    r.productName,
+			"sold_out":        false,
+			"remaining_count": r.remaining,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,399 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+
+	"pos-backend/internal/mailer"
+	"pos-backend/internal/middleware"
+	"pos-backend/internal/models"
+	"pos-backend/internal/receipt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var errNoRecipient = errors.New("order has no customer email")
+
+type EmailHandler struct {
+	db     *sql.DB
+	orders *OrderHandler
+}
+
+func NewEmailHandler(db *sql.DB) *EmailHandler {
+	return &EmailHandler{db: db, orders: NewOrderHandler(db)}
+}
+
+// SendOrderReceipt queues the receipt email for an order. It can be called again
+// to resend; an email in the request also becomes the order's customer email.
+func (h *EmailHandler) SendOrderReceipt(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid order ID",
+			Error:   stringPtr("invalid_uuid"),
+		})
+		return
+	}
+
+	var req models.SendReceiptEmailRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid request data",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+	}
+
+	if req.Email != nil {
+		address, err := mail.ParseAddress(strings.TrimSpace(*req.Email))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid email address",
+				Error:   stringPtr("invalid_email"),
+			})
+			return
+		}
+
+		result, err := h.db.Exec("UPDATE orders SET customer_email = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2", address.Address, orderID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to update customer email",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+			c.JSON(http.StatusNotFound, models.APIResponse{
+				Success: false,
+				Message: "Order not found",
+				Error:   stringPtr("order_not_found"),
+			})
+			return
+		}
+	}
+
+	userID, _, _, _ := middleware.GetUserFromContext(c)
+
+	jobID, err := enqueueReceiptEmail(h.db, h.orders, orderID, &userID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Order not found",
+			Error:   stringPtr("order_not_found"),
+		})
+		return
+	}
+	if err == errNoRecipient {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Order has no customer email",
+			Error:   stringPtr("email_required"),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to queue receipt email",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, models.APIResponse{
+		Success: true,
+		Message: "Receipt email queued",
+		Data:    map[string]interface{}{"job_id": jobID},
+	})
+}
+
+// GetEmailJobs lists queued and sent emails, newest first
+func (h *EmailHandler) GetEmailJobs(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	query := `
+		SELECT id, order_id, template, recipient, subject, status, attempts, max_attempts,
+		       last_error, next_attempt_at, created_by, sent_at, created_at, updated_at
+		FROM email_jobs
+		WHERE 1=1
+	`
+	args := []interface{}{}
+	argCount := 1
+
+	if status := c.Query("status"); status != "" {
+		query += fmt.Sprintf(" AND status = $%d", argCount)
+		args = append(args, status)
+		argCount++
+	}
+	if orderID := c.Query("order_id"); orderID != "" {
+		query += fmt.Sprintf(" AND order_id = $%d", argCount)
+		args = append(args, orderID)
+		argCount++
+	}
+
+	query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d", argCount)
+	args = append(args, limit)
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch email jobs",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	defer rows.Close()
+
+	var jobs []models.EmailJob
+	for rows.Next() {
+		var job models.EmailJob
+		err := rows.Scan(
+			&job.ID, &job.OrderID, &job.Template, &job.Recipient, &job.Subject, &job.Status,
+			&job.Attempts, &job.MaxAttempts, &job.LastError, &job.NextAttemptAt,
+			&job.CreatedBy, &job.SentAt, &job.CreatedAt, &job.UpdatedAt,
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to scan email job",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+		jobs = append(jobs, job)
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Email jobs retrieved successfully",
+		Data:    jobs,
+	})
+}
+
+// GetEmailTemplate returns the current version of a template
+func (h *EmailHandler) GetEmailTemplate(c *gin.Context) {
+	name := c.Param("name")
+	if _, ok := mailer.DefaultTemplates[name]; !ok {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Email template not found",
+			Error:   stringPtr("template_not_found"),
+		})
+		return
+	}
+
+	emailTemplate, err := loadEmailTemplate(h.db, name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch email template",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Email template retrieved successfully",
+		Data:    emailTemplate,
+	})
+}
+
+// UpdateEmailTemplate saves a customized template after checking that it parses
+func (h *EmailHandler) UpdateEmailTemplate(c *gin.Context) {
+	name := c.Param("name")
+	if _, ok := mailer.DefaultTemplates[name]; !ok {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Email template not found",
+			Error:   stringPtr("template_not_found"),
+		})
+		return
+	}
+
+	var req models.UpdateEmailTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	tmpl := mailer.Template{Subject: req.Subject, Text: req.TextBody, HTML: req.HTMLBody}
+	if err := tmpl.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid template: " + err.Error(),
+			Error:   stringPtr("invalid_template"),
+		})
+		return
+	}
+
+	userID, _, _, _ := middleware.GetUserFromContext(c)
+
+	_, err := h.db.Exec(`
+		INSERT INTO email_templates (name, subject, text_body, html_body, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP)
+		ON CONFLICT (name) DO UPDATE
+		SET subject = EXCLUDED.subject, text_body = EXCLUDED.text_body, html_body = EXCLUDED.html_body,
+		    updated_by = EXCLUDED.updated_by, updated_at = CURRENT_TIMESTAMP
+	`, name, req.Subject, req.TextBody, req.HTMLBody, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to save email template",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Email template updated successfully",
+	})
+}
+
+// ResetEmailTemplate discards a customized template and restores the built-in one
+func (h *EmailHandler) ResetEmailTemplate(c *gin.Context) {
+	name := c.Param("name")
+	if _, ok := mailer.DefaultTemplates[name]; !ok {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Email template not found",
+			Error:   stringPtr("template_not_found"),
+		})
+		return
+	}
+
+	if _, err := h.db.Exec("DELETE FROM email_templates WHERE name = $1", name); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to reset email template",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Email template reset to default",
+	})
+}
+
+// Helper functions
+
+// loadEmailTemplate returns the saved template, or the built-in one if none was saved
+func loadEmailTemplate(q queryer, name string) (*models.EmailTemplate, error) {
+	emailTemplate := models.EmailTemplate{Name: name}
+
+	err := q.QueryRow(`
+		SELECT subject, text_body, html_body, updated_by, updated_at
+		FROM email_templates
+		WHERE name = $1
+	`, name).Scan(&emailTemplate.Subject, &emailTemplate.TextBody, &emailTemplate.HTMLBody,
+		&emailTemplate.UpdatedBy, &emailTemplate.UpdatedAt)
+	if err == sql.ErrNoRows {
+		defaults := mailer.DefaultTemplates[name]
+		emailTemplate.Subject = defaults.Subject
+		emailTemplate.TextBody = defaults.Text
+		emailTemplate.HTMLBody = defaults.HTML
+		emailTemplate.IsDefault = true
+		return &emailTemplate, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &emailTemplate, nil
+}
+
+// enqueueReceiptEmail renders the receipt template for an order and queues it for
+// delivery to the order's customer email
+func enqueueReceiptEmail(db *sql.DB, orders *OrderHandler, orderID uuid.UUID, requestedBy *uuid.UUID) (uuid.UUID, error) {
+	var jobID uuid.UUID
+
+	order, err := orders.getOrderByID(orderID)
+	if err != nil {
+		return jobID, err
+	}
+	if order.CustomerEmail == nil || strings.TrimSpace(*order.CustomerEmail) == "" {
+		return jobID, errNoRecipient
+	}
+
+	settings, err := loadSettings(db)
+	if err != nil {
+		return jobID, err
+	}
+
+	emailTemplate, err := loadEmailTemplate(db, "receipt")
+	if err != nil {
+		return jobID, err
+	}
+
+	// Resends carry the COPY marker like reprinted receipts
+	var previous int
+	err = db.QueryRow("SELECT COUNT(*) FROM email_jobs WHERE order_id = $1 AND template = 'receipt'", orderID).Scan(&previous)
+	if err != nil {
+		return jobID, err
+	}
+
+	doc := receipt.NewReceipt(order, settings, previous > 0)
+	msg, err := mailer.Template{
+		Subject: emailTemplate.Subject,
+		Text:    emailTemplate.TextBody,
+		HTML:    emailTemplate.HTMLBody,
+	}.Render(mailer.ReceiptData{
+		Store:       settings,
+		Order:       order,
+		Receipt:     doc,
+		ReceiptText: string(receipt.RenderText(doc, receipt.DefaultWidth)),
+	})
+	if err != nil {
+		return jobID, err
+	}
+
+	err = db.QueryRow(`
+		INSERT INTO email_jobs (order_id, template, from_name, reply_to, recipient, subject, text_body, html_body, created_by)
+		VALUES ($1, 'receipt', $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`, orderID, settings.Name, settings.Email, strings.TrimSpace(*order.CustomerEmail),
+		msg.Subject, msg.Text, msg.HTML, requestedBy).Scan(&jobID)
+	return jobID, err
+}
+
+// autoEmailReceipt queues the receipt email of a completed order when auto-send is
+// on and the guest left an email. Failures are logged and never fail the request.
+func autoEmailReceipt(db *sql.DB, orderID uuid.UUID, requestedBy *uuid.UUID) {
+	settings, err := loadSettings(db)
+	if err != nil {
+		log.Printf("auto email: failed to load settings: %v", err)
+		return
+	}
+	if !settings.AutoEmailReceipts {
+		return
+	}
+
+	_, err = enqueueReceiptEmail(db, NewOrderHandler(db), orderID, requestedBy)
+	if err != nil && err != errNoRecipient {
+		log.Printf("auto email: failed to queue receipt for order %s: %v", orderID, err)
+	}
+}
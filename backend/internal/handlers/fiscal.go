@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"pos-backend/internal/fiscal"
+	"pos-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FiscalHandler struct {
+	db *sql.DB
+}
+
+func NewFiscalHandler(db *sql.DB) *FiscalHandler {
+	return &FiscalHandler{db: db}
+}
+
+// GetFiscalJournal returns journal entries, newest first
+func (h *FiscalHandler) GetFiscalJournal(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if limit < 1 || limit > 1000 {
+		limit = 100
+	}
+
+	rows, err := h.db.Query(`
+		SELECT fiscal_number, entry_type, order_id, order_number, payment_id, refund_id,
+		       amount, tax_amount, currency, created_at, previous_hash, hash
+		FROM fiscal_journal
+		ORDER BY fiscal_number DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch fiscal journal",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	defer rows.Close()
+
+	entries := []fiscal.Entry{}
+	for rows.Next() {
+		var entry fiscal.Entry
+		err := rows.Scan(
+			&entry.FiscalNumber, &entry.EntryType, &entry.OrderID, &entry.OrderNumber,
+			&entry.PaymentID, &entry.RefundID, &entry.Amount, &entry.TaxAmount, &entry.Currency,
+			&entry.CreatedAt, &entry.PreviousHash, &entry.Hash,
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to scan fiscal journal entry",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+		entries = append(entries, entry)
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Fiscal journal retrieved successfully",
+		Data:    entries,
+	})
+}
+
+// VerifyFiscalJournal checks the journal for gaps and tampering
+func (h *FiscalHandler) VerifyFiscalJournal(c *gin.Context) {
+	checked, problems, err := fiscal.Verify(h.db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to verify fiscal journal",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	message := "Fiscal journal is intact"
+	if len(problems) > 0 {
+		message = fmt.Sprintf("Fiscal journal has %d problem(s)", len(problems))
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: message,
+		Data: map[string]interface{}{
+			"entries_checked": checked,
+			"valid":           len(problems) == 0,
+			"problems":        problems,
+		},
+	})
+}
+
+// ExportFiscalJournal downloads the journal for a date range as a flat file.
+// Dates are inclusive and default to the current month.
+func (h *FiscalHandler) ExportFiscalJournal(c *gin.Context) {
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	to := now
+
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, now.Location())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid from date. Use YYYY-MM-DD",
+				Error:   stringPtr("invalid_date"),
+			})
+			return
+		}
+		from = parsed
+	}
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, now.Location())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid to date. Use YYYY-MM-DD",
+				Error:   stringPtr("invalid_date"),
+			})
+			return
+		}
+		to = parsed
+	}
+	end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, to.Location()).AddDate(0, 0, 1)
+
+	var b bytes.Buffer
+	if _, err := fiscal.Export(h.db, &b, from, end); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to export fiscal journal",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("fiscal-journal-%s-%s.txt", from.Format("20060102"), to.Format("20060102"))
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", b.Bytes())
+}
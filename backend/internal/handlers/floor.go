@@ -0,0 +1,381 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"pos-backend/internal/middleware"
+	"pos-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// syncTableStatus moves the table of an order through its lifecycle after an
+// order event: a table with an open dine-in order is occupied, and once its
+// last order is closed it needs cleaning (or is available straight away when
+// the order was cancelled). Tables out of service are left alone, and only an
+// occupied table is released, so a table held for a reservation stays held.
+// A table group is split again once its last order is closed.
+func syncTableStatus(tx *sql.Tx, orderID uuid.UUID) error {
+	// Lock the table before looking at its other orders, so two orders on it
+	// closing at once cannot each miss the other and free the table early
+	_, err := tx.Exec(`
+		SELECT t.id
+		FROM orders o
+		JOIN dining_tables t ON o.table_id = t.id
+		WHERE o.id = $1
+		FOR UPDATE OF t
+	`, orderID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE dining_tables t
+		SET status = next.status,
+		    reserved_for = CASE WHEN next.status = 'occupied' THEN NULL ELSE t.reserved_for END,
+		    status_changed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		FROM (
+			SELECT o.table_id,
+			       CASE
+			           WHEN EXISTS (
+			               SELECT 1 FROM orders other
+			               WHERE other.table_id = o.table_id
+			                 AND other.order_type = 'dine_in'
+			                 AND other.status NOT IN ('completed', 'cancelled')
+			           ) THEN 'occupied'
+			           WHEN o.status = 'completed' THEN 'needs_cleaning'
+			           ELSE 'available'
+			       END AS status
+			FROM orders o
+			WHERE o.id = $1 AND o.table_id IS NOT NULL AND o.order_type = 'dine_in'
+		) next
+		WHERE t.id = next.table_id
+		  AND t.status <> 'out_of_service'
+		  AND t.status <> next.status
+		  AND (next.status = 'occupied' OR t.status = 'occupied')
+	`, orderID)
+	if err != nil {
+		return err
+	}
+
+	var groupID uuid.UUID
+	var status string
+	err = tx.QueryRow(`
+		SELECT t.id, t.status
+		FROM orders o
+		JOIN dining_tables t ON o.table_id = t.id
+		WHERE o.id = $1 AND t.is_group = true AND o.status IN ('completed', 'cancelled')
+		FOR UPDATE OF t
+	`, orderID).Scan(&groupID, &status)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// The group's other orders keep it together
+	if err := splitTableGroup(tx, groupID, status); err != errGroupInUse {
+		return err
+	}
+	return nil
+}
+
+// GetFloor returns the live floor: every table with its layout, server, open
+// order, covers, how long the party has been seated, where the kitchen is with
+// their items and the amount still due. Tables pushed together are shown as
+// their group. ?location= and ?section=mine (or a section ID) narrow it down
+// like GetTables.
+func (h *TableHandler) GetFloor(c *gin.Context) {
+	query := `
+		SELECT t.id, t.table_number, t.seating_capacity, t.location, t.status,
+		       t.pos_x, t.pos_y, t.width, t.height, t.shape, t.rotation,
+		       s.id, s.name, s.color, s.server_id, s.server_name,
+		       t.is_group, t.created_at, t.updated_at, t.status_changed_at,
+		       o.id, o.order_number, o.customer_name, o.status, o.total_amount, o.created_at,
+		       o.user_id, o.opened_by,
+		       COALESCE(totals.order_count, 0), COALESCE(totals.covers, 0),
+		       COALESCE(totals.total_amount, 0), COALESCE(totals.paid, 0),
+		       COALESCE(items.pending, 0), COALESCE(items.preparing, 0),
+		       COALESCE(items.ready, 0), COALESCE(items.served, 0), COALESCE(items.late, 0)
+		FROM dining_tables t
+		LEFT JOIN LATERAL (
+			SELECT ss.id, ss.name, ss.color, ss.server_id,
+			       COALESCE(u.first_name || ' ' || u.last_name, '') AS server_name
+			FROM server_section_tables sst
+			JOIN server_sections ss ON sst.section_id = ss.id
+			LEFT JOIN users u ON ss.server_id = u.id
+			WHERE sst.table_id = t.id
+			  AND ss.shift_start <= CURRENT_TIMESTAMP AND ss.shift_end > CURRENT_TIMESTAMP
+			ORDER BY ss.shift_start DESC
+			LIMIT 1
+		) s ON true
+		LEFT JOIN LATERAL (
+			SELECT o.id, o.order_number, o.customer_name, o.status, o.total_amount, o.created_at, o.user_id,
+			       COALESCE(u.first_name || ' ' || u.last_name, '') AS opened_by
+			FROM orders o
+			LEFT JOIN users u ON o.user_id = u.id
+			WHERE o.table_id = t.id AND o.order_type = 'dine_in' AND o.status NOT IN ('completed', 'cancelled')
+			ORDER BY o.created_at
+			LIMIT 1
+		) o ON true
+		LEFT JOIN LATERAL (
+			SELECT COUNT(*) AS order_count,
+			       SUM(COALESCE(oo.covers, 0)) AS covers,
+			       SUM(oo.total_amount) AS total_amount,
+			       SUM((SELECT COALESCE(SUM(p.amount), 0) FROM payments p
+			            WHERE p.order_id = oo.id AND p.status = 'completed')) AS paid
+			FROM orders oo
+			WHERE oo.table_id = t.id AND oo.order_type = 'dine_in' AND oo.status NOT IN ('completed', 'cancelled')
+		) totals ON true
+		LEFT JOIN LATERAL (
+			SELECT COUNT(*) FILTER (WHERE oi.status = 'pending') AS pending,
+			       COUNT(*) FILTER (WHERE oi.status = 'preparing') AS preparing,
+			       COUNT(*) FILTER (WHERE oi.status = 'ready') AS ready,
+			       COUNT(*) FILTER (WHERE oi.status = 'served') AS served,
+			       COUNT(*) FILTER (WHERE oi.late_at IS NOT NULL AND oi.status IN ('pending', 'preparing')) AS late
+			FROM order_items oi
+			JOIN orders oo ON oi.order_id = oo.id
+			WHERE oo.table_id = t.id AND oo.order_type = 'dine_in' AND oo.status NOT IN ('completed', 'cancelled')
+		) items ON true
+		WHERE t.joined_to IS NULL
+	`
+
+	args := []interface{}{}
+	if location := c.Query("location"); location != "" {
+		args = append(args, location)
+		query += fmt.Sprintf(` AND t.location = $%d`, len(args))
+	}
+	if value := c.Query("section"); value != "" {
+		if value == "mine" {
+			userID, _, _, ok := middleware.GetUserFromContext(c)
+			if !ok {
+				c.JSON(http.StatusUnauthorized, models.APIResponse{
+					Success: false,
+					Message: "Authentication required",
+					Error:   stringPtr("auth_required"),
+				})
+				return
+			}
+			args = append(args, userID)
+			query += fmt.Sprintf(` AND s.server_id = $%d`, len(args))
+		} else {
+			sectionID, err := uuid.Parse(value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, models.APIResponse{
+					Success: false,
+					Message: "Invalid section. Use mine or a section ID",
+					Error:   stringPtr("invalid_section"),
+				})
+				return
+			}
+			args = append(args, sectionID)
+			query += fmt.Sprintf(` AND s.id = $%d`, len(args))
+		}
+	}
+	query += ` ORDER BY t.location, t.table_number`
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch floor",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	tables := []models.FloorTable{}
+	summary := map[string]int{"available": 0, "occupied": 0, "needs_cleaning": 0, "reserved": 0, "out_of_service": 0}
+	covers := 0
+	for rows.Next() {
+		var table models.FloorTable
+		var layout models.TableLayout
+		var sectionID, sectionServerID *uuid.UUID
+		var sectionName, sectionServerName, color *string
+		var orderID, orderUserID *uuid.UUID
+		var orderNumber, orderStatus, openedBy *string
+		var customerName *string
+		var orderTotal *float64
+		var orderCreatedAt *time.Time
+		var totalAmount, paid float64
+		var pending, preparing, ready, served, late int
+
+		err := rows.Scan(
+			&table.ID, &table.TableNumber, &table.SeatingCapacity, &table.Location, &table.Status,
+			&layout.PosX, &layout.PosY, &layout.Width, &layout.Height, &layout.Shape, &layout.Rotation,
+			&sectionID, &sectionName, &color, &sectionServerID, &sectionServerName,
+			&table.IsGroup, &table.CreatedAt, &table.UpdatedAt, &table.StatusSince,
+			&orderID, &orderNumber, &customerName, &orderStatus, &orderTotal, &orderCreatedAt,
+			&orderUserID, &openedBy,
+			&table.OpenOrderCount, &table.Covers, &totalAmount, &paid,
+			&pending, &preparing, &ready, &served, &late,
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to scan table",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+
+		table.Layout = &layout
+		if sectionID != nil {
+			table.Section = &models.TableSection{
+				ID:         *sectionID,
+				Name:       *sectionName,
+				Color:      color,
+				ServerID:   *sectionServerID,
+				ServerName: *sectionServerName,
+			}
+			table.Server = &models.FloorServer{ID: *sectionServerID, Name: *sectionServerName, Source: "section"}
+		}
+
+		if orderID != nil {
+			table.OpenOrder = &models.FloorOrder{
+				ID:           *orderID,
+				OrderNumber:  *orderNumber,
+				CustomerName: customerName,
+				Status:       *orderStatus,
+				TotalAmount:  *orderTotal,
+				CreatedAt:    *orderCreatedAt,
+			}
+			seated := int(now.Sub(*orderCreatedAt).Minutes())
+			table.SeatedMinutes = &seated
+			if table.Server == nil && orderUserID != nil {
+				table.Server = &models.FloorServer{ID: *orderUserID, Name: *openedBy, Source: "order"}
+			}
+		} else if table.Status == "occupied" && table.StatusSince != nil {
+			// Seated from a reservation or the waitlist, waiting for their order to be taken
+			seated := int(now.Sub(*table.StatusSince).Minutes())
+			table.SeatedMinutes = &seated
+		}
+
+		table.AmountPaid = paid
+		table.AmountDue = totalAmount - paid
+		if table.AmountDue < 0 {
+			table.AmountDue = 0
+		}
+		table.ItemCounts = map[string]int{"pending": pending, "preparing": preparing, "ready": ready, "served": served, "late": late}
+		table.KitchenState = kitchenState(orderID != nil, pending, preparing, ready, served, late)
+
+		covers += table.Covers
+		tables = append(tables, table)
+	}
+	rows.Close()
+
+	groupIDs := []uuid.UUID{}
+	for _, table := range tables {
+		if table.IsGroup {
+			groupIDs = append(groupIDs, table.ID)
+		}
+	}
+	members, err := loadTableMembers(h.db, groupIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch group tables",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	// Tables are counted one by one, those of a group with the group's status
+	for i := range tables {
+		if tables[i].IsGroup {
+			tables[i].Members = members[tables[i].ID]
+			summary[tables[i].Status] += len(tables[i].Members)
+		} else {
+			summary[tables[i].Status]++
+		}
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Floor retrieved successfully",
+		Data: map[string]interface{}{
+			"tables": tables,
+			"summary": map[string]interface{}{
+				"by_status": summary,
+				"covers":    covers,
+			},
+		},
+	})
+}
+
+// kitchenState sums up where the kitchen is with the items of a table's open
+// orders. Items waiting at the pass come first, since the server has to act.
+func kitchenState(hasOrder bool, pending, preparing, ready, served, late int) string {
+	switch {
+	case !hasOrder:
+		return "none"
+	case ready > 0:
+		return "ready_to_serve"
+	case late > 0:
+		return "late"
+	case pending+preparing > 0:
+		return "in_kitchen"
+	case served > 0:
+		return "served"
+	default:
+		return "ordering"
+	}
+}
+
+// MarkTableClean makes a table that has been cleared available for the next party
+func (h *TableHandler) MarkTableClean(c *gin.Context) {
+	tableID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid table ID",
+			Error:   stringPtr("invalid_uuid"),
+		})
+		return
+	}
+
+	var status string
+	err = h.db.QueryRow(`
+		UPDATE dining_tables
+		SET status = 'available', status_changed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'needs_cleaning'
+		RETURNING status
+	`, tableID).Scan(&status)
+	if err == sql.ErrNoRows {
+		var current string
+		if err := h.db.QueryRow("SELECT status FROM dining_tables WHERE id = $1", tableID).Scan(&current); err != nil {
+			c.JSON(http.StatusNotFound, models.APIResponse{
+				Success: false,
+				Message: "Table not found",
+				Error:   stringPtr("table_not_found"),
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Table does not need cleaning - table is " + current,
+			Error:   stringPtr("invalid_table_status"),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to update table status",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Table is available",
+	})
+}
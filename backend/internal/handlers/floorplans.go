@@ -0,0 +1,747 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"pos-backend/internal/models"
+	"pos-backend/internal/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// tableLocations are the locations a dining table can be in
+var tableLocations = map[string]bool{
+	"main_floor":      true,
+	"patio":           true,
+	"private_room":    true,
+	"outdoor":         true,
+	"bar_counter":     true,
+	"takeout_counter": true,
+	"window_side":     true,
+}
+
+// tableShapes are the shapes a table can be drawn with on a floor plan
+var tableShapes = map[string]bool{
+	"square":    true,
+	"rectangle": true,
+	"round":     true,
+	"oval":      true,
+	"booth":     true,
+}
+
+// tableLayoutInput is the layout of a table as sent to the admin API. Fields
+// left out keep their current value.
+type tableLayoutInput struct {
+	PosX     *float64 `json:"pos_x"`
+	PosY     *float64 `json:"pos_y"`
+	Width    *float64 `json:"width"`
+	Height   *float64 `json:"height"`
+	Shape    *string  `json:"shape"`
+	Rotation *int     `json:"rotation"`
+}
+
+// validate checks the layout values and returns a message when one is invalid
+func (l tableLayoutInput) validate() string {
+	if (l.PosX != nil && *l.PosX < 0) || (l.PosY != nil && *l.PosY < 0) {
+		return "Table position cannot be negative"
+	}
+	if (l.Width != nil && *l.Width <= 0) || (l.Height != nil && *l.Height <= 0) {
+		return "Table width and height must be positive"
+	}
+	if l.Shape != nil && !tableShapes[*l.Shape] {
+		return "Invalid shape. Use square, rectangle, round, oval or booth"
+	}
+	if l.Rotation != nil && (*l.Rotation < 0 || *l.Rotation >= 360) {
+		return "Rotation must be between 0 and 359 degrees"
+	}
+	return ""
+}
+
+// updates adds the layout columns that were sent to a dynamic update
+func (l tableLayoutInput) updates(updates []string, args []interface{}, argCount int) ([]string, []interface{}, int) {
+	columns := []struct {
+		name  string
+		value interface{}
+		set   bool
+	}{
+		{"pos_x", l.PosX, l.PosX != nil},
+		{"pos_y", l.PosY, l.PosY != nil},
+		{"width", l.Width, l.Width != nil},
+		{"height", l.Height, l.Height != nil},
+		{"shape", l.Shape, l.Shape != nil},
+		{"rotation", l.Rotation, l.Rotation != nil},
+	}
+	for _, column := range columns {
+		if !column.set {
+			continue
+		}
+		updates = append(updates, fmt.Sprintf("%s = $%d", column.name, argCount))
+		args = append(args, column.value)
+		argCount++
+	}
+	return updates, args, argCount
+}
+
+// SaveFloorPlan creates or updates the floor plan of a location
+func (h *AdminHandler) SaveFloorPlan(c *gin.Context) {
+	location := c.Param("location")
+	if !tableLocations[location] {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid location",
+			"error":   "invalid_location",
+		})
+		return
+	}
+
+	var req struct {
+		Name          string  `json:"name" binding:"required"`
+		Width         int     `json:"width"`
+		Height        int     `json:"height"`
+		BackgroundURL *string `json:"background_url"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if req.Width == 0 {
+		req.Width = 1000
+	}
+	if req.Height == 0 {
+		req.Height = 1000
+	}
+	if req.Width < 0 || req.Height < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Floor plan width and height must be positive",
+			"error":   "invalid_size",
+		})
+		return
+	}
+
+	var planID string
+	err := h.db.QueryRow(`
+		INSERT INTO floor_plans (location, name, width, height, background_url)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (location) DO UPDATE
+		SET name = EXCLUDED.name, width = EXCLUDED.width, height = EXCLUDED.height,
+		    background_url = EXCLUDED.background_url, updated_at = CURRENT_TIMESTAMP
+		RETURNING id
+	`, location, req.Name, req.Width, req.Height, req.BackgroundURL).Scan(&planID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to save floor plan",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Floor plan saved successfully",
+		"data":    gin.H{"id": planID},
+	})
+}
+
+// DeleteFloorPlan deletes the floor plan of a location. The tables keep their layout.
+func (h *AdminHandler) DeleteFloorPlan(c *gin.Context) {
+	result, err := h.db.Exec("DELETE FROM floor_plans WHERE location = $1", c.Param("location"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to delete floor plan",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "Floor plan not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Floor plan deleted successfully",
+	})
+}
+
+// UpdateFloorLayout places several tables of a location at once, as saved
+// from the floor plan editor. Either every table is updated or none is.
+func (h *AdminHandler) UpdateFloorLayout(c *gin.Context) {
+	location := c.Param("location")
+
+	var req struct {
+		Tables []struct {
+			TableID uuid.UUID `json:"table_id" binding:"required"`
+			tableLayoutInput
+		} `json:"tables" binding:"required,min=1,dive"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to start transaction",
+			"error":   err.Error(),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	for _, table := range req.Tables {
+		if message := table.validate(); message != "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": message,
+				"error":   "invalid_layout",
+			})
+			return
+		}
+
+		updates, args, argCount := table.updates([]string{}, []interface{}{}, 1)
+		if len(updates) == 0 {
+			continue
+		}
+		updates = append(updates, "updated_at = CURRENT_TIMESTAMP")
+		args = append(args, table.TableID, location)
+
+		query := fmt.Sprintf("UPDATE dining_tables SET %s WHERE id = $%d AND location = $%d",
+			strings.Join(updates, ", "), argCount, argCount+1)
+		result, err := tx.Exec(query, args...)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to update table layout",
+				"error":   err.Error(),
+			})
+			return
+		}
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Table " + table.TableID.String() + " is not in location " + location,
+				"error":   "table_not_found",
+			})
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to commit transaction",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Floor layout updated successfully",
+	})
+}
+
+// GetSections returns the server sections with a shift on ?date= (YYYY-MM-DD,
+// default today, in the restaurant's timezone)
+func (h *AdminHandler) GetSections(c *gin.Context) {
+	location, err := util.StoreLocation(h.db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to load settings",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	day := time.Now().In(location)
+	if value := c.Query("date"); value != "" {
+		day, err = time.ParseInLocation("2006-01-02", value, location)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Invalid date. Use YYYY-MM-DD",
+				"error":   "invalid_date",
+			})
+			return
+		}
+	}
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, location)
+	end := start.AddDate(0, 0, 1)
+
+	rows, err := h.db.Query(`
+		SELECT s.id, s.name, s.server_id, COALESCE(u.first_name || ' ' || u.last_name, ''), s.color,
+		       s.shift_start, s.shift_end, s.notes,
+		       ARRAY(SELECT st.table_id FROM server_section_tables st WHERE st.section_id = s.id),
+		       s.created_at, s.updated_at
+		FROM server_sections s
+		LEFT JOIN users u ON s.server_id = u.id
+		WHERE s.shift_start < $2 AND s.shift_end > $1
+		ORDER BY s.shift_start, s.name
+	`, start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to fetch sections",
+			"error":   err.Error(),
+		})
+		return
+	}
+	defer rows.Close()
+
+	sections := []models.ServerSection{}
+	for rows.Next() {
+		var section models.ServerSection
+		var tableIDs []string
+		err := rows.Scan(
+			&section.ID, &section.Name, &section.ServerID, &section.ServerName, &section.Color,
+			&section.ShiftStart, &section.ShiftEnd, &section.Notes, pq.Array(&tableIDs),
+			&section.CreatedAt, &section.UpdatedAt,
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to scan section",
+				"error":   err.Error(),
+			})
+			return
+		}
+		section.TableIDs = []uuid.UUID{}
+		for _, id := range tableIDs {
+			if tableID, err := uuid.Parse(id); err == nil {
+				section.TableIDs = append(section.TableIDs, tableID)
+			}
+		}
+		sections = append(sections, section)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Sections retrieved successfully",
+		"data":    sections,
+	})
+}
+
+// CreateSection assigns a group of tables to a server for a shift
+func (h *AdminHandler) CreateSection(c *gin.Context) {
+	var req struct {
+		Name       string      `json:"name" binding:"required"`
+		ServerID   uuid.UUID   `json:"server_id" binding:"required"`
+		Color      *string     `json:"color"`
+		ShiftStart time.Time   `json:"shift_start" binding:"required"`
+		ShiftEnd   time.Time   `json:"shift_end" binding:"required"`
+		Notes      *string     `json:"notes"`
+		TableIDs   []uuid.UUID `json:"table_ids"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to start transaction",
+			"error":   err.Error(),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	sectionID := uuid.New()
+	if !h.checkSection(c, tx, sectionID, req.ServerID, req.ShiftStart, req.ShiftEnd, req.TableIDs) {
+		return
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO server_sections (id, name, server_id, color, shift_start, shift_end, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, sectionID, req.Name, req.ServerID, req.Color, req.ShiftStart, req.ShiftEnd, req.Notes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to create section",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := setSectionTables(tx, sectionID, req.TableIDs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to assign tables",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to commit transaction",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Section created successfully",
+		"data":    gin.H{"id": sectionID},
+	})
+}
+
+// UpdateSection updates a server section. table_ids, when sent, replaces the
+// tables of the section.
+func (h *AdminHandler) UpdateSection(c *gin.Context) {
+	sectionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid section ID",
+			"error":   "invalid_uuid",
+		})
+		return
+	}
+
+	var req struct {
+		Name       *string      `json:"name"`
+		ServerID   *uuid.UUID   `json:"server_id"`
+		Color      *string      `json:"color"`
+		ShiftStart *time.Time   `json:"shift_start"`
+		ShiftEnd   *time.Time   `json:"shift_end"`
+		Notes      *string      `json:"notes"`
+		TableIDs   *[]uuid.UUID `json:"table_ids"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to start transaction",
+			"error":   err.Error(),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	var serverID uuid.UUID
+	var shiftStart, shiftEnd time.Time
+	var currentTables []string
+	err = tx.QueryRow(`
+		SELECT server_id, shift_start, shift_end,
+		       ARRAY(SELECT table_id FROM server_section_tables WHERE section_id = $1)
+		FROM server_sections
+		WHERE id = $1
+		FOR UPDATE
+	`, sectionID).Scan(&serverID, &shiftStart, &shiftEnd, pq.Array(&currentTables))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "Section not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to fetch section",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if req.ServerID != nil {
+		serverID = *req.ServerID
+	}
+	if req.ShiftStart != nil {
+		shiftStart = *req.ShiftStart
+	}
+	if req.ShiftEnd != nil {
+		shiftEnd = *req.ShiftEnd
+	}
+	tableIDs := []uuid.UUID{}
+	if req.TableIDs != nil {
+		tableIDs = *req.TableIDs
+	} else {
+		for _, id := range currentTables {
+			if tableID, err := uuid.Parse(id); err == nil {
+				tableIDs = append(tableIDs, tableID)
+			}
+		}
+	}
+
+	if !h.checkSection(c, tx, sectionID, serverID, shiftStart, shiftEnd, tableIDs) {
+		return
+	}
+
+	_, err = tx.Exec(`
+		UPDATE server_sections
+		SET name = COALESCE($1, name), server_id = $2, color = COALESCE($3, color),
+		    shift_start = $4, shift_end = $5, notes = COALESCE($6, notes), updated_at = CURRENT_TIMESTAMP
+		WHERE id = $7
+	`, req.Name, serverID, req.Color, shiftStart, shiftEnd, req.Notes, sectionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to update section",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if req.TableIDs != nil {
+		if err := setSectionTables(tx, sectionID, tableIDs); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to assign tables",
+				"error":   err.Error(),
+			})
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to commit transaction",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Section updated successfully",
+	})
+}
+
+// DeleteSection deletes a server section
+func (h *AdminHandler) DeleteSection(c *gin.Context) {
+	sectionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid section ID",
+			"error":   "invalid_uuid",
+		})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to start transaction",
+			"error":   err.Error(),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM server_section_tables WHERE section_id = $1", sectionID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to delete section",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	result, err := tx.Exec("DELETE FROM server_sections WHERE id = $1", sectionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to delete section",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "Section not found",
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to commit transaction",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Section deleted successfully",
+	})
+}
+
+// checkSection validates a section before it is saved: the shift must end
+// after it starts, the server must be an active front-of-house user and no
+// table may be in another section during an overlapping shift. It responds
+// and returns false when the section is invalid.
+func (h *AdminHandler) checkSection(c *gin.Context, tx *sql.Tx, sectionID, serverID uuid.UUID, shiftStart, shiftEnd time.Time, tableIDs []uuid.UUID) bool {
+	if !shiftEnd.After(shiftStart) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Shift must end after it starts",
+			"error":   "invalid_shift",
+		})
+		return false
+	}
+
+	var role string
+	err := tx.QueryRow("SELECT role FROM users WHERE id = $1 AND is_active = true", serverID).Scan(&role)
+	if err == sql.ErrNoRows || (err == nil && role != "server" && role != "manager" && role != "admin") {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Server not found",
+			"error":   "server_not_found",
+		})
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to fetch server",
+			"error":   err.Error(),
+		})
+		return false
+	}
+
+	if len(tableIDs) == 0 {
+		return true
+	}
+
+	ids := make([]string, len(tableIDs))
+	for i, id := range tableIDs {
+		ids[i] = id.String()
+	}
+
+	var found int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM dining_tables WHERE id = ANY($1::uuid[])", pq.Array(ids)).Scan(&found); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to fetch tables",
+			"error":   err.Error(),
+		})
+		return false
+	}
+	if found != len(uniqueStrings(ids)) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "One or more tables were not found",
+			"error":   "table_not_found",
+		})
+		return false
+	}
+
+	var conflicts []string
+	err = tx.QueryRow(`
+		SELECT ARRAY(
+			SELECT DISTINCT t.table_number || ' (' || s.name || ')'
+			FROM server_section_tables st
+			JOIN server_sections s ON st.section_id = s.id
+			JOIN dining_tables t ON st.table_id = t.id
+			WHERE st.table_id = ANY($1::uuid[])
+			  AND s.id <> $2
+			  AND s.shift_start < $4 AND s.shift_end > $3
+		)
+	`, pq.Array(ids), sectionID, shiftStart, shiftEnd).Scan(pq.Array(&conflicts))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to check sections",
+			"error":   err.Error(),
+		})
+		return false
+	}
+	if len(conflicts) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"message": "Tables already in another section during this shift: " + strings.Join(conflicts, ", "),
+			"error":   "table_in_other_section",
+		})
+		return false
+	}
+	return true
+}
+
+// setSectionTables replaces the tables of a section
+func setSectionTables(tx *sql.Tx, sectionID uuid.UUID, tableIDs []uuid.UUID) error {
+	if _, err := tx.Exec("DELETE FROM server_section_tables WHERE section_id = $1", sectionID); err != nil {
+		return err
+	}
+	for _, tableID := range tableIDs {
+		_, err := tx.Exec(`
+			INSERT INTO server_section_tables (section_id, table_id)
+			VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+		`, sectionID, tableID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uniqueStrings returns values without duplicates, keeping the first occurrence
+func uniqueStrings(values []string) []string {
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
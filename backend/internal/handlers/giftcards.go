@@ -0,0 +1,371 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"pos-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// giftCardAlphabet leaves out characters that are easily confused when read aloud
+const giftCardAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+var errGiftCardEmpty = errors.New("gift card has no remaining balance")
+
+type GiftCardHandler struct {
+	db *sql.DB
+}
+
+func NewGiftCardHandler(db *sql.DB) *GiftCardHandler {
+	return &GiftCardHandler{db: db}
+}
+
+// GetGiftCard looks up a gift card balance by code
+func (h *GiftCardHandler) GetGiftCard(c *gin.Context) {
+	code := normalizeGiftCardCode(c.Param("code"))
+
+	var giftCard models.GiftCard
+	err := h.db.QueryRow(`
+		SELECT id, code, balance, status, issued_by, activated_at, created_at, updated_at
+		FROM gift_cards
+		WHERE code = $1
+	`, code).Scan(
+		&giftCard.ID, &giftCard.Code, &giftCard.Balance, &giftCard.Status,
+		&giftCard.IssuedBy, &giftCard.ActivatedAt, &giftCard.CreatedAt, &giftCard.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Gift card not found",
+			Error:   stringPtr("gift_card_not_found"),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch gift card",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Gift card retrieved successfully",
+		Data:    giftCard,
+	})
+}
+
+// GetGiftCardTransactions returns the ledger of a gift card, newest first
+func (h *GiftCardHandler) GetGiftCardTransactions(c *gin.Context) {
+	code := normalizeGiftCardCode(c.Param("code"))
+
+	var giftCardID uuid.UUID
+	err := h.db.QueryRow("SELECT id FROM gift_cards WHERE code = $1", code).Scan(&giftCardID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Gift card not found",
+			Error:   stringPtr("gift_card_not_found"),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch gift card",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	rows, err := h.db.Query(`
+		SELECT id, gift_card_id, transaction_type, amount, balance_after,
+		       order_id, payment_id, created_by, created_at
+		FROM gift_card_transactions
+		WHERE gift_card_id = $1
+		ORDER BY created_at DESC
+	`, giftCardID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch gift card transactions",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	defer rows.Close()
+
+	var transactions []models.GiftCardTransaction
+	for rows.Next() {
+		var transaction models.GiftCardTransaction
+		err := rows.Scan(
+			&transaction.ID, &transaction.GiftCardID, &transaction.TransactionType,
+			&transaction.Amount, &transaction.BalanceAfter, &transaction.OrderID,
+			&transaction.PaymentID, &transaction.CreatedBy, &transaction.CreatedAt,
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to scan gift card transaction",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Gift card transactions retrieved successfully",
+		Data:    transactions,
+	})
+}
+
+// GetGiftCardLiability reports the outstanding stored value owed to card holders
+// together with the ledger activity for the period
+func (h *GiftCardHandler) GetGiftCardLiability(c *gin.Context) {
+	period := c.DefaultQuery("period", "month") // today, week, month, year
+
+	interval := "30 days"
+	switch period {
+	case "today":
+		interval = "0 days"
+	case "week":
+		interval = "7 days"
+	case "year":
+		interval = "1 year"
+	}
+
+	var activeCards int
+	var outstanding float64
+	err := h.db.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(balance), 0)
+		FROM gift_cards
+		WHERE status = 'active' AND balance > 0
+	`).Scan(&activeCards, &outstanding)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to calculate gift card liability",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	rows, err := h.db.Query(`
+		SELECT transaction_type, COUNT(*), COALESCE(SUM(amount), 0)
+		FROM gift_card_transactions
+		WHERE created_at >= CURRENT_DATE - $1::interval
+		GROUP BY transaction_type
+	`, interval)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch gift card activity",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	defer rows.Close()
+
+	activity := map[string]interface{}{}
+	for rows.Next() {
+		var transactionType string
+		var count int
+		var amount float64
+		if err := rows.Scan(&transactionType, &count, &amount); err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to scan gift card activity",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+		activity[transactionType] = map[string]interface{}{
+			"count":  count,
+			"amount": amount,
+		}
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Gift card liability retrieved successfully",
+		Data: map[string]interface{}{
+			"period":              period,
+			"active_cards":        activeCards,
+			"outstanding_balance": outstanding,
+			"activity":            activity,
+		},
+	})
+}
+
+// Helper functions
+
+// prepareGiftCard returns the card an order line will credit. A nil code issues a
+// new card that stays pending until the order is paid; otherwise the code must
+// belong to an active card, which is reloaded.
+func prepareGiftCard(tx *sql.Tx, code *string, issuedBy uuid.UUID) (uuid.UUID, error) {
+	var giftCardID uuid.UUID
+
+	if code != nil {
+		err := tx.QueryRow(
+			"SELECT id FROM gift_cards WHERE code = $1 AND status = 'active'",
+			normalizeGiftCardCode(*code),
+		).Scan(&giftCardID)
+		return giftCardID, err
+	}
+
+	newCode, err := generateGiftCardCode()
+	if err != nil {
+		return giftCardID, err
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO gift_cards (code, balance, status, issued_by)
+		VALUES ($1, 0, 'pending', $2)
+		RETURNING id
+	`, newCode, issuedBy).Scan(&giftCardID)
+	return giftCardID, err
+}
+
+// activateOrderGiftCards credits every gift card line of a paid order exactly once
+func activateOrderGiftCards(tx *sql.Tx, orderID uuid.UUID, changedBy *uuid.UUID) error {
+	rows, err := tx.Query(`
+		SELECT oi.id, oi.gift_card_id, oi.total_price, gc.status
+		FROM order_items oi
+		JOIN gift_cards gc ON oi.gift_card_id = gc.id
+		WHERE oi.order_id = $1 AND oi.item_type = 'gift_card'
+		  AND NOT EXISTS (SELECT 1 FROM gift_card_transactions t WHERE t.order_item_id = oi.id)
+	`, orderID)
+	if err != nil {
+		return err
+	}
+
+	type giftCardLine struct {
+		itemID     uuid.UUID
+		giftCardID uuid.UUID
+		amount     float64
+		status     string
+	}
+
+	var lines []giftCardLine
+	for rows.Next() {
+		var line giftCardLine
+		if err := rows.Scan(&line.itemID, &line.giftCardID, &line.amount, &line.status); err != nil {
+			rows.Close()
+			return err
+		}
+		lines = append(lines, line)
+	}
+	rows.Close()
+
+	for _, line := range lines {
+		transactionType := "reload"
+		if line.status == "pending" {
+			transactionType = "issue"
+		}
+
+		var balance float64
+		err := tx.QueryRow(`
+			UPDATE gift_cards
+			SET balance = balance + $1, status = 'active',
+			    activated_at = COALESCE(activated_at, CURRENT_TIMESTAMP), updated_at = CURRENT_TIMESTAMP
+			WHERE id = $2
+			RETURNING balance
+		`, line.amount, line.giftCardID).Scan(&balance)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO gift_card_transactions (gift_card_id, transaction_type, amount, balance_after, order_id, order_item_id, created_by)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`, line.giftCardID, transactionType, line.amount, balance, orderID, line.itemID, changedBy)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lockGiftCard locks an active gift card for redemption and returns its balance
+func lockGiftCard(tx *sql.Tx, code string) (uuid.UUID, float64, error) {
+	var giftCardID uuid.UUID
+	var balance float64
+	err := tx.QueryRow(
+		"SELECT id, balance FROM gift_cards WHERE code = $1 AND status = 'active' FOR UPDATE",
+		normalizeGiftCardCode(code),
+	).Scan(&giftCardID, &balance)
+	if err != nil {
+		return giftCardID, 0, err
+	}
+	if balance <= 0 {
+		return giftCardID, 0, errGiftCardEmpty
+	}
+	return giftCardID, balance, nil
+}
+
+// adjustGiftCard applies a signed balance change and appends it to the ledger
+func adjustGiftCard(tx *sql.Tx, giftCardID uuid.UUID, transactionType string, amount float64, orderID, paymentID uuid.UUID, userID *uuid.UUID) error {
+	delta := amount
+	if transactionType == "redeem" {
+		delta = -amount
+	}
+
+	var balance float64
+	err := tx.QueryRow(`
+		UPDATE gift_cards
+		SET balance = balance + $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+		RETURNING balance
+	`, delta, giftCardID).Scan(&balance)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO gift_card_transactions (gift_card_id, transaction_type, amount, balance_after, order_id, payment_id, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, giftCardID, transactionType, amount, balance, orderID, paymentID, userID)
+	return err
+}
+
+// generateGiftCardCode returns a random code formatted as GC-XXXX-XXXX-XXXX
+func generateGiftCardCode() (string, error) {
+	var b strings.Builder
+	b.WriteString("GC")
+	alphabetSize := big.NewInt(int64(len(giftCardAlphabet)))
+	for i := 0; i < 12; i++ {
+		if i%4 == 0 {
+			b.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, alphabetSize)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(giftCardAlphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
+
+func normalizeGiftCardCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// maskGiftCardCode hides all but the last four characters of a code
+func maskGiftCardCode(code string) string {
+	if len(code) <= 4 {
+		return code
+	}
+	return "****" + code[len(code)-4:]
+}
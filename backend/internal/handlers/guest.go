@@ -0,0 +1,662 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+	"time"
+
+	"pos-backend/internal/allergens"
+	"pos-backend/internal/events"
+	"pos-backend/internal/gateway"
+	"pos-backend/internal/guestlink"
+	"pos-backend/internal/middleware"
+	"pos-backend/internal/models"
+	"pos-backend/internal/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Limits on what a guest can order in one go from their phone
+const (
+	maxGuestOrderItems     = 30
+	maxGuestItemQuantity   = 20
+	maxGuestCustomerName   = 30
+	guestOrderApprovedNote = "Guest order approved"
+)
+
+// GuestHandler serves guests ordering from the QR code on their table and
+// paying from their check link
+type GuestHandler struct {
+	db       *sql.DB
+	links    *guestlink.Signer
+	orders   *OrderHandler
+	payments *PaymentHandler
+}
+
+func NewGuestHandler(db *sql.DB, links *guestlink.Signer, providers *gateway.Registry) *GuestHandler {
+	return &GuestHandler{
+		db:       db,
+		links:    links,
+		orders:   NewOrderHandler(db),
+		payments: NewPaymentHandler(db, providers),
+	}
+}
+
+// guestTable is the table a guest link points to
+type guestTable struct {
+	ID          uuid.UUID
+	TableNumber string
+	Location    *string
+	Status      string
+	JoinedTo    *uuid.UUID
+}
+
+// orderTableID is where orders from the table go: tables pushed together
+// order at their group
+func (t *guestTable) orderTableID() uuid.UUID {
+	if t.JoinedTo != nil {
+		return *t.JoinedTo
+	}
+	return t.ID
+}
+
+// resolveTable checks the table token in the URL and loads its table. It
+// writes the error response and returns nil when the link is not valid.
+func (h *GuestHandler) resolveTable(c *gin.Context) *guestTable {
+	tableID, nonce, err := h.links.Parse(guestlink.KindTable, c.Param("token"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "This table code is not valid",
+			Error:   stringPtr("invalid_table_code"),
+		})
+		return nil
+	}
+
+	var table guestTable
+	var currentNonce string
+	err = h.db.QueryRow(`
+		SELECT id, table_number, location, status, joined_to, qr_nonce
+		FROM dining_tables
+		WHERE id = $1 AND is_group = false
+	`, tableID).Scan(&table.ID, &table.TableNumber, &table.Location, &table.Status, &table.JoinedTo, &currentNonce)
+	if err == sql.ErrNoRows || (err == nil && currentNonce != nonce) {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "This table code is no longer valid",
+			Error:   stringPtr("invalid_table_code"),
+		})
+		return nil
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch table",
+			Error:   stringPtr(err.Error()),
+		})
+		return nil
+	}
+	return &table
+}
+
+// GetMenu returns the menu for the table of a QR code: the active categories
+// with the products that can be ordered now and their modifiers
+func (h *GuestHandler) GetMenu(c *gin.Context) {
+	table := h.resolveTable(c)
+	if table == nil {
+		return
+	}
+
+	current, err := currentDayparts(h.db, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch dayparts",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	rules, err := currentPriceRules(h.db, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch price rules",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	menu, err := loadGuestMenu(h.db, current, rules)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch menu",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Menu retrieved successfully",
+		Data: map[string]interface{}{
+			"table": map[string]interface{}{
+				"table_number": table.TableNumber,
+				"location":     table.Location,
+			},
+			"categories": menu,
+		},
+	})
+}
+
+// loadGuestMenu returns the active categories with their available products
+// in the current dayparts at their prices under rules, leaving out products
+// the kitchen has 86'd
+func loadGuestMenu(q queryer, current []string, rules priceRules) ([]models.MenuCategory, error) {
+	rows, err := q.Query(`
+		SELECT id, name, description, color, sort_order, station_id, is_active, created_at, updated_at
+		FROM categories
+		WHERE is_active = true AND `+daypartCategoryCondition("categories.id", "$1")+`
+		ORDER BY sort_order, name
+	`, pq.Array(current))
+	if err != nil {
+		return nil, err
+	}
+	categories := []models.MenuCategory{}
+	byID := map[uuid.UUID]int{}
+	for rows.Next() {
+		var category models.MenuCategory
+		err := rows.Scan(&category.ID, &category.Name, &category.Description, &category.Color,
+			&category.SortOrder, &category.StationID, &category.IsActive, &category.CreatedAt, &category.UpdatedAt)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		category.Products = []models.Product{}
+		byID[category.ID] = len(categories)
+		categories = append(categories, category)
+	}
+	rows.Close()
+
+	modifiers, err := loadModifiers(q, nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = q.Query(`
+		SELECT p.id, p.category_id, p.name, p.description, p.price, p.image_url, p.preparation_time, p.sort_order,
+		       e86.remaining_count, p.allergens, p.dietary_tags, p.created_at, p.updated_at
+		FROM products p
+		`+eightySixJoin+`
+		WHERE p.is_available = true
+		  AND NOT `+eightySixedCondition+`
+		  AND `+daypartProductCondition("$1")+`
+		ORDER BY p.sort_order, p.name
+	`, pq.Array(current))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var product models.Product
+		err := rows.Scan(&product.ID, &product.CategoryID, &product.Name, &product.Description, &product.Price,
+			&product.ImageURL, &product.PreparationTime, &product.SortOrder, &product.RemainingCount,
+			pq.Array(&product.Allergens), pq.Array(&product.DietaryTags), &product.CreatedAt, &product.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if product.CategoryID == nil {
+			continue
+		}
+		index, ok := byID[*product.CategoryID]
+		if !ok {
+			continue
+		}
+		product.IsAvailable = true
+		setEffectivePrice(&product, rules)
+		product.Modifiers = []models.Modifier{}
+		for _, modifier := range modifiers {
+			if modifier.ProductID == nil || *modifier.ProductID == product.ID {
+				product.Modifiers = append(product.Modifiers, modifier)
+			}
+		}
+		categories[index].Products = append(categories[index].Products, product)
+	}
+	return categories, rows.Err()
+}
+
+// PlaceOrder creates a dine-in order at the table of a QR code. When guest
+// orders need approval the order waits for a server before the kitchen sees it.
+func (h *GuestHandler) PlaceOrder(c *gin.Context) {
+	table := h.resolveTable(c)
+	if table == nil {
+		return
+	}
+	if table.Status == "out_of_service" {
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: "This table is not taking orders",
+			Error:   stringPtr("table_unavailable"),
+		})
+		return
+	}
+
+	var req models.GuestOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	if len(req.Items) > maxGuestOrderItems {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Too many items in one order",
+			Error:   stringPtr("too_many_items"),
+		})
+		return
+	}
+	for _, item := range req.Items {
+		if item.Quantity < 1 || item.Quantity > maxGuestItemQuantity {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Item quantity must be between 1 and 20",
+				Error:   stringPtr("invalid_quantity"),
+			})
+			return
+		}
+	}
+	if req.Covers != nil && *req.Covers < 1 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Covers must be at least 1",
+			Error:   stringPtr("invalid_covers"),
+		})
+		return
+	}
+	if req.CustomerName != nil {
+		name := strings.TrimSpace(*req.CustomerName)
+		if runes := []rune(name); len(runes) > maxGuestCustomerName {
+			name = strings.TrimSpace(string(runes[:maxGuestCustomerName]))
+		}
+		req.CustomerName = &name
+	}
+
+	guestAllergies, err := allergens.Normalize(req.GuestAllergies, allergens.Allergens, "allergen")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: err.Error(),
+			Error:   stringPtr("invalid_allergen"),
+		})
+		return
+	}
+
+	// Guests can only order what is on the menu right now
+	current, err := currentDayparts(h.db, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch dayparts",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	productName, err := outOfDaypart(h.db, req.Items, current)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to check dayparts",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	if productName != "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: productName + " is not on the menu right now",
+			Error:   stringPtr("outside_daypart"),
+		})
+		return
+	}
+
+	settings, err := loadSettings(h.db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to load settings",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	status := "pending"
+	if settings.GuestOrderApproval {
+		status = "awaiting_approval"
+	}
+
+	// Items are charged at the price rules in effect when the order is taken
+	rules, err := currentPriceRules(h.db, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch price rules",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to start transaction",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	subtotal, code, message, err := priceOrderItems(tx, req.Items, rules)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to price order items",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	if code != "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   stringPtr(code),
+		})
+		return
+	}
+
+	// Same tax and service charge as orders taken by staff
+	taxAmount := subtotal * 0.10
+	serviceChargeAmount := serviceCharge(tx, "dine_in", subtotal)
+	totalAmount := subtotal + serviceChargeAmount + taxAmount
+
+	orderID := uuid.New()
+	orderNumber := h.orders.generateOrderNumber()
+	tableID := table.orderTableID()
+	checkNonce := guestlink.NewNonce()
+	_, err = tx.Exec(`
+		INSERT INTO orders (id, order_number, table_id, customer_name, order_type, status, channel, check_nonce,
+		                    subtotal, tax_amount, service_charge_amount, discount_amount, total_amount, notes, guest_allergies, covers)
+		VALUES ($1, $2, $3, $4, 'dine_in', $5, 'guest', $6, $7, $8, $9, 0, $10, $11, $12, $13)
+	`, orderID, orderNumber, tableID, req.CustomerName, status, checkNonce,
+		subtotal, taxAmount, serviceChargeAmount, totalAmount, req.Notes, pq.Array(guestAllergies), req.Covers)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to create order",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	if err := insertOrderItems(tx, orderID, req.Items, rules); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to create order items",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	err = events.Publish(tx, events.OrderCreated, orderID, map[string]interface{}{
+		"order_number": orderNumber,
+		"order_type":   "dine_in",
+		"table_id":     tableID,
+		"status":       status,
+		"channel":      "guest",
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to publish order event",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	if err := syncTableStatus(tx, orderID); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to update table status",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to commit transaction",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	if status == "pending" {
+		autoPrint(h.db, orderID, "kitchen", nil)
+	}
+
+	responseMessage := "Order sent to the kitchen"
+	if status == "awaiting_approval" {
+		responseMessage = "Order received - your server will confirm it shortly"
+	}
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: responseMessage,
+		Data: map[string]interface{}{
+			"order_number":          orderNumber,
+			"status":                status,
+			"subtotal":              subtotal,
+			"service_charge_amount": serviceChargeAmount,
+			"tax_amount":            taxAmount,
+			"total_amount":          totalAmount,
+			"check_token":           h.links.Sign(guestlink.KindCheck, orderID, checkNonce),
+		},
+	})
+}
+
+// ApproveOrder sends a guest order waiting for approval to the kitchen. The
+// approving server takes over the order. Orders are rejected by cancelling them.
+func (h *GuestHandler) ApproveOrder(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid order ID",
+			Error:   stringPtr("invalid_uuid"),
+		})
+		return
+	}
+
+	userID, _, _, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Authentication required",
+			Error:   stringPtr("auth_required"),
+		})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to start transaction",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRow("SELECT status FROM orders WHERE id = $1 FOR UPDATE", orderID).Scan(&status)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Order not found",
+			Error:   stringPtr("order_not_found"),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch order",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	if status != "awaiting_approval" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Order is not waiting for approval - order is " + status,
+			Error:   stringPtr("invalid_order_status"),
+		})
+		return
+	}
+
+	_, err = tx.Exec(`
+		UPDATE orders
+		SET status = 'pending', user_id = COALESCE(user_id, $2), updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, orderID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to approve order",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO order_status_history (order_id, previous_status, new_status, changed_by, notes)
+		VALUES ($1, $2, 'pending', $3, $4)
+	`, orderID, status, userID, guestOrderApprovedNote)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to log status change",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	err = events.Publish(tx, events.OrderStatusChanged, orderID, map[string]interface{}{
+		"previous_status": status,
+		"status":          "pending",
+		"approved_by":     userID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to publish order event",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to commit transaction",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	autoPrint(h.db, orderID, "kitchen", &userID)
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Order sent to the kitchen",
+	})
+}
+
+// GetTableQRCode returns the signed link for the QR code of a table
+func (h *GuestHandler) GetTableQRCode(c *gin.Context) {
+	h.respondQRCode(c, false)
+}
+
+// RotateTableQRCode replaces the QR code of a table. Codes printed before stop
+// working, e.g. after one was photographed and shared.
+func (h *GuestHandler) RotateTableQRCode(c *gin.Context) {
+	h.respondQRCode(c, true)
+}
+
+func (h *GuestHandler) respondQRCode(c *gin.Context, rotate bool) {
+	tableID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid table ID",
+			Error:   stringPtr("invalid_uuid"),
+		})
+		return
+	}
+
+	query := `SELECT table_number, qr_nonce, qr_rotated_at FROM dining_tables WHERE id = $1 AND is_group = false`
+	args := []interface{}{tableID}
+	if rotate {
+		query = `
+			UPDATE dining_tables
+			SET qr_nonce = $2, qr_rotated_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $1 AND is_group = false
+			RETURNING table_number, qr_nonce, qr_rotated_at
+		`
+		args = append(args, guestlink.NewNonce())
+	}
+
+	qrCode := models.TableQRCode{TableID: tableID}
+	var nonce string
+	var rotatedAt *time.Time
+	err = h.db.QueryRow(query, args...).Scan(&qrCode.TableNumber, &nonce, &rotatedAt)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Table not found",
+			Error:   stringPtr("table_not_found"),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch table QR code",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	qrCode.Token = h.links.Sign(guestlink.KindTable, tableID, nonce)
+	qrCode.URL = util.FromEnv("GUEST_ORDER_URL", "http://localhost:3000/order") + "?t=" + qrCode.Token
+	qrCode.RotatedAt = rotatedAt
+
+	message := "Table QR code retrieved successfully"
+	if rotate {
+		message = "Table QR code rotated successfully"
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: message,
+		Data:    qrCode,
+	})
+}
@@ -0,0 +1,415 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+
+	"pos-backend/internal/gateway"
+	"pos-backend/internal/guestlink"
+	"pos-backend/internal/models"
+	"pos-backend/internal/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Limits on how a guest can split and tip a check
+const (
+	maxCheckSplitWays = 20
+	maxTipPercent     = 100
+)
+
+// tipPercents are the tips suggested on the guest check
+var tipPercents = []float64{10, 15, 20}
+
+// guestPaymentMethods are the methods guests can pay with from their phone;
+// cash and gift cards are taken by staff
+var guestPaymentMethods = []string{"credit_card", "debit_card", "digital_wallet"}
+
+// resolveCheck checks the check token in the URL and returns the order it is
+// for. It writes the error response and returns false when the link is not valid.
+func (h *GuestHandler) resolveCheck(c *gin.Context) (uuid.UUID, bool) {
+	orderID, nonce, err := h.links.Parse(guestlink.KindCheck, c.Param("token"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "This check link is not valid",
+			Error:   stringPtr("invalid_check_link"),
+		})
+		return uuid.Nil, false
+	}
+
+	var currentNonce string
+	err = h.db.QueryRow("SELECT check_nonce FROM orders WHERE id = $1", orderID).Scan(&currentNonce)
+	if err == sql.ErrNoRows || (err == nil && currentNonce != nonce) {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "This check link is no longer valid",
+			Error:   stringPtr("invalid_check_link"),
+		})
+		return uuid.Nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch check",
+			Error:   stringPtr(err.Error()),
+		})
+		return uuid.Nil, false
+	}
+	return orderID, true
+}
+
+// checkLink returns the signed link to the check of an order
+func (h *GuestHandler) checkLink(orderID uuid.UUID, orderNumber, nonce string) models.CheckLink {
+	token := h.links.Sign(guestlink.KindCheck, orderID, nonce)
+	return models.CheckLink{
+		OrderID:     orderID,
+		OrderNumber: orderNumber,
+		Token:       token,
+		URL:         util.FromEnv("GUEST_CHECK_URL", "http://localhost:3000/check") + "?t=" + token,
+	}
+}
+
+// GetCheckLink returns the link staff hand to guests, e.g. as a QR code on the
+// printed check, so they can pay from their phone
+func (h *GuestHandler) GetCheckLink(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid order ID",
+			Error:   stringPtr("invalid_uuid"),
+		})
+		return
+	}
+
+	var orderNumber, nonce string
+	err = h.db.QueryRow("SELECT order_number, check_nonce FROM orders WHERE id = $1", orderID).Scan(&orderNumber, &nonce)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Order not found",
+			Error:   stringPtr("order_not_found"),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch order",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Check link retrieved successfully",
+		Data:    h.checkLink(orderID, orderNumber, nonce),
+	})
+}
+
+// GetCheck returns the live check of a check link: the items, the service
+// charge, tax and what is left to pay
+func (h *GuestHandler) GetCheck(c *gin.Context) {
+	orderID, ok := h.resolveCheck(c)
+	if !ok {
+		return
+	}
+
+	order, err := h.orders.getOrderByID(orderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch check",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	summary, err := loadPaymentSummary(h.db, orderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch payment summary",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	check := models.GuestCheck{
+		OrderNumber: order.OrderNumber,
+		Status:      order.Status,
+		Currency:    h.payments.storeCurrency(),
+		Items:       []models.GuestCheckItem{},
+		Summary:     *summary,
+		TipOptions:  []models.TipOption{},
+	}
+	if order.Table != nil {
+		check.TableNumber = &order.Table.TableNumber
+	}
+	for _, item := range order.Items {
+		line := models.GuestCheckItem{
+			Name:       "Gift card",
+			Quantity:   item.Quantity,
+			UnitPrice:  item.UnitPrice,
+			TotalPrice: item.TotalPrice,
+			Modifiers:  []string{},
+		}
+		if item.Product != nil {
+			line.Name = item.Product.Name
+		}
+		for _, modifier := range item.Modifiers {
+			line.Modifiers = append(line.Modifiers, modifier.Name)
+		}
+		check.Items = append(check.Items, line)
+	}
+	for _, percent := range tipPercents {
+		check.TipOptions = append(check.TipOptions, models.TipOption{
+			Percent: percent,
+			Amount:  roundAmount(summary.Subtotal * percent / 100),
+		})
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Check retrieved successfully",
+		Data:    check,
+	})
+}
+
+// PayCheck charges a guest payment through the payment provider: the whole
+// balance, a custom amount or an even share of the check, plus an optional tip.
+// The order completes and its table frees up once the balance reaches zero.
+func (h *GuestHandler) PayCheck(c *gin.Context) {
+	orderID, ok := h.resolveCheck(c)
+	if !ok {
+		return
+	}
+
+	var req models.GuestPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	isValidMethod := false
+	for _, method := range guestPaymentMethods {
+		if req.PaymentMethod == method {
+			isValidMethod = true
+			break
+		}
+	}
+	if !isValidMethod {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid payment method",
+			Error:   stringPtr("invalid_payment_method"),
+		})
+		return
+	}
+	provider, err := h.payments.providers.ForMethod(req.PaymentMethod)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "No payment provider configured for this payment method",
+			Error:   stringPtr("payment_provider_unavailable"),
+		})
+		return
+	}
+
+	if req.Amount != nil && *req.Amount <= 0 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Payment amount must be greater than zero",
+			Error:   stringPtr("invalid_amount"),
+		})
+		return
+	}
+	if req.SplitWays != nil && (*req.SplitWays < 2 || *req.SplitWays > maxCheckSplitWays) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "A check can be split between 2 and 20 ways",
+			Error:   stringPtr("invalid_split"),
+		})
+		return
+	}
+	if (req.TipAmount != nil && *req.TipAmount < 0) ||
+		(req.TipPercent != nil && (*req.TipPercent < 0 || *req.TipPercent > maxTipPercent)) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid tip",
+			Error:   stringPtr("invalid_tip"),
+		})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to start transaction",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	var orderStatus string
+	err = tx.QueryRow("SELECT status FROM orders WHERE id = $1 FOR UPDATE", orderID).Scan(&orderStatus)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch order",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	if orderStatus == "cancelled" || orderStatus == "completed" || orderStatus == "awaiting_approval" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Check cannot be paid - order is " + orderStatus,
+			Error:   stringPtr("invalid_order_status"),
+		})
+		return
+	}
+
+	summary, err := loadPaymentSummary(tx, orderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to calculate total payments",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	// Payments still with the provider count against the balance so two
+	// guests cannot pay the same share while waiting for the webhook
+	payable := roundAmount(summary.RemainingAmount - summary.PendingAmount)
+	if payable <= 0 {
+		message, code := "Check is already fully paid", "order_fully_paid"
+		if summary.PendingAmount > 0 {
+			message, code = "The rest of the check is already being paid", "payment_in_progress"
+		}
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   stringPtr(code),
+		})
+		return
+	}
+
+	amount := payable
+	switch {
+	case req.Amount != nil:
+		amount = roundAmount(*req.Amount)
+		if amount > payable {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Payment amount exceeds remaining balance",
+				Error:   stringPtr("amount_exceeds_balance"),
+			})
+			return
+		}
+	case req.SplitWays != nil:
+		// Shares are of the whole check; the last one picks up the rounding cents
+		ways := float64(*req.SplitWays)
+		share := roundAmount(summary.TotalAmount / ways)
+		if share < payable-0.01*ways {
+			amount = share
+		}
+	}
+
+	var tip float64
+	if req.TipAmount != nil {
+		tip = roundAmount(*req.TipAmount)
+	} else if req.TipPercent != nil {
+		tip = roundAmount(amount * *req.TipPercent / 100)
+	}
+	if tip > amount {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Tip cannot be more than the amount paid",
+			Error:   stringPtr("invalid_tip"),
+		})
+		return
+	}
+
+	// Guest payments have no staff member processing them
+	paymentID := uuid.New()
+	_, err = tx.Exec(`
+		INSERT INTO payments (id, order_id, payment_method, amount, tip_amount, status, provider)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, paymentID, orderID, req.PaymentMethod, amount, tip, gateway.StatusPending, provider.Name())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to create payment record",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to commit payment",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	// The tip is charged together with the payment
+	if err := h.payments.chargeProvider(c, provider, gateway.PaymentRequest{
+		PaymentID: paymentID,
+		OrderID:   orderID,
+		Method:    req.PaymentMethod,
+		Amount:    amount + tip,
+		Currency:  h.payments.storeCurrency(),
+	}); err != nil {
+		c.JSON(http.StatusBadGateway, models.APIResponse{
+			Success: false,
+			Message: "Failed to record payment provider response",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	payment, err := h.payments.getPaymentByID(paymentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Payment processed but failed to fetch details",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	switch payment.Status {
+	case gateway.StatusFailed:
+		c.JSON(http.StatusPaymentRequired, models.APIResponse{
+			Success: false,
+			Message: "Payment was declined",
+			Data:    payment,
+			Error:   stringPtr("payment_declined"),
+		})
+	case gateway.StatusPending, gateway.StatusProcessing:
+		c.JSON(http.StatusAccepted, models.APIResponse{
+			Success: true,
+			Message: "Payment is processing",
+			Data:    payment,
+		})
+	default:
+		c.JSON(http.StatusCreated, models.APIResponse{
+			Success: true,
+			Message: "Payment processed successfully",
+			Data:    payment,
+		})
+	}
+}
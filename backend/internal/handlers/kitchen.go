@@ -2,9 +2,22 @@ package handlers
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
+
+	"pos-backend/internal/allergens"
+	"pos-backend/internal/events"
+	"pos-backend/internal/kitchen"
+	"pos-backend/internal/middleware"
+	"pos-backend/internal/util"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type KitchenHandler struct {
@@ -15,78 +28,334 @@ func NewKitchenHandler(db *sql.DB) *KitchenHandler {
 	return &KitchenHandler{db: db}
 }
 
-// GetKitchenOrders returns orders for kitchen staff
+// GetKitchenOrders returns open orders for kitchen staff with their items. With
+// ?station= (ID or name) only that station's items are listed and orders with
+// nothing for the station are left out.
 func (h *KitchenHandler) GetKitchenOrders(c *gin.Context) {
 	status := c.DefaultQuery("status", "all")
 
+	var stationID *uuid.UUID
+	if value := c.Query("station"); value != "" {
+		id, err := resolveStation(h.db, value)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"message": "Kitchen station not found",
+				"error":   "station_not_found",
+			})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to fetch kitchen station",
+				"error":   err.Error(),
+			})
+			return
+		}
+		stationID = &id
+	}
+
+	orders, err := loadKitchenOrders(h.db, status, stationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to fetch kitchen orders",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Kitchen orders retrieved successfully",
+		"data":    orders,
+	})
+}
+
+// GetExpoView returns every open order with all of its items and how far each
+// station is with its share, so the expeditor knows when a ticket is complete
+func (h *KitchenHandler) GetExpoView(c *gin.Context) {
+	orders, err := loadKitchenOrders(h.db, "all", nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to fetch kitchen orders",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	for _, order := range orders {
+		items := order["items"].([]map[string]interface{})
+
+		stations := []map[string]interface{}{}
+		byStation := map[string]map[string]interface{}{}
+		orderReady := len(items) > 0
+		for _, item := range items {
+			key := ""
+			if item["station_id"] != nil {
+				key = item["station_id"].(uuid.UUID).String()
+			}
+			station, ok := byStation[key]
+			if !ok {
+				station = map[string]interface{}{
+					"station_id":   item["station_id"],
+					"station_name": item["station_name"],
+					"total_items":  0,
+					"ready_items":  0,
+					"ready":        true,
+				}
+				byStation[key] = station
+				stations = append(stations, station)
+			}
+
+			station["total_items"] = station["total_items"].(int) + 1
+			itemStatus := item["status"].(string)
+			if itemStatus == "ready" || itemStatus == "served" {
+				station["ready_items"] = station["ready_items"].(int) + 1
+			} else {
+				station["ready"] = false
+				orderReady = false
+			}
+		}
+
+		order["stations"] = stations
+		order["ready"] = orderReady
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Expo view retrieved successfully",
+		"data":    orders,
+	})
+}
+
+// loadKitchenOrders fetches the open orders and their kitchen items, optionally
+// limited to one status and one station. Items and orders that ran over their
+// expected preparation time plus the late threshold are flagged late.
+func loadKitchenOrders(q queryer, status string, stationID *uuid.UUID) ([]map[string]interface{}, error) {
 	query := `
-		SELECT DISTINCT o.id, o.order_number, o.table_id, o.order_type, o.status, 
-		       o.created_at, o.customer_name,
-		       t.table_number
+		SELECT o.id, o.order_number, o.table_id, o.order_type, o.status, 
+		       o.created_at, o.customer_name, o.guest_allergies,
+		       COALESCE(t.table_number, g.table_number)
 		FROM orders o
 		LEFT JOIN dining_tables t ON o.table_id = t.id
+		LEFT JOIN table_groups g ON o.table_id = g.id
 		WHERE o.status IN ('confirmed', 'preparing', 'ready', 'pending')
 	`
+	args := []interface{}{}
 
 	if status != "all" {
-		query += ` AND o.status = '` + status + `'`
+		args = append(args, status)
+		query += fmt.Sprintf(` AND o.status = $%d`, len(args))
+	}
+	if stationID != nil {
+		args = append(args, *stationID)
+		query += fmt.Sprintf(` AND EXISTS (
+			SELECT 1 FROM order_items oi
+			WHERE oi.order_id = o.id AND oi.item_type = 'product' AND oi.station_id = $%d
+		)`, len(args))
 	}
 
 	query += ` ORDER BY o.created_at ASC`
 
-	rows, err := h.db.Query(query)
+	rows, err := q.Query(query, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "Failed to fetch kitchen orders",
-			"error":   err.Error(),
-		})
-		return
+		return nil, err
 	}
 	defer rows.Close()
 
-	var orders []map[string]interface{}
+	orders := []map[string]interface{}{}
 	for rows.Next() {
-		var orderID, tableID interface{}
+		var orderID uuid.UUID
+		var tableID *uuid.UUID
 		var orderNumber, orderType, orderStatus, customerName, tableNumber sql.NullString
-		var createdAt interface{}
+		var createdAt time.Time
+		var guestAllergies []string
 
 		err := rows.Scan(&orderID, &orderNumber, &tableID, &orderType, &orderStatus,
-			&createdAt, &customerName, &tableNumber)
+			&createdAt, &customerName, pq.Array(&guestAllergies), &tableNumber)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"message": "Failed to scan kitchen order",
-				"error":   err.Error(),
-			})
-			return
+			return nil, err
 		}
 
 		order := map[string]interface{}{
-			"id":            orderID,
-			"order_number":  orderNumber.String,
-			"table_id":      tableID,
-			"table_number":  tableNumber.String,
-			"order_type":    orderType.String,
-			"status":        orderStatus.String,
-			"customer_name": customerName.String,
-			"created_at":    createdAt,
+			"id":              orderID,
+			"order_number":    orderNumber.String,
+			"table_id":        tableID,
+			"table_number":    tableNumber.String,
+			"order_type":      orderType.String,
+			"status":          orderStatus.String,
+			"customer_name":   customerName.String,
+			"created_at":      createdAt,
+			"guest_allergies": guestAllergies,
+			"allergy_alert":   false,
+			"late":            false,
+			"items":           []map[string]interface{}{},
 		}
 
 		orders = append(orders, order)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "Kitchen orders retrieved successfully",
-		"data":    orders,
-	})
+	return orders, attachKitchenItems(q, orders, stationID)
 }
 
-// UpdateOrderItemStatus updates the status of an order item
+// attachKitchenItems loads the kitchen items of the given orders into their
+// "items" entries, optionally only those of one station, and flags late items
+// and items containing a guest allergy, and their orders
+func attachKitchenItems(q queryer, orders []map[string]interface{}, stationID *uuid.UUID) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	byID := map[uuid.UUID]map[string]interface{}{}
+	orderIDs := []string{}
+	for _, order := range orders {
+		orderID := order["id"].(uuid.UUID)
+		byID[orderID] = order
+		orderIDs = append(orderIDs, orderID.String())
+	}
+
+	itemQuery := `
+		SELECT oi.id, oi.order_id, oi.product_id, p.name, oi.quantity,
+		       oi.special_instructions, oi.status, oi.station_id, s.name,
+		       oi.expected_prep_time, oi.started_at, oi.ready_at,
+		       oi.is_remake, oi.remake_reason, oi.allergens,
+		       ARRAY(SELECT m.name FROM order_item_modifiers m WHERE m.order_item_id = oi.id ORDER BY m.created_at),
+		       oi.created_at
+		FROM order_items oi
+		LEFT JOIN products p ON oi.product_id = p.id
+		LEFT JOIN kitchen_stations s ON oi.station_id = s.id
+		WHERE oi.order_id = ANY($1::uuid[]) AND oi.item_type = 'product'
+	`
+	itemArgs := []interface{}{pq.Array(orderIDs)}
+	if stationID != nil {
+		itemQuery += ` AND oi.station_id = $2`
+		itemArgs = append(itemArgs, *stationID)
+	}
+	itemQuery += ` ORDER BY oi.created_at`
+
+	itemRows, err := q.Query(itemQuery, itemArgs...)
+	if err != nil {
+		return err
+	}
+	defer itemRows.Close()
+
+	now := time.Now()
+	threshold := kitchen.LateThreshold()
+	for itemRows.Next() {
+		var itemID, orderID uuid.UUID
+		var productID, itemStationID *uuid.UUID
+		var productName, stationName sql.NullString
+		var quantity, expectedPrepTime int
+		var specialInstructions *string
+		var itemStatus string
+		var startedAt, readyAt *time.Time
+		var isRemake bool
+		var remakeReason *string
+		var itemAllergens, modifiers []string
+		var createdAt time.Time
+
+		err := itemRows.Scan(&itemID, &orderID, &productID, &productName, &quantity,
+			&specialInstructions, &itemStatus, &itemStationID, &stationName,
+			&expectedPrepTime, &startedAt, &readyAt, &isRemake, &remakeReason,
+			pq.Array(&itemAllergens), pq.Array(&modifiers), &createdAt)
+		if err != nil {
+			return err
+		}
+
+		// Elapsed time runs from when the ticket was fired until the item is ready
+		end := now
+		if readyAt != nil {
+			end = *readyAt
+		}
+		late := kitchen.IsLate(itemStatus, expectedPrepTime, createdAt, end, threshold)
+
+		order := byID[orderID]
+		guestAllergies, _ := order["guest_allergies"].([]string)
+		conflicts := allergens.Conflicts(itemAllergens, guestAllergies)
+
+		item := map[string]interface{}{
+			"id":                   itemID,
+			"product_id":           productID,
+			"product_name":         productName.String,
+			"quantity":             quantity,
+			"special_instructions": specialInstructions,
+			"status":               itemStatus,
+			"station_id":           nil,
+			"station_name":         stationName.String,
+			"expected_minutes":     expectedPrepTime,
+			"elapsed_minutes":      int(end.Sub(createdAt).Minutes()),
+			"started_at":           startedAt,
+			"ready_at":             readyAt,
+			"late":                 late,
+			"is_remake":            isRemake,
+			"remake_reason":        remakeReason,
+			"modifiers":            modifiers,
+			"allergens":            itemAllergens,
+			"allergen_conflicts":   conflicts,
+			"created_at":           createdAt,
+		}
+		if itemStationID != nil {
+			item["station_id"] = *itemStationID
+		}
+
+		order["items"] = append(order["items"].([]map[string]interface{}), item)
+		if late {
+			order["late"] = true
+		}
+		if len(conflicts) > 0 {
+			order["allergy_alert"] = true
+		}
+	}
+
+	return itemRows.Err()
+}
+
+// validItemStatuses are the states an order item moves through in the kitchen
+var validItemStatuses = map[string]bool{
+	"pending":   true,
+	"preparing": true,
+	"ready":     true,
+	"served":    true,
+}
+
+// UpdateOrderItemStatus updates the status of an order item and rolls the
+// change up to the order
 func (h *KitchenHandler) UpdateOrderItemStatus(c *gin.Context) {
-	orderID := c.Param("id")
-	itemID := c.Param("item_id")
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid order ID",
+			"error":   "invalid_uuid",
+		})
+		return
+	}
+
+	itemID, err := uuid.Parse(c.Param("item_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid item ID",
+			"error":   "invalid_uuid",
+		})
+		return
+	}
+
+	userID, _, _, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "Authentication required",
+			"error":   "auth_required",
+		})
+		return
+	}
 
 	var req struct {
 		Status string `json:"status"`
@@ -101,11 +370,72 @@ func (h *KitchenHandler) UpdateOrderItemStatus(c *gin.Context) {
 		return
 	}
 
+	if !validItemStatuses[req.Status] {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid item status",
+			"error":   "invalid_status",
+		})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to start transaction",
+			"error":   err.Error(),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	// Lock the order so concurrent item updates roll up one at a time
+	var orderStatus string
+	err = tx.QueryRow("SELECT status FROM orders WHERE id = $1 FOR UPDATE", orderID).Scan(&orderStatus)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "Order not found",
+			"error":   "order_not_found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to fetch order",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if orderStatus == "completed" || orderStatus == "cancelled" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Items of a " + orderStatus + " order cannot be changed",
+			"error":   "invalid_order_status",
+		})
+		return
+	}
+
 	// Update order item status
-	_, err := h.db.Exec(`
+	// Preparation starts when the item is first marked preparing and ends when it
+	// is ready; moving an item back clears the later timestamps
+	result, err := tx.Exec(`
 		UPDATE order_items 
-		SET status = $1, updated_at = CURRENT_TIMESTAMP 
-		WHERE id = $2 AND order_id = $3
+		SET status = $1,
+		    started_at = CASE
+		        WHEN $1 = 'pending' THEN NULL
+		        WHEN $1 = 'preparing' THEN COALESCE(started_at, CURRENT_TIMESTAMP)
+		        ELSE started_at
+		    END,
+		    ready_at = CASE
+		        WHEN $1 IN ('ready', 'served') THEN COALESCE(ready_at, CURRENT_TIMESTAMP)
+		        ELSE NULL
+		    END,
+		    updated_at = CURRENT_TIMESTAMP 
+		WHERE id = $2 AND order_id = $3 AND item_type = 'product'
 	`, req.Status, itemID, orderID)
 
 	if err != nil {
@@ -117,8 +447,302 @@ func (h *KitchenHandler) UpdateOrderItemStatus(c *gin.Context) {
 		return
 	}
 
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "Order item not found",
+			"error":   "item_not_found",
+		})
+		return
+	}
+
+	err = events.Publish(tx, events.ItemStatusChanged, orderID, map[string]interface{}{
+		"item_id": itemID,
+		"status":  req.Status,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to publish order event",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	newStatus, err := rollUpOrderStatus(tx, orderID, orderStatus, &userID, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to update order status",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to commit transaction",
+			"error":   err.Error(),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Order item status updated successfully",
+		"data": gin.H{
+			"item_id":      itemID,
+			"status":       req.Status,
+			"order_status": newStatus,
+		},
 	})
-}
\ No newline at end of file
+}
+
+// orderStatusProgress ranks the order statuses in the order an order moves through them
+var orderStatusProgress = map[string]int{
+	"pending":   0,
+	"confirmed": 1,
+	"preparing": 2,
+	"ready":     3,
+	"served":    4,
+	"completed": 5,
+	"cancelled": 6,
+}
+
+// rollUpOrderStatus derives the order status from its kitchen items: the first
+// item in progress makes the order preparing, all items ready make it ready and
+// all items served make it served. The order only moves forward, so an item
+// corrected back does not undo what the server did with the order, unless
+// reopen is set for items the kitchen makes again. A change is logged in the
+// status history and published so the server is notified. It returns the
+// resulting order status.
+func rollUpOrderStatus(tx *sql.Tx, orderID uuid.UUID, currentStatus string, changedBy *uuid.UUID, reopen bool) (string, error) {
+	var total, pending, ready, served int
+	err := tx.QueryRow(`
+		SELECT COUNT(*),
+		       COUNT(*) FILTER (WHERE status = 'pending'),
+		       COUNT(*) FILTER (WHERE status = 'ready'),
+		       COUNT(*) FILTER (WHERE status = 'served')
+		FROM order_items
+		WHERE order_id = $1 AND item_type = 'product'
+	`, orderID).Scan(&total, &pending, &ready, &served)
+	if err != nil {
+		return currentStatus, err
+	}
+
+	var derived string
+	switch {
+	case total == 0 || pending == total:
+		// Nothing started yet; leave pending or confirmed orders alone
+		return currentStatus, nil
+	case served == total:
+		derived = "served"
+	case ready+served == total:
+		derived = "ready"
+	default:
+		derived = "preparing"
+	}
+
+	if derived == currentStatus {
+		return currentStatus, nil
+	}
+	if !reopen && orderStatusProgress[derived] < orderStatusProgress[currentStatus] {
+		return currentStatus, nil
+	}
+
+	updateQuery := "UPDATE orders SET status = $1, updated_at = CURRENT_TIMESTAMP"
+	if derived == "served" {
+		updateQuery += ", served_at = CURRENT_TIMESTAMP"
+	}
+	if _, err := tx.Exec(updateQuery+" WHERE id = $2", derived, orderID); err != nil {
+		return currentStatus, err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO order_status_history (order_id, previous_status, new_status, changed_by, notes)
+		VALUES ($1, $2, $3, $4, 'Derived from item statuses')
+	`, orderID, currentStatus, derived, changedBy)
+	if err != nil {
+		return currentStatus, err
+	}
+
+	eventType := events.OrderStatusChanged
+	if derived == "ready" {
+		eventType = events.OrderReady
+	}
+	err = events.Publish(tx, eventType, orderID, map[string]interface{}{
+		"previous_status": currentStatus,
+		"status":          derived,
+		"derived":         true,
+	})
+	if err != nil {
+		return currentStatus, err
+	}
+
+	return derived, nil
+}
+
+// prepReportGroups maps the group_by values of the prep time report to the
+// grouping key and display name
+var prepReportGroups = map[string][2]string{
+	"product": {"oi.product_id::text", "COALESCE(MAX(p.name), '')"},
+	"station": {"COALESCE(oi.station_id::text, '')", "COALESCE(MAX(s.name), 'unassigned')"},
+	"hour":    {"LPAD(EXTRACT(HOUR FROM oi.created_at AT TIME ZONE $3)::int::text, 2, '0')", "''"},
+}
+
+// GetPrepTimeReport compares actual with expected preparation times for items
+// finished between ?from= and ?to= (YYYY-MM-DD, inclusive, default the last 7
+// days), grouped by ?group_by=product|station|hour. Prep time runs from when an
+// item was started (or fired, if it was never marked preparing) until ready;
+// ticket time runs from when it was fired and is what the late flag uses.
+func (h *KitchenHandler) GetPrepTimeReport(c *gin.Context) {
+	groupBy := c.DefaultQuery("group_by", "product")
+	group, ok := prepReportGroups[groupBy]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid group_by. Use product, station or hour",
+			"error":   "invalid_group_by",
+		})
+		return
+	}
+
+	location, err := util.StoreLocation(h.db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to load settings",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	start, end, err := parseReportRange(c, location, 7)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"error":   "invalid_date",
+		})
+		return
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %[1]s AS group_key, %[2]s AS group_name,
+		       COUNT(*),
+		       COALESCE(AVG(oi.expected_prep_time), 0),
+		       COALESCE(AVG(EXTRACT(EPOCH FROM oi.ready_at - COALESCE(oi.started_at, oi.created_at))) / 60, 0),
+		       COALESCE(AVG(EXTRACT(EPOCH FROM oi.ready_at - oi.created_at)) / 60, 0),
+		       COUNT(*) FILTER (WHERE oi.late_at IS NOT NULL)
+		FROM order_items oi
+		LEFT JOIN products p ON oi.product_id = p.id
+		LEFT JOIN kitchen_stations s ON oi.station_id = s.id
+		WHERE oi.item_type = 'product'
+		  AND oi.ready_at IS NOT NULL
+		  AND oi.ready_at >= $1 AND oi.ready_at < $2
+		GROUP BY 1
+		ORDER BY 1
+	`, group[0], group[1])
+
+	args := []interface{}{start, end}
+	if groupBy == "hour" {
+		args = append(args, location.String())
+	}
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to fetch prep time report",
+			"error":   err.Error(),
+		})
+		return
+	}
+	defer rows.Close()
+
+	report := []map[string]interface{}{}
+	for rows.Next() {
+		var key, name string
+		var items, lateItems int
+		var expected, prep, ticket float64
+
+		if err := rows.Scan(&key, &name, &items, &expected, &prep, &ticket, &lateItems); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to scan prep time report",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		row := map[string]interface{}{
+			"items":                items,
+			"avg_expected_minutes": roundMinutes(expected),
+			"avg_prep_minutes":     roundMinutes(prep),
+			"avg_ticket_minutes":   roundMinutes(ticket),
+			"avg_variance_minutes": roundMinutes(ticket - expected),
+			"late_items":           lateItems,
+		}
+		switch groupBy {
+		case "hour":
+			hour, _ := strconv.Atoi(key)
+			row["hour"] = hour
+		default:
+			row[groupBy+"_id"] = nil
+			if key != "" {
+				row[groupBy+"_id"] = key
+			}
+			row[groupBy+"_name"] = name
+		}
+		report = append(report, row)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Prep time report retrieved successfully",
+		"data": gin.H{
+			"group_by":   groupBy,
+			"from":       start,
+			"to":         end,
+			"late_after": kitchen.LateThreshold().String(),
+			"rows":       report,
+		},
+	})
+}
+
+func roundMinutes(minutes float64) float64 {
+	return math.Round(minutes*10) / 10
+}
+
+// parseReportRange reads the inclusive ?from= and ?to= dates (YYYY-MM-DD) of a
+// report and returns the half-open range [start, end) in location. Without
+// dates the range covers the last defaultDays days including today.
+func parseReportRange(c *gin.Context, location *time.Location, defaultDays int) (time.Time, time.Time, error) {
+	now := time.Now().In(location)
+	from := now.AddDate(0, 0, 1-defaultDays)
+	to := now
+
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, location)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("Invalid from date. Use YYYY-MM-DD")
+		}
+		from = parsed
+	}
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, location)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("Invalid to date. Use YYYY-MM-DD")
+		}
+		to = parsed
+	}
+	if from.After(to) {
+		return time.Time{}, time.Time{}, errors.New("Invalid date range. from must not be after to")
+	}
+
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, location)
+	end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, location).AddDate(0, 0, 1)
+	return start, end, nil
+}
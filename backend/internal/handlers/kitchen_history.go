@@ -0,0 +1,642 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"pos-backend/internal/events"
+	"pos-backend/internal/middleware"
+	"pos-backend/internal/models"
+	"pos-backend/internal/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// maxBumpedWindow bounds how far back the bumped ticket list reaches, in minutes
+const maxBumpedWindow = 24 * 60
+
+// GetBumpedOrders returns tickets bumped (served) in the last ?minutes= minutes,
+// newest first, so a ticket bumped by mistake can be found and recalled. Only
+// served orders can be recalled; paid orders are listed for reference.
+func (h *KitchenHandler) GetBumpedOrders(c *gin.Context) {
+	minutes, err := strconv.Atoi(c.DefaultQuery("minutes", "30"))
+	if err != nil || minutes < 1 || minutes > maxBumpedWindow {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": fmt.Sprintf("minutes must be between 1 and %d", maxBumpedWindow),
+			"error":   "invalid_minutes",
+		})
+		return
+	}
+
+	var stationID *uuid.UUID
+	if value := c.Query("station"); value != "" {
+		id, err := resolveStation(h.db, value)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"message": "Kitchen station not found",
+				"error":   "station_not_found",
+			})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to fetch kitchen station",
+				"error":   err.Error(),
+			})
+			return
+		}
+		stationID = &id
+	}
+
+	query := `
+		SELECT o.id, o.order_number, o.table_id, o.order_type, o.status,
+		       o.created_at, o.customer_name, o.guest_allergies, COALESCE(t.table_number, g.table_number),
+		       bump.created_at, bump.changed_by
+		FROM orders o
+		JOIN LATERAL (
+			SELECT created_at, changed_by
+			FROM order_status_history
+			WHERE order_id = o.id AND new_status = 'served'
+			ORDER BY created_at DESC
+			LIMIT 1
+		) bump ON true
+		LEFT JOIN dining_tables t ON o.table_id = t.id
+		LEFT JOIN table_groups g ON o.table_id = g.id
+		WHERE o.status IN ('served', 'completed')
+		  AND bump.created_at >= CURRENT_TIMESTAMP - $1::float8 * INTERVAL '1 minute'
+	`
+	args := []interface{}{minutes}
+	if stationID != nil {
+		args = append(args, *stationID)
+		query += fmt.Sprintf(` AND EXISTS (
+			SELECT 1 FROM order_items oi
+			WHERE oi.order_id = o.id AND oi.item_type = 'product' AND oi.station_id = $%d
+		)`, len(args))
+	}
+	query += ` ORDER BY bump.created_at DESC`
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to fetch bumped orders",
+			"error":   err.Error(),
+		})
+		return
+	}
+	defer rows.Close()
+
+	orders := []map[string]interface{}{}
+	for rows.Next() {
+		var orderID uuid.UUID
+		var tableID, bumpedBy *uuid.UUID
+		var orderNumber, orderType, orderStatus, customerName, tableNumber sql.NullString
+		var createdAt, bumpedAt time.Time
+		var guestAllergies []string
+
+		err := rows.Scan(&orderID, &orderNumber, &tableID, &orderType, &orderStatus,
+			&createdAt, &customerName, pq.Array(&guestAllergies), &tableNumber, &bumpedAt, &bumpedBy)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to scan bumped order",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		orders = append(orders, map[string]interface{}{
+			"id":              orderID,
+			"order_number":    orderNumber.String,
+			"table_id":        tableID,
+			"table_number":    tableNumber.String,
+			"order_type":      orderType.String,
+			"status":          orderStatus.String,
+			"customer_name":   customerName.String,
+			"created_at":      createdAt,
+			"guest_allergies": guestAllergies,
+			"allergy_alert":   false,
+			"bumped_at":       bumpedAt,
+			"bumped_by":       bumpedBy,
+			"can_recall":      orderStatus.String == "served",
+			"late":            false,
+			"items":           []map[string]interface{}{},
+		})
+	}
+	rows.Close()
+
+	if err := attachKitchenItems(h.db, orders, stationID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to fetch bumped order items",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Bumped orders retrieved successfully",
+		"data":    orders,
+	})
+}
+
+// RecallOrder brings a bumped ticket back to the kitchen: served items and the
+// order return to ready and the recall is recorded in the ticket audit trail
+func (h *KitchenHandler) RecallOrder(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid order ID",
+			"error":   "invalid_uuid",
+		})
+		return
+	}
+
+	userID, _, _, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "Authentication required",
+			"error":   "auth_required",
+		})
+		return
+	}
+
+	var req models.RecallTicketRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Invalid request body",
+				"error":   err.Error(),
+			})
+			return
+		}
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to start transaction",
+			"error":   err.Error(),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	var orderStatus string
+	err = tx.QueryRow("SELECT status FROM orders WHERE id = $1 FOR UPDATE", orderID).Scan(&orderStatus)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "Order not found",
+			"error":   "order_not_found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to fetch order",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if orderStatus != "served" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Only served orders can be recalled - order is " + orderStatus,
+			"error":   "invalid_order_status",
+		})
+		return
+	}
+
+	rows, err := tx.Query(`
+		UPDATE order_items
+		SET status = 'ready', updated_at = CURRENT_TIMESTAMP
+		WHERE order_id = $1 AND item_type = 'product' AND status = 'served'
+		RETURNING id
+	`, orderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to recall order items",
+			"error":   err.Error(),
+		})
+		return
+	}
+	itemIDs := []string{}
+	for rows.Next() {
+		var itemID uuid.UUID
+		if err := rows.Scan(&itemID); err != nil {
+			rows.Close()
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to recall order items",
+				"error":   err.Error(),
+			})
+			return
+		}
+		itemIDs = append(itemIDs, itemID.String())
+	}
+	rows.Close()
+
+	_, err = tx.Exec(`
+		UPDATE orders
+		SET status = 'ready', served_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, orderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to update order status",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	notes := "Recalled by kitchen"
+	if req.Reason != nil && strings.TrimSpace(*req.Reason) != "" {
+		notes += ": " + strings.TrimSpace(*req.Reason)
+	}
+	_, err = tx.Exec(`
+		INSERT INTO order_status_history (order_id, previous_status, new_status, changed_by, notes)
+		VALUES ($1, $2, 'ready', $3, $4)
+	`, orderID, orderStatus, userID, notes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to log status change",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := recordTicketAction(tx, orderID, "recall", itemIDs, req.Reason, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to record recall",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	err = events.Publish(tx, events.OrderRecalled, orderID, map[string]interface{}{
+		"previous_status": orderStatus,
+		"status":          "ready",
+		"item_ids":        itemIDs,
+		"reason":          req.Reason,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to publish order event",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to commit transaction",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Order recalled successfully",
+		"data": gin.H{
+			"order_id": orderID,
+			"status":   "ready",
+			"item_ids": itemIDs,
+		},
+	})
+}
+
+// RemakeItems sends items of an order to the kitchen again as free remake items
+// linked to the originals. The reason is kept on the items for waste tracking.
+func (h *KitchenHandler) RemakeItems(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid order ID",
+			"error":   "invalid_uuid",
+		})
+		return
+	}
+
+	userID, _, _, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "Authentication required",
+			"error":   "auth_required",
+		})
+		return
+	}
+
+	var req models.RemakeItemsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" || len(req.Items) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "A reason and at least one item are required",
+			"error":   "invalid_remake",
+		})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to start transaction",
+			"error":   err.Error(),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	var orderStatus string
+	err = tx.QueryRow("SELECT status FROM orders WHERE id = $1 FOR UPDATE", orderID).Scan(&orderStatus)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "Order not found",
+			"error":   "order_not_found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to fetch order",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if orderStatus == "completed" || orderStatus == "cancelled" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Items of a " + orderStatus + " order cannot be remade",
+			"error":   "invalid_order_status",
+		})
+		return
+	}
+
+	remakes := []map[string]interface{}{}
+	remakeIDs := []string{}
+	for _, item := range req.Items {
+		var originalQuantity int
+		err := tx.QueryRow(`
+			SELECT quantity FROM order_items
+			WHERE id = $1 AND order_id = $2 AND item_type = 'product'
+		`, item.ItemID, orderID).Scan(&originalQuantity)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"message": "Order item not found",
+				"error":   "item_not_found",
+			})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to fetch order item",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		quantity := originalQuantity
+		if item.Quantity != nil {
+			quantity = *item.Quantity
+		}
+		if quantity < 1 || quantity > originalQuantity {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": fmt.Sprintf("Remake quantity must be between 1 and %d", originalQuantity),
+				"error":   "invalid_quantity",
+			})
+			return
+		}
+
+		// The remake is free: the original line already carries the price
+		remakeID := uuid.New()
+		_, err = tx.Exec(`
+			INSERT INTO order_items (id, order_id, product_id, quantity, original_unit_price, unit_price, total_price, special_instructions,
+			                         station_id, expected_prep_time, allergens, is_remake, remake_of, remake_reason)
+			SELECT $1::uuid, order_id, product_id, $2::int, 0, 0, 0, special_instructions,
+			       station_id, expected_prep_time, allergens, true, id, $3::text
+			FROM order_items
+			WHERE id = $4
+		`, remakeID, quantity, req.Reason, item.ItemID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to create remake item",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO order_item_modifiers (order_item_id, modifier_id, name, price, allergens)
+			SELECT $1::uuid, modifier_id, name, 0, allergens
+			FROM order_item_modifiers
+			WHERE order_item_id = $2
+		`, remakeID, item.ItemID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to copy remake modifiers",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		remakes = append(remakes, map[string]interface{}{
+			"item_id":   remakeID,
+			"remake_of": item.ItemID,
+			"quantity":  quantity,
+		})
+		remakeIDs = append(remakeIDs, remakeID.String())
+	}
+
+	if err := recordTicketAction(tx, orderID, "remake", remakeIDs, &req.Reason, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to record remake",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	err = events.Publish(tx, events.ItemsRemade, orderID, map[string]interface{}{
+		"items":  remakes,
+		"reason": req.Reason,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to publish order event",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// The pending remakes put a finished order back in preparation
+	newStatus, err := rollUpOrderStatus(tx, orderID, orderStatus, &userID, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to update order status",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to commit transaction",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Remake sent to the kitchen",
+		"data": gin.H{
+			"order_id":     orderID,
+			"order_status": newStatus,
+			"items":        remakes,
+		},
+	})
+}
+
+// GetWasteReport summarizes remade items between ?from= and ?to= (YYYY-MM-DD,
+// inclusive, default the last 7 days) by product and reason. The value is what
+// the original items were sold for.
+func (h *KitchenHandler) GetWasteReport(c *gin.Context) {
+	location, err := util.StoreLocation(h.db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to load settings",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	start, end, err := parseReportRange(c, location, 7)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"error":   "invalid_date",
+		})
+		return
+	}
+
+	rows, err := h.db.Query(`
+		SELECT oi.product_id, COALESCE(p.name, ''), oi.remake_reason,
+		       COUNT(*), SUM(oi.quantity), COALESCE(SUM(oi.quantity * orig.unit_price), 0)
+		FROM order_items oi
+		LEFT JOIN order_items orig ON oi.remake_of = orig.id
+		LEFT JOIN products p ON oi.product_id = p.id
+		WHERE oi.is_remake AND oi.created_at >= $1 AND oi.created_at < $2
+		GROUP BY oi.product_id, p.name, oi.remake_reason
+		ORDER BY 6 DESC
+	`, start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to fetch waste report",
+			"error":   err.Error(),
+		})
+		return
+	}
+	defer rows.Close()
+
+	report := []map[string]interface{}{}
+	var totalQuantity int
+	var totalValue float64
+	for rows.Next() {
+		var productID *uuid.UUID
+		var productName string
+		var reason *string
+		var remakes, quantity int
+		var value float64
+
+		if err := rows.Scan(&productID, &productName, &reason, &remakes, &quantity, &value); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to scan waste report",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		totalQuantity += quantity
+		totalValue += value
+		report = append(report, map[string]interface{}{
+			"product_id":   productID,
+			"product_name": productName,
+			"reason":       reason,
+			"remakes":      remakes,
+			"quantity":     quantity,
+			"value":        value,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Waste report retrieved successfully",
+		"data": gin.H{
+			"from":           start,
+			"to":             end,
+			"total_quantity": totalQuantity,
+			"total_value":    totalValue,
+			"rows":           report,
+		},
+	})
+}
+
+// recordTicketAction writes an entry to the kitchen ticket audit trail
+func recordTicketAction(tx *sql.Tx, orderID uuid.UUID, action string, itemIDs []string, reason *string, performedBy uuid.UUID) error {
+	_, err := tx.Exec(`
+		INSERT INTO kitchen_ticket_actions (order_id, action, item_ids, reason, performed_by)
+		VALUES ($1, $2, $3::uuid[], $4, $5)
+	`, orderID, action, pq.Array(itemIDs), reason, performedBy)
+	return err
+}
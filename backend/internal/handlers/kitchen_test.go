@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseReportRange(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		wantStart string
+		wantEnd   string
+		wantErr   bool
+	}{
+		{"single day", "from=2024-03-01&to=2024-03-01", "2024-03-01", "2024-03-02", false},
+		{"several days", "from=2024-03-01&to=2024-03-07", "2024-03-01", "2024-03-08", false},
+		{"from after to", "from=2024-03-07&to=2024-03-01", "", "", true},
+		{"invalid from", "from=03/01/2024&to=2024-03-01", "", "", true},
+		{"invalid to", "from=2024-03-01&to=tomorrow", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/?"+tt.query, nil)
+
+			start, end, err := parseReportRange(c, time.UTC, 7)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseReportRange(%q) = %v, %v, want an error", tt.query, start, end)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseReportRange(%q) failed: %v", tt.query, err)
+			}
+			if got := start.Format("2006-01-02"); got != tt.wantStart {
+				t.Errorf("start = %s, want %s", got, tt.wantStart)
+			}
+			if got := end.Format("2006-01-02"); got != tt.wantEnd {
+				t.Errorf("end = %s, want %s", got, tt.wantEnd)
+			}
+		})
+	}
+}
@@ -0,0 +1,507 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"pos-backend/internal/menuio"
+	"pos-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// menuRowTypes are the row types of a menu import, in the order they are
+// applied so products can use categories and modifiers products from the same file
+var menuRowTypes = []string{"category", "product", "modifier"}
+
+// menuFormat returns the format of a menu export or import: ?format=, or for
+// imports the request's content type. JSON unless it is CSV.
+func menuFormat(c *gin.Context) string {
+	format := strings.ToLower(c.Query("format"))
+	if format == "" && strings.Contains(c.ContentType(), "csv") {
+		format = "csv"
+	}
+	if format == "" {
+		format = "json"
+	}
+	return format
+}
+
+// loadMenuRows returns the categories, products and modifiers as import rows.
+// Products without a SKU and their modifiers are left out as they could not
+// be matched on import.
+func loadMenuRows(q queryer) ([]models.MenuRow, error) {
+	rows := []models.MenuRow{}
+
+	categoryRows, err := q.Query(`
+		SELECT name, COALESCE(description, ''), COALESCE(color, ''), sort_order, is_active
+		FROM categories
+		ORDER BY sort_order, name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	for categoryRows.Next() {
+		row := models.MenuRow{Type: "category"}
+		var isActive bool
+		if err := categoryRows.Scan(&row.Name, &row.Description, &row.Color, &row.SortOrder, &isActive); err != nil {
+			categoryRows.Close()
+			return nil, err
+		}
+		row.IsAvailable = &isActive
+		rows = append(rows, row)
+	}
+	categoryRows.Close()
+	if err := categoryRows.Err(); err != nil {
+		return nil, err
+	}
+
+	productRows, err := q.Query(`
+		SELECT p.name, p.sku, COALESCE(c.name, ''), COALESCE(p.description, ''), p.price,
+		       COALESCE(p.barcode, ''), COALESCE(p.image_url, ''), p.preparation_time, p.sort_order, p.is_available,
+		       p.allergens, p.dietary_tags
+		FROM products p
+		LEFT JOIN categories c ON p.category_id = c.id
+		WHERE NULLIF(p.sku, '') IS NOT NULL
+		ORDER BY c.sort_order, c.name, p.sort_order, p.name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	for productRows.Next() {
+		row := models.MenuRow{Type: "product"}
+		var isAvailable bool
+		err := productRows.Scan(&row.Name, &row.SKU, &row.Category, &row.Description, &row.Price,
+			&row.Barcode, &row.ImageURL, &row.PreparationTime, &row.SortOrder, &isAvailable,
+			pq.Array(&row.Allergens), pq.Array(&row.DietaryTags))
+		if err != nil {
+			productRows.Close()
+			return nil, err
+		}
+		row.IsAvailable = &isAvailable
+		rows = append(rows, row)
+	}
+	productRows.Close()
+	if err := productRows.Err(); err != nil {
+		return nil, err
+	}
+
+	modifierRows, err := q.Query(`
+		SELECT m.name, COALESCE(p.sku, ''), m.price, m.sort_order, m.is_available, m.allergens, m.dietary_tags
+		FROM modifiers m
+		LEFT JOIN products p ON m.product_id = p.id
+		WHERE m.product_id IS NULL OR NULLIF(p.sku, '') IS NOT NULL
+		ORDER BY p.sku NULLS FIRST, m.sort_order, m.name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer modifierRows.Close()
+	for modifierRows.Next() {
+		row := models.MenuRow{Type: "modifier"}
+		var isAvailable bool
+		err := modifierRows.Scan(&row.Name, &row.ProductSKU, &row.Price, &row.SortOrder, &isAvailable,
+			pq.Array(&row.Allergens), pq.Array(&row.DietaryTags))
+		if err != nil {
+			return nil, err
+		}
+		row.IsAvailable = &isAvailable
+		rows = append(rows, row)
+	}
+	return rows, modifierRows.Err()
+}
+
+// ExportMenu downloads the categories, products and modifiers as CSV, or as
+// JSON rows in the body ImportMenu takes, so either can be imported again
+func (h *MenuHandler) ExportMenu(c *gin.Context) {
+	format := menuFormat(c)
+	if format != "csv" && format != "json" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Format must be csv or json",
+			Error:   stringPtr("invalid_format"),
+		})
+		return
+	}
+
+	rows, err := loadMenuRows(h.db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to export menu",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	filename := "menu-" + time.Now().Format("20060102")
+	if format == "json" {
+		c.Header("Content-Disposition", "attachment; filename="+filename+".json")
+		c.JSON(http.StatusOK, models.MenuImportRequest{Rows: rows})
+		return
+	}
+
+	var b bytes.Buffer
+	if err := menuio.WriteCSV(&b, rows); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to export menu",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename="+filename+".csv")
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", b.Bytes())
+}
+
+// ImportMenu creates or updates categories by name, products by SKU and
+// modifiers by product SKU and name from a CSV file or JSON rows. Rows replace
+// every field of what they match. The import is applied only if every row
+// succeeds; with ?dry_run=true it reports what each row would do and changes nothing.
+func (h *MenuHandler) ImportMenu(c *gin.Context) {
+	dryRun := c.Query("dry_run") == "true"
+
+	var records []menuio.Record
+	switch menuFormat(c) {
+	case "csv":
+		var err error
+		records, err = menuio.ReadCSV(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid CSV file: " + err.Error(),
+				Error:   stringPtr("invalid_import"),
+			})
+			return
+		}
+	case "json":
+		var req models.MenuImportRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid request data",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+		for i, row := range req.Rows {
+			records = append(records, menuio.Record{Line: i + 1, Row: row})
+		}
+	default:
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Format must be csv or json",
+			Error:   stringPtr("invalid_format"),
+		})
+		return
+	}
+
+	if len(records) == 0 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "No rows to import",
+			Error:   stringPtr("invalid_import"),
+		})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to start transaction",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := importMenuRows(tx, records)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to import menu",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	result.DryRun = dryRun
+
+	if result.Failed > 0 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: fmt.Sprintf("%d rows could not be imported; nothing was changed", result.Failed),
+			Error:   stringPtr("invalid_import"),
+			Data:    result,
+		})
+		return
+	}
+
+	if dryRun {
+		c.JSON(http.StatusOK, models.APIResponse{
+			Success: true,
+			Message: fmt.Sprintf("Dry run: %d rows would be created and %d updated", result.Created, result.Updated),
+			Data:    result,
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to commit transaction",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	result.Applied = true
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: fmt.Sprintf("Menu imported: %d rows created and %d updated", result.Created, result.Updated),
+		Data:    result,
+	})
+}
+
+// importMenuRows applies the rows in tx, categories first, then products,
+// then modifiers. Each row runs in a savepoint so a failing row is reported
+// and the others still run; the caller commits only when none failed. The
+// error is for failures of the transaction itself.
+func importMenuRows(tx *sql.Tx, records []menuio.Record) (*models.MenuImportResult, error) {
+	result := &models.MenuImportResult{Rows: make([]models.MenuImportRowResult, len(records))}
+
+	order := make([]int, len(records))
+	for i := range records {
+		order[i] = i
+		records[i].Row.Type = strings.ToLower(strings.TrimSpace(records[i].Row.Type))
+	}
+	rank := func(rowType string) int {
+		for i, t := range menuRowTypes {
+			if t == rowType {
+				return i
+			}
+		}
+		return len(menuRowTypes)
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return rank(records[order[a]].Row.Type) < rank(records[order[b]].Row.Type)
+	})
+
+	// Rows matching the same category, product or modifier as an earlier row
+	seen := map[string]int{}
+
+	for _, i := range order {
+		record := records[i]
+		row := record.Row
+		rowResult := &result.Rows[i]
+		rowResult.Row = record.Line
+		rowResult.Type = row.Type
+		rowResult.Key = menuRowKey(row)
+
+		rowErr := record.Err
+		if rowErr == nil {
+			rowErr = validateMenuRow(row)
+		}
+		if rowErr == nil {
+			key := row.Type + "\x00" + strings.TrimSpace(row.ProductSKU) + "\x00" + rowResult.Key
+			if row.Type != "product" {
+				// Categories and modifiers are matched by name whatever its case
+				key = strings.ToLower(key)
+			}
+			if line, ok := seen[key]; ok {
+				rowErr = fmt.Errorf("duplicate of row %d", line)
+			} else {
+				seen[key] = record.Line
+			}
+		}
+
+		if rowErr == nil {
+			if _, err := tx.Exec("SAVEPOINT menu_import_row"); err != nil {
+				return nil, err
+			}
+			var created bool
+			created, rowErr = importMenuRow(tx, row)
+			if rowErr != nil {
+				if _, err := tx.Exec("ROLLBACK TO SAVEPOINT menu_import_row"); err != nil {
+					return nil, err
+				}
+			} else if _, err := tx.Exec("RELEASE SAVEPOINT menu_import_row"); err != nil {
+				return nil, err
+			}
+			if rowErr == nil && created {
+				rowResult.Action = "create"
+				result.Created++
+			} else if rowErr == nil {
+				rowResult.Action = "update"
+				result.Updated++
+			}
+		}
+
+		if rowErr != nil {
+			rowResult.Action = "error"
+			rowResult.Error = stringPtr(rowErr.Error())
+			result.Failed++
+		}
+	}
+	return result, nil
+}
+
+// menuRowKey is what a row is matched by: the SKU of a product, the name of
+// a category or modifier
+func menuRowKey(row models.MenuRow) string {
+	if row.Type == "product" {
+		return strings.TrimSpace(row.SKU)
+	}
+	return strings.TrimSpace(row.Name)
+}
+
+// validateMenuRow checks a row before it is applied
+func validateMenuRow(row models.MenuRow) error {
+	known := false
+	for _, rowType := range menuRowTypes {
+		if rowType == row.Type {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return fmt.Errorf("type must be category, product or modifier, got %q", row.Type)
+	}
+	if strings.TrimSpace(row.Name) == "" {
+		return errors.New("name is required")
+	}
+	if row.Type == "product" && strings.TrimSpace(row.SKU) == "" {
+		return errors.New("sku is required for products")
+	}
+	if row.Price < 0 {
+		return errors.New("price cannot be negative")
+	}
+	if row.PreparationTime < 0 {
+		return errors.New("preparation_time cannot be negative")
+	}
+	if _, _, err := normalizeMenuAttributes(row.Allergens, row.DietaryTags); err != nil {
+		return err
+	}
+	return nil
+}
+
+// importMenuRow creates or updates what a row matches, reporting whether it was created
+func importMenuRow(tx *sql.Tx, row models.MenuRow) (bool, error) {
+	name := strings.TrimSpace(row.Name)
+	isAvailable := getBoolValue(row.IsAvailable, true)
+	itemAllergens, dietaryTags, err := normalizeMenuAttributes(row.Allergens, row.DietaryTags)
+	if err != nil {
+		return false, err
+	}
+
+	switch row.Type {
+	case "category":
+		var categoryID uuid.UUID
+		err := tx.QueryRow(`
+			SELECT id FROM categories WHERE LOWER(name) = LOWER($1) ORDER BY created_at LIMIT 1
+		`, name).Scan(&categoryID)
+		if err == sql.ErrNoRows {
+			_, err = tx.Exec(`
+				INSERT INTO categories (name, description, color, sort_order, is_active)
+				VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), $4, $5)
+			`, name, row.Description, row.Color, row.SortOrder, isAvailable)
+			return true, err
+		}
+		if err != nil {
+			return false, err
+		}
+		_, err = tx.Exec(`
+			UPDATE categories
+			SET description = NULLIF($2, ''), color = NULLIF($3, ''), sort_order = $4, is_active = $5,
+			    updated_at = CURRENT_TIMESTAMP
+			WHERE id = $1
+		`, categoryID, row.Description, row.Color, row.SortOrder, isAvailable)
+		return false, err
+
+	case "product":
+		var categoryID *uuid.UUID
+		if category := strings.TrimSpace(row.Category); category != "" {
+			var id uuid.UUID
+			err := tx.QueryRow(`
+				SELECT id FROM categories WHERE LOWER(name) = LOWER($1) ORDER BY created_at LIMIT 1
+			`, category).Scan(&id)
+			if err == sql.ErrNoRows {
+				return false, fmt.Errorf("category %q not found", category)
+			}
+			if err != nil {
+				return false, err
+			}
+			categoryID = &id
+		}
+
+		sku := strings.TrimSpace(row.SKU)
+		result, err := tx.Exec(`
+			UPDATE products
+			SET category_id = $2, name = $3, description = NULLIF($4, ''), price = $5, barcode = NULLIF($6, ''),
+			    image_url = NULLIF($7, ''), preparation_time = $8, sort_order = $9, is_available = $10,
+			    allergens = $11, dietary_tags = $12, updated_at = CURRENT_TIMESTAMP
+			WHERE sku = $1
+		`, sku, categoryID, name, row.Description, row.Price, row.Barcode, row.ImageURL, row.PreparationTime,
+			row.SortOrder, isAvailable, pq.Array(itemAllergens), pq.Array(dietaryTags))
+		if err != nil {
+			return false, err
+		}
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected > 0 {
+			return false, nil
+		}
+		_, err = tx.Exec(`
+			INSERT INTO products (sku, category_id, name, description, price, barcode, image_url, preparation_time,
+			                      sort_order, is_available, allergens, dietary_tags)
+			VALUES ($1, $2, $3, NULLIF($4, ''), $5, NULLIF($6, ''), NULLIF($7, ''), $8, $9, $10, $11, $12)
+		`, sku, categoryID, name, row.Description, row.Price, row.Barcode, row.ImageURL, row.PreparationTime,
+			row.SortOrder, isAvailable, pq.Array(itemAllergens), pq.Array(dietaryTags))
+		return true, err
+
+	default: // modifier
+		var productID *uuid.UUID
+		if sku := strings.TrimSpace(row.ProductSKU); sku != "" {
+			var id uuid.UUID
+			err := tx.QueryRow("SELECT id FROM products WHERE sku = $1", sku).Scan(&id)
+			if err == sql.ErrNoRows {
+				return false, fmt.Errorf("product with sku %q not found", sku)
+			}
+			if err != nil {
+				return false, err
+			}
+			productID = &id
+		}
+
+		var modifierID uuid.UUID
+		err := tx.QueryRow(`
+			SELECT id FROM modifiers
+			WHERE LOWER(name) = LOWER($1) AND product_id IS NOT DISTINCT FROM $2
+			ORDER BY created_at LIMIT 1
+		`, name, productID).Scan(&modifierID)
+		if err == sql.ErrNoRows {
+			_, err = tx.Exec(`
+				INSERT INTO modifiers (product_id, name, price, allergens, dietary_tags, is_available, sort_order)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)
+			`, productID, name, row.Price, pq.Array(itemAllergens), pq.Array(dietaryTags), isAvailable, row.SortOrder)
+			return true, err
+		}
+		if err != nil {
+			return false, err
+		}
+		_, err = tx.Exec(`
+			UPDATE modifiers
+			SET price = $2, allergens = $3, dietary_tags = $4, is_available = $5, sort_order = $6,
+			    updated_at = CURRENT_TIMESTAMP
+			WHERE id = $1
+		`, modifierID, row.Price, pq.Array(itemAllergens), pq.Array(dietaryTags), isAvailable, row.SortOrder)
+		return false, err
+	}
+}
@@ -0,0 +1,397 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"pos-backend/internal/allergens"
+	"pos-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	errInvalidModifier   = errors.New("modifier not found or not available for this product")
+	errDuplicateModifier = errors.New("modifier chosen more than once")
+)
+
+// GetModifiers returns the modifiers, or with ?product_id= those that can be
+// ordered with the product
+func (h *AdminHandler) GetModifiers(c *gin.Context) {
+	var productID *uuid.UUID
+	if value := c.Query("product_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Invalid product ID",
+				"error":   "invalid_uuid",
+			})
+			return
+		}
+		productID = &id
+	}
+
+	modifiers, err := loadModifiers(h.db, productID, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to fetch modifiers",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Modifiers retrieved successfully",
+		"data":    modifiers,
+	})
+}
+
+// CreateModifier creates a modifier, for one product or without a product for all
+func (h *AdminHandler) CreateModifier(c *gin.Context) {
+	var req struct {
+		ProductID   *uuid.UUID `json:"product_id"`
+		Name        string     `json:"name" binding:"required"`
+		Price       float64    `json:"price"`
+		Allergens   []string   `json:"allergens"`
+		DietaryTags []string   `json:"dietary_tags"`
+		IsAvailable *bool      `json:"is_available"`
+		SortOrder   int        `json:"sort_order"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if req.Price < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Modifier price cannot be negative",
+			"error":   "invalid_price",
+		})
+		return
+	}
+
+	itemAllergens, dietaryTags, err := normalizeMenuAttributes(req.Allergens, req.DietaryTags)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"error":   "invalid_menu_attribute",
+		})
+		return
+	}
+
+	if req.ProductID != nil {
+		var exists bool
+		if err := h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM products WHERE id = $1)", *req.ProductID).Scan(&exists); err != nil || !exists {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Product not found",
+				"error":   "product_not_found",
+			})
+			return
+		}
+	}
+
+	var modifierID string
+	err = h.db.QueryRow(`
+		INSERT INTO modifiers (product_id, name, price, allergens, dietary_tags, is_available, sort_order)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`, req.ProductID, req.Name, req.Price, pq.Array(itemAllergens), pq.Array(dietaryTags),
+		getBoolValue(req.IsAvailable, true), req.SortOrder).Scan(&modifierID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to create modifier",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Modifier created successfully",
+		"data":    gin.H{"id": modifierID},
+	})
+}
+
+// UpdateModifier updates an existing modifier
+func (h *AdminHandler) UpdateModifier(c *gin.Context) {
+	modifierID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid modifier ID",
+			"error":   "invalid_uuid",
+		})
+		return
+	}
+
+	var req struct {
+		ProductID   *string  `json:"product_id"` // empty string makes the modifier available for all products
+		Name        *string  `json:"name"`
+		Price       *float64 `json:"price"`
+		Allergens   []string `json:"allergens"`
+		DietaryTags []string `json:"dietary_tags"`
+		IsAvailable *bool    `json:"is_available"`
+		SortOrder   *int     `json:"sort_order"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// Build dynamic update query
+	updates := []string{}
+	args := []interface{}{}
+	argCount := 1
+
+	if req.ProductID != nil {
+		var productID interface{}
+		if *req.ProductID != "" {
+			id, err := uuid.Parse(*req.ProductID)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"success": false,
+					"message": "Invalid product ID",
+					"error":   "invalid_uuid",
+				})
+				return
+			}
+			productID = id
+		}
+		updates = append(updates, fmt.Sprintf("product_id = $%d", argCount))
+		args = append(args, productID)
+		argCount++
+	}
+	if req.Name != nil {
+		updates = append(updates, fmt.Sprintf("name = $%d", argCount))
+		args = append(args, *req.Name)
+		argCount++
+	}
+	if req.Price != nil {
+		if *req.Price < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Modifier price cannot be negative",
+				"error":   "invalid_price",
+			})
+			return
+		}
+		updates = append(updates, fmt.Sprintf("price = $%d", argCount))
+		args = append(args, *req.Price)
+		argCount++
+	}
+	if req.Allergens != nil {
+		values, err := allergens.Normalize(req.Allergens, allergens.Allergens, "allergen")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": err.Error(),
+				"error":   "invalid_menu_attribute",
+			})
+			return
+		}
+		updates = append(updates, fmt.Sprintf("allergens = $%d", argCount))
+		args = append(args, pq.Array(values))
+		argCount++
+	}
+	if req.DietaryTags != nil {
+		values, err := allergens.Normalize(req.DietaryTags, allergens.DietaryTags, "dietary tag")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": err.Error(),
+				"error":   "invalid_menu_attribute",
+			})
+			return
+		}
+		updates = append(updates, fmt.Sprintf("dietary_tags = $%d", argCount))
+		args = append(args, pq.Array(values))
+		argCount++
+	}
+	if req.IsAvailable != nil {
+		updates = append(updates, fmt.Sprintf("is_available = $%d", argCount))
+		args = append(args, *req.IsAvailable)
+		argCount++
+	}
+	if req.SortOrder != nil {
+		updates = append(updates, fmt.Sprintf("sort_order = $%d", argCount))
+		args = append(args, *req.SortOrder)
+		argCount++
+	}
+
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "No fields to update",
+		})
+		return
+	}
+
+	updates = append(updates, "updated_at = CURRENT_TIMESTAMP")
+	args = append(args, modifierID)
+
+	query := fmt.Sprintf("UPDATE modifiers SET %s WHERE id = $%d", strings.Join(updates, ", "), argCount)
+	result, err := h.db.Exec(query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to update modifier",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "Modifier not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Modifier updated successfully",
+	})
+}
+
+// DeleteModifier deletes a modifier. Orders keep the modifiers as they were ordered.
+func (h *AdminHandler) DeleteModifier(c *gin.Context) {
+	modifierID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid modifier ID",
+			"error":   "invalid_uuid",
+		})
+		return
+	}
+
+	result, err := h.db.Exec("DELETE FROM modifiers WHERE id = $1", modifierID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to delete modifier",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "Modifier not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Modifier deleted successfully",
+	})
+}
+
+// loadModifiers returns every modifier, or those that can be ordered with a
+// product: its own and the ones available for all products
+func loadModifiers(q queryer, productID *uuid.UUID, availableOnly bool) ([]models.Modifier, error) {
+	query := `
+		SELECT id, product_id, name, price, allergens, dietary_tags, is_available, sort_order, created_at, updated_at
+		FROM modifiers
+		WHERE 1=1
+	`
+	args := []interface{}{}
+	if productID != nil {
+		args = append(args, *productID)
+		query += ` AND (product_id = $1 OR product_id IS NULL)`
+	}
+	if availableOnly {
+		query += ` AND is_available = true`
+	}
+	query += ` ORDER BY sort_order, name`
+
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	modifiers := []models.Modifier{}
+	for rows.Next() {
+		var modifier models.Modifier
+		err := rows.Scan(
+			&modifier.ID, &modifier.ProductID, &modifier.Name, &modifier.Price,
+			pq.Array(&modifier.Allergens), pq.Array(&modifier.DietaryTags),
+			&modifier.IsAvailable, &modifier.SortOrder, &modifier.CreatedAt, &modifier.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		modifiers = append(modifiers, modifier)
+	}
+	return modifiers, rows.Err()
+}
+
+// resolveModifiers loads the modifiers chosen for an order item, returning
+// errInvalidModifier when one cannot be ordered with the product and
+// errDuplicateModifier when one is chosen twice
+func resolveModifiers(q queryer, productID uuid.UUID, modifierIDs []uuid.UUID) ([]models.Modifier, error) {
+	modifiers := []models.Modifier{}
+	seen := map[uuid.UUID]bool{}
+	for _, modifierID := range modifierIDs {
+		if seen[modifierID] {
+			return nil, errDuplicateModifier
+		}
+		seen[modifierID] = true
+
+		var modifier models.Modifier
+		err := q.QueryRow(`
+			SELECT id, name, price, allergens
+			FROM modifiers
+			WHERE id = $1 AND is_available = true AND (product_id = $2 OR product_id IS NULL)
+		`, modifierID, productID).Scan(&modifier.ID, &modifier.Name, &modifier.Price, pq.Array(&modifier.Allergens))
+		if err == sql.ErrNoRows {
+			return nil, errInvalidModifier
+		}
+		if err != nil {
+			return nil, err
+		}
+		modifiers = append(modifiers, modifier)
+	}
+	return modifiers, nil
+}
+
+// normalizeMenuAttributes validates the allergens and dietary tags of a product or modifier
+func normalizeMenuAttributes(itemAllergens, dietaryTags []string) ([]string, []string, error) {
+	normalizedAllergens, err := allergens.Normalize(itemAllergens, allergens.Allergens, "allergen")
+	if err != nil {
+		return nil, nil, err
+	}
+	normalizedTags, err := allergens.Normalize(dietaryTags, allergens.DietaryTags, "dietary tag")
+	if err != nil {
+		return nil, nil, err
+	}
+	return normalizedAllergens, normalizedTags, nil
+}
@@ -7,11 +7,14 @@ import (
 	"strconv"
 	"time"
 
+	"pos-backend/internal/allergens"
+	"pos-backend/internal/events"
 	"pos-backend/internal/middleware"
 	"pos-backend/internal/models"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type OrderHandler struct {
@@ -47,12 +50,13 @@ func (h *OrderHandler) GetOrders(c *gin.Context) {
 	// Build query with filters
 	queryBuilder := `
 		SELECT DISTINCT o.id, o.order_number, o.table_id, o.user_id, o.customer_name, 
-		       o.order_type, o.status, o.subtotal, o.tax_amount, o.discount_amount, 
-		       o.total_amount, o.notes, o.created_at, o.updated_at, o.served_at, o.completed_at,
-		       t.table_number, t.location,
+		       o.order_type, o.status, o.subtotal, o.tax_amount, o.service_charge_amount, o.discount_amount, 
+		       o.total_amount, o.notes, o.guest_allergies, o.covers, o.channel, o.fiscal_number, o.created_at, o.updated_at, o.served_at, o.completed_at,
+		       COALESCE(t.table_number, g.table_number), COALESCE(t.location, g.location),
 		       u.username, u.first_name, u.last_name
 		FROM orders o
 		LEFT JOIN dining_tables t ON o.table_id = t.id
+		LEFT JOIN table_groups g ON o.table_id = g.id
 		LEFT JOIN users u ON o.user_id = u.id
 		WHERE 1=1
 	`
@@ -112,8 +116,8 @@ func (h *OrderHandler) GetOrders(c *gin.Context) {
 
 		err := rows.Scan(
 			&order.ID, &order.OrderNumber, &order.TableID, &order.UserID, &order.CustomerName,
-			&order.OrderType, &order.Status, &order.Subtotal, &order.TaxAmount, &order.DiscountAmount,
-			&order.TotalAmount, &order.Notes, &order.CreatedAt, &order.UpdatedAt, &order.ServedAt, &order.CompletedAt,
+			&order.OrderType, &order.Status, &order.Subtotal, &order.TaxAmount, &order.ServiceChargeAmount, &order.DiscountAmount,
+			&order.TotalAmount, &order.Notes, pq.Array(&order.GuestAllergies), &order.Covers, &order.Channel, &order.FiscalNumber, &order.CreatedAt, &order.UpdatedAt, &order.ServedAt, &order.CompletedAt,
 			&tableNumber, &tableLocation,
 			&username, &firstName, &lastName,
 		)
@@ -232,7 +236,7 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	}
 
 	// Validate request
-	if len(req.Items) == 0 {
+	if len(req.Items) == 0 && len(req.GiftCards) == 0 {
 		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
 			Message: "Order must contain at least one item",
@@ -241,6 +245,83 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		return
 	}
 
+	if req.ReservationID != nil && req.WaitlistEntryID != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "An order can be for a reservation or a waitlist party, not both",
+			Error:   stringPtr("invalid_party"),
+		})
+		return
+	}
+
+	if req.Covers != nil && *req.Covers < 1 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Covers must be at least 1",
+			Error:   stringPtr("invalid_covers"),
+		})
+		return
+	}
+
+	// Products outside the current dayparts can only be ordered with a manager override
+	if req.OverrideDaypart && !isManager(c) {
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Message: "Only managers can order outside the current daypart",
+			Error:   stringPtr("daypart_override_forbidden"),
+		})
+		return
+	}
+	if !req.OverrideDaypart && len(req.Items) > 0 {
+		current, err := currentDayparts(h.db, time.Now())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to fetch dayparts",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+		productName, err := outOfDaypart(h.db, req.Items, current)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to check dayparts",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+		if productName != "" {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: productName + " is not on the menu right now",
+				Error:   stringPtr("outside_daypart"),
+			})
+			return
+		}
+	}
+
+	guestAllergies, err := allergens.Normalize(req.GuestAllergies, allergens.Allergens, "allergen")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: err.Error(),
+			Error:   stringPtr("invalid_allergen"),
+		})
+		return
+	}
+
+	// Items are charged at the price rules in effect when the order is taken
+	rules, err := currentPriceRules(h.db, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch price rules",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
 	// Start transaction
 	tx, err := h.db.Begin()
 	if err != nil {
@@ -253,49 +334,123 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	}
 	defer tx.Rollback()
 
-	// Generate order number
-	orderNumber := h.generateOrderNumber()
-
-	// Calculate totals
-	var subtotal float64
-	for _, item := range req.Items {
-		// Get product price
-		var price float64
-		err := tx.QueryRow("SELECT price FROM products WHERE id = $1 AND is_available = true", item.ProductID).Scan(&price)
+	// An order for a seated reservation or waitlist party is linked to it and
+	// takes the party's table, name and size unless they are given
+	if req.ReservationID != nil {
+		party, err := seatedReservation(tx, *req.ReservationID)
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusBadRequest, models.APIResponse{
 				Success: false,
-				Message: "Product not found or not available",
-				Error:   stringPtr("product_not_found"),
+				Message: "Reservation is not seated or already has an order",
+				Error:   stringPtr("invalid_reservation"),
 			})
 			return
 		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.APIResponse{
 				Success: false,
-				Message: "Failed to fetch product price",
+				Message: "Failed to fetch reservation",
 				Error:   stringPtr(err.Error()),
 			})
 			return
 		}
-		subtotal += price * float64(item.Quantity)
+		party.applyTo(&req)
+	}
+	if req.WaitlistEntryID != nil {
+		party, err := seatedWaitlistEntry(tx, *req.WaitlistEntryID)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Waitlist party is not seated or already has an order",
+				Error:   stringPtr("invalid_waitlist_entry"),
+			})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to fetch waitlist entry",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+		party.applyTo(&req)
+	}
+
+	// Tables pushed together take orders at their group only
+	if req.TableID != nil {
+		var joinedTo *uuid.UUID
+		err := tx.QueryRow("SELECT joined_to FROM dining_tables WHERE id = $1", req.TableID).Scan(&joinedTo)
+		if err != nil && err != sql.ErrNoRows {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to fetch table",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+		if joinedTo != nil {
+			c.JSON(http.StatusConflict, models.APIResponse{
+				Success: false,
+				Message: "Table is joined to a table group - seat the order at the group",
+				Error:   stringPtr("table_joined"),
+			})
+			return
+		}
+	}
+
+	// Generate order number
+	orderNumber := h.generateOrderNumber()
+
+	// Price the items, rejecting products that cannot be sold or the kitchen has 86'd
+	subtotal, code, message, err := priceOrderItems(tx, req.Items, rules)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to price order items",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	if code != "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   stringPtr(code),
+		})
+		return
+	}
+
+	// Gift cards are stored value, not revenue: they are added to the total untaxed
+	var giftCardTotal float64
+	for _, giftCard := range req.GiftCards {
+		if giftCard.Amount <= 0 {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Gift card amount must be greater than zero",
+				Error:   stringPtr("invalid_gift_card_amount"),
+			})
+			return
+		}
+		giftCardTotal += giftCard.Amount
 	}
 
 	// Calculate tax (10% for example)
 	taxRate := 0.10
 	taxAmount := subtotal * taxRate
-	totalAmount := subtotal + taxAmount
+	serviceChargeAmount := serviceCharge(tx, req.OrderType, subtotal)
+	totalAmount := subtotal + serviceChargeAmount + taxAmount + giftCardTotal
 
 	// Create order
 	orderID := uuid.New()
 	orderQuery := `
-		INSERT INTO orders (id, order_number, table_id, user_id, customer_name, order_type, status, 
-		                   subtotal, tax_amount, discount_amount, total_amount, notes)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		INSERT INTO orders (id, order_number, table_id, user_id, customer_name, customer_email, order_type, status, 
+		                   subtotal, tax_amount, service_charge_amount, discount_amount, total_amount, notes, guest_allergies, covers)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
 	`
 
-	_, err = tx.Exec(orderQuery, orderID, orderNumber, req.TableID, userID, req.CustomerName,
-		req.OrderType, "pending", subtotal, taxAmount, 0, totalAmount, req.Notes)
+	_, err = tx.Exec(orderQuery, orderID, orderNumber, req.TableID, userID, req.CustomerName, req.CustomerEmail,
+		req.OrderType, "pending", subtotal, taxAmount, serviceChargeAmount, 0, totalAmount, req.Notes, pq.Array(guestAllergies), req.Covers)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
@@ -306,50 +461,94 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	}
 
 	// Create order items
-	for _, item := range req.Items {
-		// Get product price again for consistency
-		var price float64
-		err := tx.QueryRow("SELECT price FROM products WHERE id = $1", item.ProductID).Scan(&price)
+	if err := insertOrderItems(tx, orderID, req.Items, rules); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to create order items",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	// Create gift card lines
+	for _, giftCard := range req.GiftCards {
+		giftCardID, err := prepareGiftCard(tx, giftCard.Code, userID)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Gift card not found or not active",
+				Error:   stringPtr("gift_card_not_found"),
+			})
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.APIResponse{
 				Success: false,
-				Message: "Failed to fetch product price",
+				Message: "Failed to prepare gift card",
 				Error:   stringPtr(err.Error()),
 			})
 			return
 		}
 
-		totalPrice := price * float64(item.Quantity)
-		itemID := uuid.New()
-
-		itemQuery := `
-			INSERT INTO order_items (id, order_id, product_id, quantity, unit_price, total_price, special_instructions)
-			VALUES ($1, $2, $3, $4, $5, $6, $7)
-		`
-
-		_, err = tx.Exec(itemQuery, itemID, orderID, item.ProductID, item.Quantity, price, totalPrice, item.SpecialInstructions)
+		_, err = tx.Exec(`
+			INSERT INTO order_items (id, order_id, item_type, gift_card_id, quantity, original_unit_price, unit_price, total_price, status)
+			VALUES ($1, $2, 'gift_card', $3, 1, $4, $4, $4, 'served')
+		`, uuid.New(), orderID, giftCardID, giftCard.Amount)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.APIResponse{
 				Success: false,
-				Message: "Failed to create order item",
+				Message: "Failed to create gift card item",
 				Error:   stringPtr(err.Error()),
 			})
 			return
 		}
 	}
 
-	// Update table status if dine-in
-	if req.OrderType == "dine_in" && req.TableID != nil {
-		_, err = tx.Exec("UPDATE dining_tables SET is_occupied = true WHERE id = $1", *req.TableID)
+	if req.ReservationID != nil {
+		_, err = tx.Exec("UPDATE reservations SET order_id = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2", orderID, *req.ReservationID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.APIResponse{
 				Success: false,
-				Message: "Failed to update table status",
+				Message: "Failed to link reservation",
 				Error:   stringPtr(err.Error()),
 			})
 			return
 		}
 	}
+	if req.WaitlistEntryID != nil {
+		_, err = tx.Exec("UPDATE waitlist_entries SET order_id = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2", orderID, *req.WaitlistEntryID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to link waitlist entry",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+	}
+
+	err = events.Publish(tx, events.OrderCreated, orderID, map[string]interface{}{
+		"order_number": orderNumber,
+		"order_type":   req.OrderType,
+		"table_id":     req.TableID,
+		"status":       "pending",
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to publish order event",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	if err := syncTableStatus(tx, orderID); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to update table status",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
 
 	// Commit transaction
 	if err := tx.Commit(); err != nil {
@@ -361,6 +560,8 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		return
 	}
 
+	autoPrint(h.db, orderID, "kitchen", &userID)
+
 	// Fetch and return the created order
 	order, err := h.getOrderByID(orderID)
 	if err != nil {
@@ -379,6 +580,130 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	})
 }
 
+// serviceCharge returns the service charge for a dine-in order subtotal;
+// settings.service_charge_rate is a percentage. Other order types pay none.
+func serviceCharge(q queryer, orderType string, subtotal float64) float64 {
+	if orderType != "dine_in" {
+		return 0
+	}
+	var rate float64
+	q.QueryRow("SELECT service_charge_rate FROM settings ORDER BY created_at DESC LIMIT 1").Scan(&rate)
+	return roundAmount(subtotal * rate / 100)
+}
+
+// priceOrderItems checks that the products and modifiers of an order can be
+// sold, uses up limited portions on the 86 list and returns the subtotal at
+// the prices set by rules. A non-empty code and message say why the items
+// were rejected.
+func priceOrderItems(tx *sql.Tx, items []models.CreateOrderItem, rules priceRules) (float64, string, string, error) {
+	var subtotal float64
+	for _, item := range items {
+		var listPrice float64
+		var categoryID *uuid.UUID
+		err := tx.QueryRow("SELECT price, category_id FROM products WHERE id = $1 AND is_available = true", item.ProductID).Scan(&listPrice, &categoryID)
+		if err == sql.ErrNoRows {
+			return 0, "product_not_found", "Product not found or not available", nil
+		}
+		if err != nil {
+			return 0, "", "", err
+		}
+
+		modifiers, err := resolveModifiers(tx, item.ProductID, item.ModifierIDs)
+		if err == errInvalidModifier {
+			return 0, "modifier_not_found", "Modifier not found or not available for this product", nil
+		}
+		if err == errDuplicateModifier {
+			return 0, "duplicate_modifier", "Each modifier can only be chosen once per item", nil
+		}
+		if err != nil {
+			return 0, "", "", err
+		}
+		price, _ := rules.price(item.ProductID, categoryID, listPrice)
+		for _, modifier := range modifiers {
+			price += modifier.Price
+		}
+
+		subtotal += price * float64(item.Quantity)
+	}
+
+	// Reject products the kitchen has 86'd and use up limited portions
+	code, message, err := claimEightySixed(tx, items)
+	if err != nil || code != "" {
+		return 0, code, message, err
+	}
+	return subtotal, "", "", nil
+}
+
+// insertOrderItems adds the items of a new order with their modifiers, routed
+// to their kitchen station. Items keep their list price next to the price
+// charged under rules; a price rule only changes the product's own price.
+func insertOrderItems(tx *sql.Tx, orderID uuid.UUID, items []models.CreateOrderItem, rules priceRules) error {
+	for _, item := range items {
+		// Get product price again for consistency
+		var originalPrice float64
+		var categoryID *uuid.UUID
+		var productAllergens []string
+		err := tx.QueryRow("SELECT price, category_id, allergens FROM products WHERE id = $1", item.ProductID).Scan(&originalPrice, &categoryID, pq.Array(&productAllergens))
+		if err != nil {
+			return err
+		}
+		price, rule := rules.price(item.ProductID, categoryID, originalPrice)
+		var ruleID *uuid.UUID
+		var ruleName *string
+		if rule != nil {
+			ruleID, ruleName = &rule.ID, &rule.Name
+		}
+
+		modifiers, err := resolveModifiers(tx, item.ProductID, item.ModifierIDs)
+		if err != nil {
+			return err
+		}
+
+		// The kitchen checks guest allergies against the product and its modifiers
+		itemAllergens := allergens.Merge(productAllergens)
+		for _, modifier := range modifiers {
+			originalPrice += modifier.Price
+			price += modifier.Price
+			itemAllergens = allergens.Merge(itemAllergens, modifier.Allergens)
+		}
+
+		totalPrice := price * float64(item.Quantity)
+		itemID := uuid.New()
+
+		// Route the item to the product's station, or its category's, and keep the
+		// preparation time it is expected to take
+		itemQuery := `
+			INSERT INTO order_items (id, order_id, product_id, quantity, unit_price, total_price, special_instructions,
+			                         station_id, expected_prep_time, allergens, original_unit_price, price_rule_id, price_rule_name)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, (
+				SELECT COALESCE(p.station_id, c.station_id)
+				FROM products p
+				LEFT JOIN categories c ON p.category_id = c.id
+				WHERE p.id = $3
+			), (
+				SELECT COALESCE(preparation_time, 0) FROM products WHERE id = $3
+			), $8, $9, $10, $11)
+		`
+
+		_, err = tx.Exec(itemQuery, itemID, orderID, item.ProductID, item.Quantity, price, totalPrice, item.SpecialInstructions,
+			pq.Array(itemAllergens), originalPrice, ruleID, ruleName)
+		if err != nil {
+			return err
+		}
+
+		for _, modifier := range modifiers {
+			_, err = tx.Exec(`
+				INSERT INTO order_item_modifiers (order_item_id, modifier_id, name, price, allergens)
+				VALUES ($1, $2, $3, $4, $5)
+			`, itemID, modifier.ID, modifier.Name, modifier.Price, pq.Array(modifier.Allergens))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // UpdateOrderStatus updates the status of an order
 func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 	orderID, err := uuid.Parse(c.Param("id"))
@@ -444,7 +769,7 @@ func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 
 	// Get current order status
 	var currentStatus string
-	err = tx.QueryRow("SELECT status FROM orders WHERE id = $1", orderID).Scan(&currentStatus)
+	err = tx.QueryRow("SELECT status FROM orders WHERE id = $1 FOR UPDATE", orderID).Scan(&currentStatus)
 	if err == sql.ErrNoRows {
 		c.JSON(http.StatusNotFound, models.APIResponse{
 			Success: false,
@@ -462,58 +787,229 @@ func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 		return
 	}
 
-	// Update order status
-	updateQuery := "UPDATE orders SET status = $1, updated_at = CURRENT_TIMESTAMP"
-	args := []interface{}{req.Status, orderID}
+	// Completing an order records its fiscal sale, so it goes through the same
+	// path as a payment that settles the order
+	if req.Status == "completed" {
+		completed, err := completeOrderIfPaid(tx, orderID, &userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to complete order",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+		if !completed {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Only an open order that is fully paid can be completed",
+				Error:   stringPtr("order_not_paid"),
+			})
+			return
+		}
+	} else {
+		// Update order status
+		updateQuery := "UPDATE orders SET status = $1, updated_at = CURRENT_TIMESTAMP"
+		args := []interface{}{req.Status, orderID}
+
+		// Set served_at timestamp
+		if req.Status == "served" {
+			updateQuery += ", served_at = CURRENT_TIMESTAMP"
+		}
+
+		updateQuery += " WHERE id = $2"
+
+		_, err = tx.Exec(updateQuery, args...)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to update order status",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+
+		// Log status change in history
+		historyQuery := `
+			INSERT INTO order_status_history (order_id, previous_status, new_status, changed_by, notes)
+			VALUES ($1, $2, $3, $4, $5)
+		`
+		_, err = tx.Exec(historyQuery, orderID, currentStatus, req.Status, userID, req.Notes)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to log status change",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+
+		// Portions of 86'd products the order used up can be sold again
+		if req.Status == "cancelled" && currentStatus != "cancelled" {
+			if err := releaseEightySixed(tx, orderID); err != nil {
+				c.JSON(http.StatusInternalServerError, models.APIResponse{
+					Success: false,
+					Message: "Failed to release 86'd portions",
+					Error:   stringPtr(err.Error()),
+				})
+				return
+			}
+		}
 
-	// Set served_at or completed_at timestamps
-	if req.Status == "served" {
-		updateQuery += ", served_at = CURRENT_TIMESTAMP"
-	} else if req.Status == "completed" {
-		updateQuery += ", completed_at = CURRENT_TIMESTAMP"
+		eventType := events.OrderStatusChanged
+		switch req.Status {
+		case "cancelled":
+			eventType = events.OrderCancelled
+		case "ready":
+			eventType = events.OrderReady
+		}
+		err = events.Publish(tx, eventType, orderID, map[string]interface{}{
+			"previous_status": currentStatus,
+			"status":          req.Status,
+			"notes":           req.Notes,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to publish order event",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
 	}
 
-	updateQuery += " WHERE id = $2"
+	if err := syncTableStatus(tx, orderID); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to update table status",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
 
-	_, err = tx.Exec(updateQuery, args...)
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to commit transaction",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	// Fetch and return the updated order
+	order, err := h.getOrderByID(orderID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
-			Message: "Failed to update order status",
+			Message: "Order updated but failed to fetch details",
 			Error:   stringPtr(err.Error()),
 		})
 		return
 	}
 
-	// Log status change in history
-	historyQuery := `
-		INSERT INTO order_status_history (order_id, previous_status, new_status, changed_by, notes)
-		VALUES ($1, $2, $3, $4, $5)
-	`
-	_, err = tx.Exec(historyQuery, orderID, currentStatus, req.Status, userID, req.Notes)
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Order status updated successfully",
+		Data:    order,
+	})
+}
+
+// UpdateGuestAllergies replaces the guest allergies flagged on an open order so
+// the kitchen can check the items against them
+func (h *OrderHandler) UpdateGuestAllergies(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid order ID",
+			Error:   stringPtr("invalid_uuid"),
+		})
+		return
+	}
+
+	var req models.UpdateGuestAllergiesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	guestAllergies, err := allergens.Normalize(req.GuestAllergies, allergens.Allergens, "allergen")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: err.Error(),
+			Error:   stringPtr("invalid_allergen"),
+		})
+		return
+	}
+
+	tx, err := h.db.Begin()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
-			Message: "Failed to log status change",
+			Message: "Failed to start transaction",
 			Error:   stringPtr(err.Error()),
 		})
 		return
 	}
+	defer tx.Rollback()
 
-	// If order is completed or cancelled, free up the table
-	if (req.Status == "completed" || req.Status == "cancelled") {
-		_, err = tx.Exec(`
-			UPDATE dining_tables 
-			SET is_occupied = false 
-			WHERE id IN (SELECT table_id FROM orders WHERE id = $1 AND table_id IS NOT NULL)
-		`, orderID)
-		if err != nil {
-			// Log error but don't fail the transaction
-			fmt.Printf("Warning: Failed to update table status: %v\n", err)
-		}
+	var status string
+	err = tx.QueryRow("SELECT status FROM orders WHERE id = $1 FOR UPDATE", orderID).Scan(&status)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Order not found",
+			Error:   stringPtr("order_not_found"),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch order",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	if status == "completed" || status == "cancelled" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Cannot change allergies of a " + status + " order",
+			Error:   stringPtr("invalid_order_status"),
+		})
+		return
+	}
+
+	_, err = tx.Exec(`
+		UPDATE orders SET guest_allergies = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2
+	`, pq.Array(guestAllergies), orderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to update guest allergies",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	err = events.Publish(tx, events.AllergiesChanged, orderID, map[string]interface{}{
+		"guest_allergies": guestAllergies,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to publish order event",
+			Error:   stringPtr(err.Error()),
+		})
+		return
 	}
 
-	// Commit transaction
 	if err := tx.Commit(); err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
@@ -523,12 +1019,11 @@ func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 		return
 	}
 
-	// Fetch and return the updated order
 	order, err := h.getOrderByID(orderID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
-			Message: "Order updated but failed to fetch details",
+			Message: "Failed to fetch updated order",
 			Error:   stringPtr(err.Error()),
 		})
 		return
@@ -536,7 +1031,7 @@ func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
-		Message: "Order status updated successfully",
+		Message: "Guest allergies updated successfully",
 		Data:    order,
 	})
 }
@@ -549,21 +1044,22 @@ func (h *OrderHandler) getOrderByID(orderID uuid.UUID) (*models.Order, error) {
 	var username, firstName, lastName sql.NullString
 
 	query := `
-		SELECT o.id, o.order_number, o.table_id, o.user_id, o.customer_name, 
-		       o.order_type, o.status, o.subtotal, o.tax_amount, o.discount_amount, 
-		       o.total_amount, o.notes, o.created_at, o.updated_at, o.served_at, o.completed_at,
-		       t.table_number, t.location,
+		SELECT o.id, o.order_number, o.table_id, o.user_id, o.customer_name, o.customer_email,
+		       o.order_type, o.status, o.subtotal, o.tax_amount, o.service_charge_amount, o.discount_amount, 
+		       o.total_amount, o.notes, o.guest_allergies, o.covers, o.channel, o.fiscal_number, o.created_at, o.updated_at, o.served_at, o.completed_at,
+		       COALESCE(t.table_number, g.table_number), COALESCE(t.location, g.location),
 		       u.username, u.first_name, u.last_name
 		FROM orders o
 		LEFT JOIN dining_tables t ON o.table_id = t.id
+		LEFT JOIN table_groups g ON o.table_id = g.id
 		LEFT JOIN users u ON o.user_id = u.id
 		WHERE o.id = $1
 	`
 
 	err := h.db.QueryRow(query, orderID).Scan(
-		&order.ID, &order.OrderNumber, &order.TableID, &order.UserID, &order.CustomerName,
-		&order.OrderType, &order.Status, &order.Subtotal, &order.TaxAmount, &order.DiscountAmount,
-		&order.TotalAmount, &order.Notes, &order.CreatedAt, &order.UpdatedAt, &order.ServedAt, &order.CompletedAt,
+		&order.ID, &order.OrderNumber, &order.TableID, &order.UserID, &order.CustomerName, &order.CustomerEmail,
+		&order.OrderType, &order.Status, &order.Subtotal, &order.TaxAmount, &order.ServiceChargeAmount, &order.DiscountAmount,
+		&order.TotalAmount, &order.Notes, pq.Array(&order.GuestAllergies), &order.Covers, &order.Channel, &order.FiscalNumber, &order.CreatedAt, &order.UpdatedAt, &order.ServedAt, &order.CompletedAt,
 		&tableNumber, &tableLocation,
 		&username, &firstName, &lastName,
 	)
@@ -604,11 +1100,14 @@ func (h *OrderHandler) getOrderByID(orderID uuid.UUID) (*models.Order, error) {
 
 func (h *OrderHandler) loadOrderItems(order *models.Order) error {
 	query := `
-		SELECT oi.id, oi.product_id, oi.quantity, oi.unit_price, oi.total_price, 
-		       oi.special_instructions, oi.status, oi.created_at, oi.updated_at,
+		SELECT oi.id, oi.product_id, oi.item_type, oi.gift_card_id, gc.code,
+		       oi.quantity, oi.original_unit_price, oi.unit_price, oi.total_price, oi.price_rule_id, oi.price_rule_name,
+		       oi.special_instructions, oi.status, oi.station_id,
+		       oi.is_remake, oi.remake_of, oi.remake_reason, oi.allergens, oi.created_at, oi.updated_at,
 		       p.name, p.description, p.price, p.preparation_time
 		FROM order_items oi
-		JOIN products p ON oi.product_id = p.id
+		LEFT JOIN products p ON oi.product_id = p.id
+		LEFT JOIN gift_cards gc ON oi.gift_card_id = gc.id
 		WHERE oi.order_id = $1
 		ORDER BY oi.created_at
 	`
@@ -622,13 +1121,15 @@ func (h *OrderHandler) loadOrderItems(order *models.Order) error {
 	var items []models.OrderItem
 	for rows.Next() {
 		var item models.OrderItem
-		var productName, productDescription string
-		var productPrice float64
-		var preparationTime int
+		var productName, productDescription sql.NullString
+		var productPrice sql.NullFloat64
+		var preparationTime sql.NullInt64
 
 		err := rows.Scan(
-			&item.ID, &item.ProductID, &item.Quantity, &item.UnitPrice, &item.TotalPrice,
-			&item.SpecialInstructions, &item.Status, &item.CreatedAt, &item.UpdatedAt,
+			&item.ID, &item.ProductID, &item.ItemType, &item.GiftCardID, &item.GiftCardCode,
+			&item.Quantity, &item.OriginalUnitPrice, &item.UnitPrice, &item.TotalPrice, &item.PriceRuleID, &item.PriceRuleName,
+			&item.SpecialInstructions, &item.Status, &item.StationID,
+			&item.IsRemake, &item.RemakeOf, &item.RemakeReason, pq.Array(&item.Allergens), &item.CreatedAt, &item.UpdatedAt,
 			&productName, &productDescription, &productPrice, &preparationTime,
 		)
 		if err != nil {
@@ -636,16 +1137,56 @@ func (h *OrderHandler) loadOrderItems(order *models.Order) error {
 		}
 
 		item.OrderID = order.ID
-		item.Product = &models.Product{
-			ID:              item.ProductID,
-			Name:            productName,
-			Description:     &productDescription,
-			Price:           productPrice,
-			PreparationTime: preparationTime,
+		if conflicts := allergens.Conflicts(item.Allergens, order.GuestAllergies); len(conflicts) > 0 {
+			item.AllergenConflicts = conflicts
+		}
+		if item.ProductID != nil {
+			item.Product = &models.Product{
+				ID:              *item.ProductID,
+				Name:            productName.String,
+				Description:     &productDescription.String,
+				Price:           productPrice.Float64,
+				PreparationTime: int(preparationTime.Int64),
+			}
 		}
 
 		items = append(items, item)
 	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Add the modifiers chosen for each item
+	modifierRows, err := h.db.Query(`
+		SELECT m.order_item_id, m.id, m.modifier_id, m.name, m.price, m.allergens
+		FROM order_item_modifiers m
+		JOIN order_items oi ON m.order_item_id = oi.id
+		WHERE oi.order_id = $1
+		ORDER BY m.created_at
+	`, order.ID)
+	if err != nil {
+		return err
+	}
+	defer modifierRows.Close()
+
+	byItem := map[uuid.UUID]int{}
+	for i, item := range items {
+		byItem[item.ID] = i
+	}
+	for modifierRows.Next() {
+		var itemID uuid.UUID
+		var modifier models.OrderItemModifier
+		err := modifierRows.Scan(&itemID, &modifier.ID, &modifier.ModifierID, &modifier.Name, &modifier.Price, pq.Array(&modifier.Allergens))
+		if err != nil {
+			return err
+		}
+		if i, ok := byItem[itemID]; ok {
+			items[i].Modifiers = append(items[i].Modifiers, modifier)
+		}
+	}
+	if err := modifierRows.Err(); err != nil {
+		return err
+	}
 
 	order.Items = items
 	return nil
@@ -653,7 +1194,8 @@ func (h *OrderHandler) loadOrderItems(order *models.Order) error {
 
 func (h *OrderHandler) loadOrderPayments(order *models.Order) error {
 	query := `
-		SELECT p.id, p.payment_method, p.amount, p.reference_number, p.status, 
+		SELECT p.id, p.payment_method, p.amount, p.tip_amount, p.tendered_amount, p.reference_number, p.gift_card_id, p.status, 
+		       p.provider, p.transaction_id, p.failure_reason, p.refunded_amount,
 		       p.processed_by, p.processed_at, p.created_at,
 		       u.username, u.first_name, u.last_name
 		FROM payments p
@@ -674,8 +1216,9 @@ func (h *OrderHandler) loadOrderPayments(order *models.Order) error {
 		var username, firstName, lastName sql.NullString
 
 		err := rows.Scan(
-			&payment.ID, &payment.PaymentMethod, &payment.Amount, &payment.ReferenceNumber,
-			&payment.Status, &payment.ProcessedBy, &payment.ProcessedAt, &payment.CreatedAt,
+			&payment.ID, &payment.PaymentMethod, &payment.Amount, &payment.TipAmount, &payment.TenderedAmount, &payment.ReferenceNumber,
+			&payment.GiftCardID, &payment.Status, &payment.Provider, &payment.TransactionID, &payment.FailureReason,
+			&payment.RefundedAmount, &payment.ProcessedBy, &payment.ProcessedAt, &payment.CreatedAt,
 			&username, &firstName, &lastName,
 		)
 		if err != nil {
@@ -1,10 +1,17 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"time"
 
+	"pos-backend/internal/events"
+	"pos-backend/internal/fiscal"
+	"pos-backend/internal/gateway"
 	"pos-backend/internal/middleware"
 	"pos-backend/internal/models"
 
@@ -12,12 +19,16 @@ import (
 	"github.com/google/uuid"
 )
 
+// providerTimeout bounds every synchronous call to a payment provider
+const providerTimeout = 15 * time.Second
+
 type PaymentHandler struct {
-	db *sql.DB
+	db        *sql.DB
+	providers *gateway.Registry
 }
 
-func NewPaymentHandler(db *sql.DB) *PaymentHandler {
-	return &PaymentHandler{db: db}
+func NewPaymentHandler(db *sql.DB, providers *gateway.Registry) *PaymentHandler {
+	return &PaymentHandler{db: db, providers: providers}
 }
 
 // ProcessPayment processes a payment for an order
@@ -53,7 +64,7 @@ func (h *PaymentHandler) ProcessPayment(c *gin.Context) {
 	}
 
 	// Validate payment method
-	validMethods := []string{"cash", "credit_card", "debit_card", "digital_wallet"}
+	validMethods := []string{"cash", "credit_card", "debit_card", "digital_wallet", "gift_card"}
 	isValidMethod := false
 	for _, method := range validMethods {
 		if req.PaymentMethod == method {
@@ -80,6 +91,29 @@ func (h *PaymentHandler) ProcessPayment(c *gin.Context) {
 		return
 	}
 
+	if req.PaymentMethod == "gift_card" && req.GiftCardCode == nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Gift card code is required",
+			Error:   stringPtr("gift_card_required"),
+		})
+		return
+	}
+
+	// Card and wallet payments must have a provider configured
+	var provider gateway.PaymentProvider
+	if req.PaymentMethod != "cash" && req.PaymentMethod != "gift_card" {
+		provider, err = h.providers.ForMethod(req.PaymentMethod)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "No payment provider configured for this payment method",
+				Error:   stringPtr("payment_provider_unavailable"),
+			})
+			return
+		}
+	}
+
 	// Start transaction
 	tx, err := h.db.Begin()
 	if err != nil {
@@ -95,7 +129,7 @@ func (h *PaymentHandler) ProcessPayment(c *gin.Context) {
 	// Check if order exists and get total amount
 	var orderTotalAmount float64
 	var orderStatus string
-	err = tx.QueryRow("SELECT total_amount, status FROM orders WHERE id = $1", orderID).Scan(&orderTotalAmount, &orderStatus)
+	err = tx.QueryRow("SELECT total_amount, status FROM orders WHERE id = $1 FOR UPDATE", orderID).Scan(&orderTotalAmount, &orderStatus)
 	if err == sql.ErrNoRows {
 		c.JSON(http.StatusNotFound, models.APIResponse{
 			Success: false,
@@ -123,13 +157,15 @@ func (h *PaymentHandler) ProcessPayment(c *gin.Context) {
 		return
 	}
 
-	// Check if order is already fully paid
-	var totalPaid float64
+	// Payments still in flight with a provider count against the balance so
+	// the same amount cannot be charged twice while waiting for the webhook
+	var totalPaid, inFlight float64
 	err = tx.QueryRow(`
-		SELECT COALESCE(SUM(amount), 0) 
+		SELECT COALESCE(SUM(CASE WHEN status = 'completed' THEN amount ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN status IN ('pending', 'processing') THEN amount ELSE 0 END), 0)
 		FROM payments 
-		WHERE order_id = $1 AND status = 'completed'
-	`, orderID).Scan(&totalPaid)
+		WHERE order_id = $1
+	`, orderID).Scan(&totalPaid, &inFlight)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
@@ -149,7 +185,7 @@ func (h *PaymentHandler) ProcessPayment(c *gin.Context) {
 	}
 
 	// Check if payment amount doesn't exceed remaining balance
-	remainingAmount := orderTotalAmount - totalPaid
+	remainingAmount := orderTotalAmount - totalPaid - inFlight
 	if req.Amount > remainingAmount {
 		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
@@ -159,25 +195,72 @@ func (h *PaymentHandler) ProcessPayment(c *gin.Context) {
 		return
 	}
 
+	// Gift cards are redeemed up to their remaining balance; the rest of the
+	// order can be settled with another tender
+	var giftCardID *uuid.UUID
+	if req.PaymentMethod == "gift_card" {
+		id, balance, err := lockGiftCard(tx, *req.GiftCardCode)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Gift card not found or not active",
+				Error:   stringPtr("gift_card_not_found"),
+			})
+			return
+		}
+		if err == errGiftCardEmpty {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Gift card has no remaining balance",
+				Error:   stringPtr("gift_card_empty"),
+			})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to fetch gift card",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+
+		if req.Amount > balance {
+			req.Amount = balance
+		}
+		giftCardID = &id
+		if req.ReferenceNumber == nil {
+			req.ReferenceNumber = stringPtr(maskGiftCardCode(normalizeGiftCardCode(*req.GiftCardCode)))
+		}
+	}
+
 	// Create payment record
 	paymentID := uuid.New()
 	now := time.Now()
 
 	paymentQuery := `
-		INSERT INTO payments (id, order_id, payment_method, amount, reference_number, status, processed_by, processed_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO payments (id, order_id, payment_method, amount, tendered_amount, reference_number, gift_card_id, status, provider, processed_by, processed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`
 
-	// Simulate payment processing
-	paymentStatus := "completed"
-	if req.PaymentMethod != "cash" {
-		// For non-cash payments, we simulate processing
-		// In a real system, this would integrate with payment processors
-		paymentStatus = "completed" // Simulating successful processing
+	// Only cash can be overpaid; the receipt shows the difference as change
+	var tenderedAmount *float64
+	if req.PaymentMethod == "cash" && req.TenderedAmount != nil && *req.TenderedAmount > req.Amount {
+		tenderedAmount = req.TenderedAmount
+	}
+
+	// Cash is settled at the counter; everything else starts pending until the provider answers
+	paymentStatus := gateway.StatusCompleted
+	var providerName *string
+	processedAt := &now
+	if provider != nil {
+		paymentStatus = gateway.StatusPending
+		providerName = stringPtr(provider.Name())
+		processedAt = nil
 	}
 
-	_, err = tx.Exec(paymentQuery, paymentID, orderID, req.PaymentMethod, req.Amount,
-		req.ReferenceNumber, paymentStatus, userID, now)
+	_, err = tx.Exec(paymentQuery, paymentID, orderID, req.PaymentMethod, req.Amount, tenderedAmount,
+		req.ReferenceNumber, giftCardID, paymentStatus, providerName, userID, processedAt)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
@@ -187,43 +270,26 @@ func (h *PaymentHandler) ProcessPayment(c *gin.Context) {
 		return
 	}
 
-	// Check if order is now fully paid
-	newTotalPaid := totalPaid + req.Amount
-	if newTotalPaid >= orderTotalAmount {
-		// Update order status to completed if fully paid
-		_, err = tx.Exec(`
-			UPDATE orders 
-			SET status = 'completed', completed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
-			WHERE id = $1
-		`, orderID)
-		if err != nil {
+	if giftCardID != nil {
+		if err := adjustGiftCard(tx, *giftCardID, "redeem", req.Amount, orderID, paymentID, &userID); err != nil {
 			c.JSON(http.StatusInternalServerError, models.APIResponse{
 				Success: false,
-				Message: "Failed to update order status",
+				Message: "Failed to redeem gift card",
 				Error:   stringPtr(err.Error()),
 			})
 			return
 		}
+	}
 
-		// Free up the table
-		_, err = tx.Exec(`
-			UPDATE dining_tables 
-			SET is_occupied = false 
-			WHERE id IN (SELECT table_id FROM orders WHERE id = $1 AND table_id IS NOT NULL)
-		`, orderID)
-		if err != nil {
-			// Log error but don't fail the transaction
-			// fmt.Printf("Warning: Failed to update table status: %v\n", err)
-		}
-
-		// Log status change
-		_, err = tx.Exec(`
-			INSERT INTO order_status_history (order_id, previous_status, new_status, changed_by, notes)
-			VALUES ($1, $2, 'completed', $3, 'Order completed after payment')
-		`, orderID, orderStatus, userID)
-		if err != nil {
-			// Log error but don't fail the transaction
-			// fmt.Printf("Warning: Failed to log status change: %v\n", err)
+	orderCompleted := false
+	if paymentStatus == gateway.StatusCompleted {
+		if orderCompleted, err = completeOrderIfPaid(tx, orderID, &userID); err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to update order status",
+				Error:   stringPtr(err.Error()),
+			})
+			return
 		}
 	}
 
@@ -237,6 +303,30 @@ func (h *PaymentHandler) ProcessPayment(c *gin.Context) {
 		return
 	}
 
+	if orderCompleted {
+		afterOrderCompleted(h.db, orderID, &userID)
+	}
+
+	// Hand the charge to the provider outside of the database transaction
+	if provider != nil {
+		currency := h.storeCurrency()
+		if err := h.chargeProvider(c, provider, gateway.PaymentRequest{
+			PaymentID: paymentID,
+			OrderID:   orderID,
+			Method:    req.PaymentMethod,
+			Amount:    req.Amount,
+			Currency:  currency,
+			Reference: req.ReferenceNumber,
+		}); err != nil {
+			c.JSON(http.StatusBadGateway, models.APIResponse{
+				Success: false,
+				Message: "Failed to record payment provider response",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+	}
+
 	// Fetch the created payment
 	payment, err := h.getPaymentByID(paymentID)
 	if err != nil {
@@ -248,9 +338,415 @@ func (h *PaymentHandler) ProcessPayment(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, models.APIResponse{
+	switch payment.Status {
+	case gateway.StatusFailed:
+		c.JSON(http.StatusPaymentRequired, models.APIResponse{
+			Success: false,
+			Message: "Payment was declined",
+			Data:    payment,
+			Error:   stringPtr("payment_declined"),
+		})
+	case gateway.StatusPending, gateway.StatusProcessing:
+		c.JSON(http.StatusAccepted, models.APIResponse{
+			Success: true,
+			Message: "Payment is processing",
+			Data:    payment,
+		})
+	default:
+		c.JSON(http.StatusCreated, models.APIResponse{
+			Success: true,
+			Message: "Payment processed successfully",
+			Data:    payment,
+		})
+	}
+}
+
+// HandleWebhook receives signed asynchronous status updates from payment providers
+func (h *PaymentHandler) HandleWebhook(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Failed to read webhook body",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	if !h.providers.Verify(body, c.GetHeader(gateway.SignatureHeader)) {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Invalid webhook signature",
+			Error:   stringPtr("invalid_signature"),
+		})
+		return
+	}
+
+	var event gateway.WebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid webhook payload",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	providerName := c.Param("provider")
+	if _, ok := h.providers.ByName(providerName); !ok || event.Provider != providerName {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Unknown payment provider",
+			Error:   stringPtr("unknown_provider"),
+		})
+		return
+	}
+
+	if event.Status != gateway.StatusCompleted && event.Status != gateway.StatusFailed {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Unsupported webhook status",
+			Error:   stringPtr("invalid_status"),
+		})
+		return
+	}
+
+	var paymentID uuid.UUID
+	err = h.db.QueryRow(
+		"SELECT id FROM payments WHERE provider = $1 AND transaction_id = $2",
+		providerName, event.TransactionID,
+	).Scan(&paymentID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Payment not found",
+			Error:   stringPtr("payment_not_found"),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch payment",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	if err := h.settlePayment(paymentID, event.TransactionID, event.Status, event.Message); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to apply webhook",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Webhook processed successfully",
+	})
+}
+
+// VoidPayment cancels a provider payment that has not been captured yet
+func (h *PaymentHandler) VoidPayment(c *gin.Context) {
+	paymentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid payment ID",
+			Error:   stringPtr("invalid_uuid"),
+		})
+		return
+	}
+
+	payment, err := h.getPaymentByID(paymentID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Payment not found",
+			Error:   stringPtr("payment_not_found"),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch payment",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	if payment.Status != gateway.StatusPending && payment.Status != gateway.StatusProcessing {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Only pending or processing payments can be voided",
+			Error:   stringPtr("invalid_payment_status"),
+		})
+		return
+	}
+
+	if payment.Provider != nil && payment.TransactionID != nil {
+		provider, ok := h.providers.ByName(*payment.Provider)
+		if !ok {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Payment provider is no longer configured",
+				Error:   stringPtr("payment_provider_unavailable"),
+			})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), providerTimeout)
+		defer cancel()
+
+		if _, err := provider.Void(ctx, *payment.TransactionID); err != nil {
+			c.JSON(http.StatusBadGateway, models.APIResponse{
+				Success: false,
+				Message: "Payment provider rejected the void",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+	}
+
+	_, err = h.db.Exec(`
+		UPDATE payments
+		SET status = 'failed', failure_reason = 'Voided', updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status IN ('pending', 'processing')
+	`, paymentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to void payment",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	payment, err = h.getPaymentByID(paymentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Payment voided but failed to fetch details",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Payment voided successfully",
+		Data:    payment,
+	})
+}
+
+// RefundPayment returns all or part of a completed payment
+func (h *PaymentHandler) RefundPayment(c *gin.Context) {
+	paymentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid payment ID",
+			Error:   stringPtr("invalid_uuid"),
+		})
+		return
+	}
+
+	userID, _, _, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Authentication required",
+			Error:   stringPtr("auth_required"),
+		})
+		return
+	}
+
+	var req models.RefundPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	payment, err := h.getPaymentByID(paymentID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Payment not found",
+			Error:   stringPtr("payment_not_found"),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch payment",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to start transaction",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	// Lock the payment so concurrent refunds cannot together return more than was paid
+	err = tx.QueryRow(`
+		SELECT status, amount, refunded_amount FROM payments WHERE id = $1 FOR UPDATE
+	`, paymentID).Scan(&payment.Status, &payment.Amount, &payment.RefundedAmount)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch payment",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	if payment.Status != gateway.StatusCompleted {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Only completed payments can be refunded",
+			Error:   stringPtr("invalid_payment_status"),
+		})
+		return
+	}
+
+	refundable := payment.Amount - payment.RefundedAmount
+	amount := refundable
+	if req.Amount != nil {
+		amount = *req.Amount
+	}
+	if amount <= 0 || amount > refundable {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Refund amount must be greater than zero and not exceed the refundable amount",
+			Error:   stringPtr("invalid_amount"),
+		})
+		return
+	}
+
+	if payment.Provider != nil && payment.TransactionID != nil {
+		provider, ok := h.providers.ByName(*payment.Provider)
+		if !ok {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Payment provider is no longer configured",
+				Error:   stringPtr("payment_provider_unavailable"),
+			})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), providerTimeout)
+		defer cancel()
+
+		if _, err := provider.Refund(ctx, *payment.TransactionID, amount); err != nil {
+			c.JSON(http.StatusBadGateway, models.APIResponse{
+				Success: false,
+				Message: "Payment provider rejected the refund",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+	}
+
+	result, err := tx.Exec(`
+		UPDATE payments
+		SET refunded_amount = refunded_amount + $1,
+		    refunded_at = CURRENT_TIMESTAMP,
+		    refund_reason = $2,
+		    status = CASE WHEN refunded_amount + $1 >= amount THEN 'refunded' ELSE status END,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3 AND status = 'completed' AND refunded_amount + $1 <= amount
+	`, amount, req.Reason, paymentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to record refund",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: "Payment was refunded by someone else in the meantime",
+			Error:   stringPtr("refund_conflict"),
+		})
+		return
+	}
+
+	var refundID uuid.UUID
+	err = tx.QueryRow(`
+		INSERT INTO payment_refunds (payment_id, amount, reason, refunded_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, paymentID, amount, req.Reason, userID).Scan(&refundID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to record refund",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	if err := recordFiscalRefund(tx, payment.OrderID, paymentID, refundID, amount); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to record fiscal journal entry",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	// Gift card refunds go back onto the card
+	if payment.GiftCardID != nil {
+		if err := adjustGiftCard(tx, *payment.GiftCardID, "refund", amount, payment.OrderID, paymentID, &userID); err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to credit gift card",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to commit refund",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	payment, err = h.getPaymentByID(paymentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Refund recorded but failed to fetch details",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
-		Message: "Payment processed successfully",
+		Message: "Payment refunded successfully",
 		Data:    payment,
 	})
 }
@@ -290,7 +786,8 @@ func (h *PaymentHandler) GetPayments(c *gin.Context) {
 
 	// Fetch payments
 	query := `
-		SELECT p.id, p.payment_method, p.amount, p.reference_number, p.status, 
+		SELECT p.id, p.payment_method, p.amount, p.tip_amount, p.tendered_amount, p.reference_number, p.gift_card_id, p.status, 
+		       p.provider, p.transaction_id, p.failure_reason, p.refunded_amount,
 		       p.processed_by, p.processed_at, p.created_at,
 		       u.username, u.first_name, u.last_name
 		FROM payments p
@@ -316,8 +813,9 @@ func (h *PaymentHandler) GetPayments(c *gin.Context) {
 		var username, firstName, lastName sql.NullString
 
 		err := rows.Scan(
-			&payment.ID, &payment.PaymentMethod, &payment.Amount, &payment.ReferenceNumber,
-			&payment.Status, &payment.ProcessedBy, &payment.ProcessedAt, &payment.CreatedAt,
+			&payment.ID, &payment.PaymentMethod, &payment.Amount, &payment.TipAmount, &payment.TenderedAmount, &payment.ReferenceNumber,
+			&payment.GiftCardID, &payment.Status, &payment.Provider, &payment.TransactionID, &payment.FailureReason,
+			&payment.RefundedAmount, &payment.ProcessedBy, &payment.ProcessedAt, &payment.CreatedAt,
 			&username, &firstName, &lastName,
 		)
 		if err != nil {
@@ -362,23 +860,7 @@ func (h *PaymentHandler) GetPaymentSummary(c *gin.Context) {
 		return
 	}
 
-	// Get order total and payment summary
-	query := `
-		SELECT 
-		    o.total_amount,
-		    COALESCE(SUM(CASE WHEN p.status = 'completed' THEN p.amount ELSE 0 END), 0) as total_paid,
-		    COALESCE(SUM(CASE WHEN p.status = 'pending' THEN p.amount ELSE 0 END), 0) as pending_amount,
-		    COUNT(p.id) as payment_count
-		FROM orders o
-		LEFT JOIN payments p ON o.id = p.order_id
-		WHERE o.id = $1
-		GROUP BY o.id, o.total_amount
-	`
-
-	var totalAmount, totalPaid, pendingAmount float64
-	var paymentCount int
-
-	err = h.db.QueryRow(query, orderID).Scan(&totalAmount, &totalPaid, &pendingAmount, &paymentCount)
+	summary, err := loadPaymentSummary(h.db, orderID)
 	if err == sql.ErrNoRows {
 		c.JSON(http.StatusNotFound, models.APIResponse{
 			Success: false,
@@ -396,19 +878,6 @@ func (h *PaymentHandler) GetPaymentSummary(c *gin.Context) {
 		return
 	}
 
-	remainingAmount := totalAmount - totalPaid
-	isFullyPaid := remainingAmount <= 0
-
-	summary := map[string]interface{}{
-		"order_id":         orderID,
-		"total_amount":     totalAmount,
-		"total_paid":       totalPaid,
-		"pending_amount":   pendingAmount,
-		"remaining_amount": remainingAmount,
-		"is_fully_paid":    isFullyPaid,
-		"payment_count":    paymentCount,
-	}
-
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Message: "Payment summary retrieved successfully",
@@ -418,12 +887,39 @@ func (h *PaymentHandler) GetPaymentSummary(c *gin.Context) {
 
 // Helper functions
 
+// loadPaymentSummary returns the balance of an order: what it is made of and
+// what has been paid. Payments still with the provider are pending, not paid.
+func loadPaymentSummary(q queryer, orderID uuid.UUID) (*models.PaymentSummary, error) {
+	summary := models.PaymentSummary{OrderID: orderID}
+	err := q.QueryRow(`
+		SELECT 
+		    o.subtotal, o.discount_amount, o.service_charge_amount, o.tax_amount, o.total_amount,
+		    COALESCE(SUM(CASE WHEN p.status = 'completed' THEN p.amount ELSE 0 END), 0) as total_paid,
+		    COALESCE(SUM(CASE WHEN p.status IN ('pending', 'processing') THEN p.amount ELSE 0 END), 0) as pending_amount,
+		    COALESCE(SUM(CASE WHEN p.status = 'completed' THEN p.tip_amount ELSE 0 END), 0) as tip_amount,
+		    COUNT(p.id) as payment_count
+		FROM orders o
+		LEFT JOIN payments p ON o.id = p.order_id
+		WHERE o.id = $1
+		GROUP BY o.id
+	`, orderID).Scan(&summary.Subtotal, &summary.DiscountAmount, &summary.ServiceChargeAmount, &summary.TaxAmount,
+		&summary.TotalAmount, &summary.TotalPaid, &summary.PendingAmount, &summary.TipAmount, &summary.PaymentCount)
+	if err != nil {
+		return nil, err
+	}
+
+	summary.RemainingAmount = summary.TotalAmount - summary.TotalPaid
+	summary.IsFullyPaid = summary.RemainingAmount <= 0
+	return &summary, nil
+}
+
 func (h *PaymentHandler) getPaymentByID(paymentID uuid.UUID) (*models.Payment, error) {
 	var payment models.Payment
 	var username, firstName, lastName sql.NullString
 
 	query := `
-		SELECT p.id, p.order_id, p.payment_method, p.amount, p.reference_number, p.status, 
+		SELECT p.id, p.order_id, p.payment_method, p.amount, p.tip_amount, p.tendered_amount, p.reference_number, p.gift_card_id, p.status, 
+		       p.provider, p.transaction_id, p.failure_reason, p.refunded_amount,
 		       p.processed_by, p.processed_at, p.created_at,
 		       u.username, u.first_name, u.last_name
 		FROM payments p
@@ -432,8 +928,9 @@ func (h *PaymentHandler) getPaymentByID(paymentID uuid.UUID) (*models.Payment, e
 	`
 
 	err := h.db.QueryRow(query, paymentID).Scan(
-		&payment.ID, &payment.OrderID, &payment.PaymentMethod, &payment.Amount,
-		&payment.ReferenceNumber, &payment.Status, &payment.ProcessedBy,
+		&payment.ID, &payment.OrderID, &payment.PaymentMethod, &payment.Amount, &payment.TipAmount,
+		&payment.TenderedAmount, &payment.ReferenceNumber, &payment.GiftCardID, &payment.Status, &payment.Provider, &payment.TransactionID,
+		&payment.FailureReason, &payment.RefundedAmount, &payment.ProcessedBy,
 		&payment.ProcessedAt, &payment.CreatedAt,
 		&username, &firstName, &lastName,
 	)
@@ -454,3 +951,231 @@ func (h *PaymentHandler) getPaymentByID(paymentID uuid.UUID) (*models.Payment, e
 	return &payment, nil
 }
 
+// chargeProvider authorizes and captures a pending payment and records the outcome.
+// Declines fail the payment right away; timeouts and asynchronous captures leave it
+// processing until the provider reports the final status through the webhook.
+func (h *PaymentHandler) chargeProvider(c *gin.Context, provider gateway.PaymentProvider, req gateway.PaymentRequest) error {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), providerTimeout)
+	defer cancel()
+
+	result, err := provider.Authorize(ctx, req)
+	if err == nil {
+		if err := h.markProcessing(req.PaymentID, result.TransactionID); err != nil {
+			return err
+		}
+		result, err = provider.Capture(ctx, result.TransactionID, req.Amount)
+	}
+
+	transactionID := ""
+	if result != nil {
+		transactionID = result.TransactionID
+	}
+
+	switch {
+	case errors.Is(err, gateway.ErrTimeout):
+		return h.markProcessing(req.PaymentID, transactionID)
+	case err != nil:
+		reason := err.Error()
+		if result != nil && result.Message != "" {
+			reason = result.Message
+		}
+		return h.settlePayment(req.PaymentID, transactionID, gateway.StatusFailed, reason)
+	case result.Status == gateway.StatusCompleted:
+		return h.settlePayment(req.PaymentID, transactionID, gateway.StatusCompleted, result.Message)
+	default:
+		return h.markProcessing(req.PaymentID, transactionID)
+	}
+}
+
+// markProcessing records that the provider has accepted the payment for processing
+func (h *PaymentHandler) markProcessing(paymentID uuid.UUID, transactionID string) error {
+	_, err := h.db.Exec(`
+		UPDATE payments
+		SET status = 'processing', transaction_id = COALESCE(NULLIF($2, ''), transaction_id),
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status IN ('pending', 'processing')
+	`, paymentID, transactionID)
+	return err
+}
+
+// settlePayment moves an in-flight payment to its final status. Payments that are
+// already settled are left untouched so repeated webhooks are harmless.
+func (h *PaymentHandler) settlePayment(paymentID uuid.UUID, transactionID, status, message string) error {
+	tx, err := h.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var orderID uuid.UUID
+	var currentStatus string
+	err = tx.QueryRow("SELECT order_id, status FROM payments WHERE id = $1 FOR UPDATE", paymentID).Scan(&orderID, &currentStatus)
+	if err != nil {
+		return err
+	}
+
+	if currentStatus != gateway.StatusPending && currentStatus != gateway.StatusProcessing {
+		return nil
+	}
+
+	var failureReason *string
+	if status == gateway.StatusFailed {
+		failureReason = &message
+	}
+
+	_, err = tx.Exec(`
+		UPDATE payments
+		SET status = $2, transaction_id = COALESCE(NULLIF($3, ''), transaction_id),
+		    failure_reason = $4, processed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, paymentID, status, transactionID, failureReason)
+	if err != nil {
+		return err
+	}
+
+	orderCompleted := false
+	if status == gateway.StatusCompleted {
+		if orderCompleted, err = completeOrderIfPaid(tx, orderID, nil); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if orderCompleted {
+		afterOrderCompleted(h.db, orderID, nil)
+	}
+	return nil
+}
+
+// storeCurrency returns the configured store currency
+func (h *PaymentHandler) storeCurrency() string {
+	currency := "USD"
+	h.db.QueryRow("SELECT currency FROM settings ORDER BY created_at DESC LIMIT 1").Scan(&currency)
+	return currency
+}
+
+// afterOrderCompleted runs the follow-ups of a completed order once its payment is committed
+func afterOrderCompleted(db *sql.DB, orderID uuid.UUID, changedBy *uuid.UUID) {
+	autoPrint(db, orderID, "receipt", changedBy)
+	autoEmailReceipt(db, orderID, changedBy)
+}
+
+// completeOrderIfPaid marks the order completed and frees its table once the
+// completed payments cover the order total. It reports whether the order was completed.
+func completeOrderIfPaid(tx *sql.Tx, orderID uuid.UUID, changedBy *uuid.UUID) (bool, error) {
+	var orderTotalAmount, totalPaid float64
+	var orderStatus string
+	err := tx.QueryRow(`
+		SELECT o.total_amount, o.status,
+		       COALESCE((SELECT SUM(amount) FROM payments WHERE order_id = o.id AND status = 'completed'), 0)
+		FROM orders o
+		WHERE o.id = $1
+	`, orderID).Scan(&orderTotalAmount, &orderStatus, &totalPaid)
+	if err != nil {
+		return false, err
+	}
+
+	if totalPaid < orderTotalAmount || orderStatus == "completed" || orderStatus == "cancelled" {
+		return false, nil
+	}
+
+	// Update order status to completed if fully paid
+	_, err = tx.Exec(`
+		UPDATE orders 
+		SET status = 'completed', completed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, orderID)
+	if err != nil {
+		return false, err
+	}
+
+	if err := recordFiscalSale(tx, orderID); err != nil {
+		return false, err
+	}
+
+	// Log status change
+	_, err = tx.Exec(`
+		INSERT INTO order_status_history (order_id, previous_status, new_status, changed_by, notes)
+		VALUES ($1, $2, 'completed', $3, 'Order completed after payment')
+	`, orderID, orderStatus, changedBy)
+	if err != nil {
+		return false, err
+	}
+
+	// Gift cards sold on the order only carry value once it is paid
+	if err := activateOrderGiftCards(tx, orderID, changedBy); err != nil {
+		return false, err
+	}
+
+	if err := events.Publish(tx, events.OrderStatusChanged, orderID, map[string]interface{}{
+		"previous_status": orderStatus,
+		"status":          "completed",
+	}); err != nil {
+		return false, err
+	}
+	if err := syncTableStatus(tx, orderID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// recordFiscalSale assigns the next fiscal number to a completed order
+func recordFiscalSale(tx *sql.Tx, orderID uuid.UUID) error {
+	settings, err := loadSettings(tx)
+	if err != nil {
+		return err
+	}
+
+	entry := fiscal.Entry{EntryType: fiscal.EntrySale, OrderID: orderID, Currency: settings.Currency}
+	err = tx.QueryRow("SELECT order_number, total_amount, tax_amount FROM orders WHERE id = $1", orderID).Scan(
+		&entry.OrderNumber, &entry.Amount, &entry.TaxAmount,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := fiscal.Append(tx, &entry); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE orders SET fiscal_number = $1 WHERE id = $2", entry.FiscalNumber, orderID)
+	return err
+}
+
+// recordFiscalRefund assigns the next fiscal number to a refund. The refunded tax
+// is the order's tax share of the refunded amount.
+func recordFiscalRefund(tx *sql.Tx, orderID, paymentID, refundID uuid.UUID, amount float64) error {
+	settings, err := loadSettings(tx)
+	if err != nil {
+		return err
+	}
+
+	var totalAmount, taxAmount float64
+	entry := fiscal.Entry{
+		EntryType: fiscal.EntryRefund,
+		OrderID:   orderID,
+		PaymentID: &paymentID,
+		RefundID:  &refundID,
+		Amount:    amount,
+		Currency:  settings.Currency,
+	}
+	err = tx.QueryRow("SELECT order_number, total_amount, tax_amount FROM orders WHERE id = $1", orderID).Scan(
+		&entry.OrderNumber, &totalAmount, &taxAmount,
+	)
+	if err != nil {
+		return err
+	}
+	if totalAmount > 0 {
+		entry.TaxAmount = taxAmount * amount / totalAmount
+	}
+
+	if err := fiscal.Append(tx, &entry); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE payment_refunds SET fiscal_number = $1 WHERE id = $2", entry.FiscalNumber, refundID)
+	return err
+}
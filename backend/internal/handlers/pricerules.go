@@ -0,0 +1,424 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+	"time"
+
+	"pos-backend/internal/dayparts"
+	"pos-backend/internal/models"
+	"pos-backend/internal/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type PriceRuleHandler struct {
+	db *sql.DB
+}
+
+func NewPriceRuleHandler(db *sql.DB) *PriceRuleHandler {
+	return &PriceRuleHandler{db: db}
+}
+
+// priceRules are the price rules in effect at one moment
+type priceRules []models.PriceRule
+
+// price returns what a product listed at listPrice costs under the rules and
+// the rule that sets it, or nil when none applies. A rule for the product wins
+// over one for its category, which wins over one for every product; the lowest
+// price wins between rules of the same kind.
+func (rules priceRules) price(productID uuid.UUID, categoryID *uuid.UUID, listPrice float64) (float64, *models.PriceRule) {
+	var best *models.PriceRule
+	bestRank, bestPrice := 0, listPrice
+	for i := range rules {
+		rule := &rules[i]
+		rank := 0
+		switch {
+		case rule.ProductID != nil:
+			if *rule.ProductID != productID {
+				continue
+			}
+			rank = 3
+		case rule.CategoryID != nil:
+			if categoryID == nil || *rule.CategoryID != *categoryID {
+				continue
+			}
+			rank = 2
+		default:
+			rank = 1
+		}
+
+		rulePrice := adjustPrice(listPrice, rule.AdjustmentType, rule.AdjustmentValue)
+		if rank > bestRank || (rank == bestRank && rulePrice < bestPrice) {
+			best, bestRank, bestPrice = rule, rank, rulePrice
+		}
+	}
+	return bestPrice, best
+}
+
+// adjustPrice applies a price rule adjustment to a list price
+func adjustPrice(listPrice float64, adjustmentType string, value float64) float64 {
+	price := listPrice
+	switch adjustmentType {
+	case "percent_off":
+		price = listPrice * (1 - value/100)
+	case "amount_off":
+		price = listPrice - value
+	case "fixed_price":
+		price = value
+	}
+	if price < 0 {
+		price = 0
+	}
+	return roundAmount(price)
+}
+
+// loadPriceRules returns every price rule
+func loadPriceRules(q queryer) ([]models.PriceRule, error) {
+	rows, err := q.Query(`
+		SELECT id, name, days_of_week, to_char(start_time, 'HH24:MI'), to_char(end_time, 'HH24:MI'),
+		       category_id, product_id, adjustment_type, adjustment_value, is_active, created_at, updated_at
+		FROM price_rules
+		ORDER BY start_time, name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []models.PriceRule{}
+	for rows.Next() {
+		var rule models.PriceRule
+		err := rows.Scan(&rule.ID, &rule.Name, pq.Array(&rule.DaysOfWeek), &rule.StartTime, &rule.EndTime,
+			&rule.CategoryID, &rule.ProductID, &rule.AdjustmentType, &rule.AdjustmentValue, &rule.IsActive,
+			&rule.CreatedAt, &rule.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, rule)
+	}
+	return result, rows.Err()
+}
+
+// priceRuleWindow returns the weekly window a price rule is in effect
+func priceRuleWindow(rule models.PriceRule) dayparts.Window {
+	days := make([]int, len(rule.DaysOfWeek))
+	for i, day := range rule.DaysOfWeek {
+		days[i] = int(day)
+	}
+	return dayparts.Window{Days: days, Start: rule.StartTime, End: rule.EndTime}
+}
+
+// markCurrentPriceRules flags the price rules in effect at now, in the store's timezone
+func markCurrentPriceRules(q queryer, all []models.PriceRule, now time.Time) error {
+	location, err := util.StoreLocation(q)
+	if err != nil {
+		return err
+	}
+
+	local := now.In(location)
+	for i := range all {
+		all[i].IsCurrent = all[i].IsActive && priceRuleWindow(all[i]).Contains(local)
+	}
+	return nil
+}
+
+// currentPriceRules returns the price rules in effect at now
+func currentPriceRules(q queryer, now time.Time) (priceRules, error) {
+	all, err := loadPriceRules(q)
+	if err != nil {
+		return nil, err
+	}
+	if err := markCurrentPriceRules(q, all, now); err != nil {
+		return nil, err
+	}
+
+	current := priceRules{}
+	for _, rule := range all {
+		if rule.IsCurrent {
+			current = append(current, rule)
+		}
+	}
+	return current, nil
+}
+
+// setEffectivePrice sets what a product costs under rules
+func setEffectivePrice(product *models.Product, rules priceRules) {
+	price, rule := rules.price(product.ID, product.CategoryID, product.Price)
+	product.EffectivePrice = price
+	if rule != nil {
+		product.PriceRuleName = &rule.Name
+	}
+}
+
+// applyPriceRules sets the effective price of listed products
+func applyPriceRules(products []models.Product, rules priceRules) {
+	for i := range products {
+		setEffectivePrice(&products[i], rules)
+	}
+}
+
+// validatePriceAdjustment checks the adjustment of a price rule
+func validatePriceAdjustment(adjustmentType string, value float64) string {
+	switch adjustmentType {
+	case "percent_off":
+		if value < 0 || value > 100 {
+			return "Percent off must be between 0 and 100"
+		}
+	case "amount_off", "fixed_price":
+		if value < 0 {
+			return "Adjustment value cannot be negative"
+		}
+	default:
+		return "Adjustment type must be percent_off, amount_off or fixed_price"
+	}
+	return ""
+}
+
+// GetPriceRules returns the price rules, flagging the ones in effect now
+func (h *PriceRuleHandler) GetPriceRules(c *gin.Context) {
+	rules, err := loadPriceRules(h.db)
+	if err == nil {
+		err = markCurrentPriceRules(h.db, rules, time.Now())
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch price rules",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Price rules retrieved successfully",
+		Data:    rules,
+	})
+}
+
+// CreatePriceRule adds a price rule for a product, a category or every product
+func (h *PriceRuleHandler) CreatePriceRule(c *gin.Context) {
+	var req models.CreatePriceRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	message := ""
+	switch {
+	case name == "":
+		message = "Price rule name is required"
+	case req.CategoryID != nil && req.ProductID != nil:
+		message = "A price rule applies to a category or a product, not both"
+	default:
+		message = validatePriceAdjustment(req.AdjustmentType, req.AdjustmentValue)
+	}
+	if message == "" {
+		window := dayparts.Window{Days: req.DaysOfWeek, Start: req.StartTime, End: req.EndTime}
+		if err := window.Validate(); err != nil {
+			message = err.Error()
+		}
+	}
+	if message != "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   stringPtr("invalid_price_rule"),
+		})
+		return
+	}
+
+	if req.CategoryID != nil {
+		var exists bool
+		if err := h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM categories WHERE id = $1)", *req.CategoryID).Scan(&exists); err != nil || !exists {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Category not found",
+				Error:   stringPtr("category_not_found"),
+			})
+			return
+		}
+	}
+	if req.ProductID != nil {
+		var exists bool
+		if err := h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM products WHERE id = $1)", *req.ProductID).Scan(&exists); err != nil || !exists {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Product not found",
+				Error:   stringPtr("product_not_found"),
+			})
+			return
+		}
+	}
+
+	var ruleID uuid.UUID
+	err := h.db.QueryRow(`
+		INSERT INTO price_rules (name, days_of_week, start_time, end_time, category_id, product_id,
+		                         adjustment_type, adjustment_value, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`, name, pq.Array(daysOfWeek(req.DaysOfWeek)), req.StartTime, req.EndTime, req.CategoryID, req.ProductID,
+		req.AdjustmentType, req.AdjustmentValue, getBoolValue(req.IsActive, true)).Scan(&ruleID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to create price rule",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Price rule created successfully",
+		Data:    map[string]interface{}{"id": ruleID},
+	})
+}
+
+// UpdatePriceRule updates the name, window or adjustment of a price rule
+func (h *PriceRuleHandler) UpdatePriceRule(c *gin.Context) {
+	ruleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid price rule ID",
+			Error:   stringPtr("invalid_uuid"),
+		})
+		return
+	}
+
+	var req models.UpdatePriceRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	var rule models.PriceRule
+	err = h.db.QueryRow(`
+		SELECT name, days_of_week, to_char(start_time, 'HH24:MI'), to_char(end_time, 'HH24:MI'),
+		       adjustment_type, adjustment_value, is_active
+		FROM price_rules
+		WHERE id = $1
+	`, ruleID).Scan(&rule.Name, pq.Array(&rule.DaysOfWeek), &rule.StartTime, &rule.EndTime,
+		&rule.AdjustmentType, &rule.AdjustmentValue, &rule.IsActive)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Price rule not found",
+			Error:   stringPtr("price_rule_not_found"),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch price rule",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	window := priceRuleWindow(rule)
+	if req.DaysOfWeek != nil {
+		window.Days = daysOfWeek(req.DaysOfWeek)
+	}
+	if req.StartTime != nil {
+		window.Start = *req.StartTime
+	}
+	if req.EndTime != nil {
+		window.End = *req.EndTime
+	}
+	adjustmentType := getStringValue(req.AdjustmentType, rule.AdjustmentType)
+	adjustmentValue := rule.AdjustmentValue
+	if req.AdjustmentValue != nil {
+		adjustmentValue = *req.AdjustmentValue
+	}
+
+	message := validatePriceAdjustment(adjustmentType, adjustmentValue)
+	if message == "" {
+		if err := window.Validate(); err != nil {
+			message = err.Error()
+		}
+	}
+	if message != "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   stringPtr("invalid_price_rule"),
+		})
+		return
+	}
+
+	_, err = h.db.Exec(`
+		UPDATE price_rules
+		SET name = $2, days_of_week = $3, start_time = $4, end_time = $5, adjustment_type = $6,
+		    adjustment_value = $7, is_active = $8, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, ruleID, strings.TrimSpace(getStringValue(req.Name, rule.Name)), pq.Array(window.Days), window.Start, window.End,
+		adjustmentType, adjustmentValue, getBoolValue(req.IsActive, rule.IsActive))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to update price rule",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Price rule updated successfully",
+	})
+}
+
+// DeletePriceRule removes a price rule. Items already sold under it keep
+// their prices and the rule's name.
+func (h *PriceRuleHandler) DeletePriceRule(c *gin.Context) {
+	ruleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid price rule ID",
+			Error:   stringPtr("invalid_uuid"),
+		})
+		return
+	}
+
+	result, err := h.db.Exec("DELETE FROM price_rules WHERE id = $1", ruleID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to delete price rule",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Price rule not found",
+			Error:   stringPtr("price_rule_not_found"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Price rule deleted successfully",
+	})
+}
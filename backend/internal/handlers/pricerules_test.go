@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"testing"
+
+	"pos-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestAdjustPrice(t *testing.T) {
+	tests := []struct {
+		adjustmentType string
+		value          float64
+		want           float64
+	}{
+		{"percent_off", 25, 7.5},
+		{"percent_off", 33, 6.7},
+		{"percent_off", 100, 0},
+		{"amount_off", 2.5, 7.5},
+		{"amount_off", 15, 0},
+		{"fixed_price", 6, 6},
+		{"fixed_price", 12, 12},
+		{"unknown", 5, 10},
+	}
+
+	for _, tt := range tests {
+		if got := adjustPrice(10, tt.adjustmentType, tt.value); got != tt.want {
+			t.Errorf("adjustPrice(10, %s, %v) = %v, want %v", tt.adjustmentType, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestPriceRulesPrice(t *testing.T) {
+	product, otherProduct := uuid.New(), uuid.New()
+	category, otherCategory := uuid.New(), uuid.New()
+
+	global := models.PriceRule{Name: "Happy hour", AdjustmentType: "percent_off", AdjustmentValue: 50}
+	categoryRule := models.PriceRule{Name: "Draft beer", CategoryID: &category, AdjustmentType: "amount_off", AdjustmentValue: 1}
+	deeperCategory := models.PriceRule{Name: "Beer night", CategoryID: &category, AdjustmentType: "fixed_price", AdjustmentValue: 6}
+	productRule := models.PriceRule{Name: "House lager", ProductID: &product, AdjustmentType: "fixed_price", AdjustmentValue: 9}
+	otherProductRule := models.PriceRule{Name: "Cider", ProductID: &otherProduct, AdjustmentType: "fixed_price", AdjustmentValue: 1}
+	otherCategoryRule := models.PriceRule{Name: "Wine", CategoryID: &otherCategory, AdjustmentType: "fixed_price", AdjustmentValue: 1}
+
+	tests := []struct {
+		name      string
+		rules     priceRules
+		category  *uuid.UUID
+		wantPrice float64
+		wantRule  string
+	}{
+		{"no rules", nil, &category, 10, ""},
+		{"global rule", priceRules{global}, nil, 5, "Happy hour"},
+		{"category beats global", priceRules{global, categoryRule}, &category, 9, "Draft beer"},
+		{"product beats category", priceRules{deeperCategory, productRule}, &category, 9, "House lager"},
+		{"lowest price within a rank", priceRules{categoryRule, deeperCategory}, &category, 6, "Beer night"},
+		{"rules for other items are ignored", priceRules{otherProductRule, otherCategoryRule}, &category, 10, ""},
+		{"category rule needs a category", priceRules{categoryRule}, nil, 10, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price, rule := tt.rules.price(product, tt.category, 10)
+			ruleName := ""
+			if rule != nil {
+				ruleName = rule.Name
+			}
+			if price != tt.wantPrice || ruleName != tt.wantRule {
+				t.Errorf("price = %v (%q), want %v (%q)", price, ruleName, tt.wantPrice, tt.wantRule)
+			}
+		})
+	}
+}
+
+func TestValidatePriceAdjustment(t *testing.T) {
+	tests := []struct {
+		adjustmentType string
+		value          float64
+		valid          bool
+	}{
+		{"percent_off", 0, true},
+		{"percent_off", 100, true},
+		{"percent_off", 101, false},
+		{"amount_off", 3, true},
+		{"amount_off", -1, false},
+		{"fixed_price", 0, true},
+		{"markup", 5, false},
+	}
+
+	for _, tt := range tests {
+		message := validatePriceAdjustment(tt.adjustmentType, tt.value)
+		if (message == "") != tt.valid {
+			t.Errorf("validatePriceAdjustment(%s, %v) = %q, want valid %v", tt.adjustmentType, tt.value, message, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,894 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"pos-backend/internal/middleware"
+	"pos-backend/internal/models"
+	"pos-backend/internal/printing"
+	"pos-backend/internal/receipt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var validPrinterRoles = map[string]bool{
+	"receipt": true,
+	"kitchen": true,
+	"bar":     true,
+}
+
+type PrinterHandler struct {
+	db     *sql.DB
+	orders *OrderHandler
+}
+
+func NewPrinterHandler(db *sql.DB) *PrinterHandler {
+	return &PrinterHandler{db: db, orders: NewOrderHandler(db)}
+}
+
+// GetPrinters returns all configured printers with their category routing
+func (h *PrinterHandler) GetPrinters(c *gin.Context) {
+	printers, err := loadPrinters(h.db, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch printers",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Printers retrieved successfully",
+		Data:    printers,
+	})
+}
+
+// CreatePrinter registers a network printer
+func (h *PrinterHandler) CreatePrinter(c *gin.Context) {
+	var req models.CreatePrinterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	port := printing.DefaultPort
+	if req.Port != nil {
+		port = *req.Port
+	}
+
+	if message := validatePrinter(req.Role, port); message != "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   stringPtr("invalid_printer"),
+		})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to start transaction",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	var printerID uuid.UUID
+	err = tx.QueryRow(`
+		INSERT INTO printers (name, host, port, role, is_active)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, req.Name, req.Host, port, req.Role, getBoolValue(req.IsActive, true)).Scan(&printerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to create printer",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	if err := setPrinterCategories(tx, printerID, req.CategoryIDs); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to save printer routing",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to commit transaction",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Printer created successfully",
+		Data:    map[string]interface{}{"id": printerID},
+	})
+}
+
+// UpdatePrinter updates a printer's connection, role or routing
+func (h *PrinterHandler) UpdatePrinter(c *gin.Context) {
+	printerID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid printer ID",
+			Error:   stringPtr("invalid_uuid"),
+		})
+		return
+	}
+
+	var req models.UpdatePrinterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	role, port := "receipt", printing.DefaultPort
+	if req.Role != nil {
+		role = *req.Role
+	}
+	if req.Port != nil {
+		port = *req.Port
+	}
+	if message := validatePrinter(role, port); message != "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   stringPtr("invalid_printer"),
+		})
+		return
+	}
+
+	// Build dynamic update query
+	updates := []string{}
+	args := []interface{}{}
+	argCount := 1
+
+	if req.Name != nil {
+		updates = append(updates, fmt.Sprintf("name = $%d", argCount))
+		args = append(args, *req.Name)
+		argCount++
+	}
+	if req.Host != nil {
+		updates = append(updates, fmt.Sprintf("host = $%d", argCount))
+		args = append(args, *req.Host)
+		argCount++
+	}
+	if req.Port != nil {
+		updates = append(updates, fmt.Sprintf("port = $%d", argCount))
+		args = append(args, *req.Port)
+		argCount++
+	}
+	if req.Role != nil {
+		updates = append(updates, fmt.Sprintf("role = $%d", argCount))
+		args = append(args, *req.Role)
+		argCount++
+	}
+	if req.IsActive != nil {
+		updates = append(updates, fmt.Sprintf("is_active = $%d", argCount))
+		args = append(args, *req.IsActive)
+		argCount++
+	}
+
+	updates = append(updates, "updated_at = CURRENT_TIMESTAMP")
+	args = append(args, printerID)
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to start transaction",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf("UPDATE printers SET %s WHERE id = $%d", strings.Join(updates, ", "), argCount)
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to update printer",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Printer not found",
+			Error:   stringPtr("printer_not_found"),
+		})
+		return
+	}
+
+	if req.CategoryIDs != nil {
+		if err := setPrinterCategories(tx, printerID, *req.CategoryIDs); err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to save printer routing",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to commit transaction",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Printer updated successfully",
+	})
+}
+
+// DeletePrinter removes a printer and fails the jobs still queued for it
+func (h *PrinterHandler) DeletePrinter(c *gin.Context) {
+	printerID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid printer ID",
+			Error:   stringPtr("invalid_uuid"),
+		})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to start transaction",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM printers WHERE id = $1", printerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to delete printer",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Printer not found",
+			Error:   stringPtr("printer_not_found"),
+		})
+		return
+	}
+
+	if _, err := tx.Exec("DELETE FROM printer_categories WHERE printer_id = $1", printerID); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to delete printer routing",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	_, err = tx.Exec(`
+		UPDATE print_jobs
+		SET status = 'failed', last_error = 'printer deleted', updated_at = CURRENT_TIMESTAMP
+		WHERE printer_id = $1 AND status = 'pending'
+	`, printerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to cancel queued print jobs",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to commit transaction",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Printer deleted successfully",
+	})
+}
+
+// TestPrinter queues a test page for a printer
+func (h *PrinterHandler) TestPrinter(c *gin.Context) {
+	printerID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid printer ID",
+			Error:   stringPtr("invalid_uuid"),
+		})
+		return
+	}
+
+	var printer models.Printer
+	err = h.db.QueryRow("SELECT name, host, port, role FROM printers WHERE id = $1", printerID).Scan(
+		&printer.Name, &printer.Host, &printer.Port, &printer.Role,
+	)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Printer not found",
+			Error:   stringPtr("printer_not_found"),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch printer",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	settings, err := loadSettings(h.db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch restaurant settings",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	doc := &receipt.Document{
+		Title:  "TEST PAGE",
+		Header: []string{settings.Name},
+		Meta: []receipt.Field{
+			{Label: "Printer", Value: printer.Name, Bold: true},
+			{Label: "Address", Value: printer.Host + ":" + strconv.Itoa(printer.Port)},
+			{Label: "Role", Value: printer.Role},
+			{Label: "Time", Value: time.Now().Format("2006-01-02 15:04:05")},
+		},
+	}
+
+	userID, _, _, _ := middleware.GetUserFromContext(c)
+
+	var jobID uuid.UUID
+	err = h.db.QueryRow(`
+		INSERT INTO print_jobs (printer_id, kind, payload, created_by)
+		VALUES ($1, 'test', $2, $3)
+		RETURNING id
+	`, printerID, receipt.RenderESCPOS(doc, receipt.DefaultWidth), userID).Scan(&jobID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to queue test page",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, models.APIResponse{
+		Success: true,
+		Message: "Test page queued",
+		Data:    map[string]interface{}{"job_id": jobID},
+	})
+}
+
+// PrintOrder queues an order's receipt or kitchen tickets on the matching printers
+func (h *PrinterHandler) PrintOrder(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid order ID",
+			Error:   stringPtr("invalid_uuid"),
+		})
+		return
+	}
+
+	var req models.PrintOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	if req.Kind != "receipt" && req.Kind != "kitchen" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid kind. Must be one of: receipt, kitchen",
+			Error:   stringPtr("invalid_kind"),
+		})
+		return
+	}
+
+	userID, _, _, _ := middleware.GetUserFromContext(c)
+
+	jobIDs, err := enqueueOrderPrint(h.db, h.orders, orderID, req.Kind, &userID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Order not found",
+			Error:   stringPtr("order_not_found"),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to queue print jobs",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	if len(jobIDs) == 0 {
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: "No active printer is configured for this document",
+			Error:   stringPtr("no_printer"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, models.APIResponse{
+		Success: true,
+		Message: "Print jobs queued",
+		Data:    map[string]interface{}{"job_ids": jobIDs},
+	})
+}
+
+// GetPrintJobs lists print jobs, newest first
+func (h *PrinterHandler) GetPrintJobs(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	query := `
+		SELECT j.id, j.printer_id, p.name, j.order_id, j.kind, j.status, j.attempts, j.max_attempts,
+		       j.last_error, j.next_attempt_at, j.created_by, j.printed_at, j.created_at, j.updated_at
+		FROM print_jobs j
+		JOIN printers p ON j.printer_id = p.id
+		WHERE 1=1
+	`
+	args := []interface{}{}
+	argCount := 1
+
+	if status := c.Query("status"); status != "" {
+		query += fmt.Sprintf(" AND j.status = $%d", argCount)
+		args = append(args, status)
+		argCount++
+	}
+	if orderID := c.Query("order_id"); orderID != "" {
+		query += fmt.Sprintf(" AND j.order_id = $%d", argCount)
+		args = append(args, orderID)
+		argCount++
+	}
+	if printerID := c.Query("printer_id"); printerID != "" {
+		query += fmt.Sprintf(" AND j.printer_id = $%d", argCount)
+		args = append(args, printerID)
+		argCount++
+	}
+
+	query += fmt.Sprintf(" ORDER BY j.created_at DESC LIMIT $%d", argCount)
+	args = append(args, limit)
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch print jobs",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	defer rows.Close()
+
+	var jobs []models.PrintJob
+	for rows.Next() {
+		job, err := scanPrintJob(rows)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to scan print job",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+		jobs = append(jobs, *job)
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Print jobs retrieved successfully",
+		Data:    jobs,
+	})
+}
+
+// GetPrintJob returns the status of a single print job
+func (h *PrinterHandler) GetPrintJob(c *gin.Context) {
+	jobID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid print job ID",
+			Error:   stringPtr("invalid_uuid"),
+		})
+		return
+	}
+
+	job, err := scanPrintJob(h.db.QueryRow(`
+		SELECT j.id, j.printer_id, p.name, j.order_id, j.kind, j.status, j.attempts, j.max_attempts,
+		       j.last_error, j.next_attempt_at, j.created_by, j.printed_at, j.created_at, j.updated_at
+		FROM print_jobs j
+		JOIN printers p ON j.printer_id = p.id
+		WHERE j.id = $1
+	`, jobID))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Print job not found",
+			Error:   stringPtr("print_job_not_found"),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch print job",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Print job retrieved successfully",
+		Data:    job,
+	})
+}
+
+// RetryPrintJob puts a failed job back in the queue with a fresh set of attempts
+func (h *PrinterHandler) RetryPrintJob(c *gin.Context) {
+	jobID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid print job ID",
+			Error:   stringPtr("invalid_uuid"),
+		})
+		return
+	}
+
+	result, err := h.db.Exec(`
+		UPDATE print_jobs
+		SET status = 'pending', attempts = 0, next_attempt_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'failed'
+	`, jobID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to retry print job",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Only failed print jobs can be retried",
+			Error:   stringPtr("invalid_print_job_status"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Print job queued for retry",
+	})
+}
+
+// Helper functions
+
+func validatePrinter(role string, port int) string {
+	if !validPrinterRoles[role] {
+		return "Invalid role. Must be one of: receipt, kitchen, bar"
+	}
+	if port < 1 || port > 65535 {
+		return "Port must be between 1 and 65535"
+	}
+	return ""
+}
+
+func setPrinterCategories(tx *sql.Tx, printerID uuid.UUID, categoryIDs []uuid.UUID) error {
+	if _, err := tx.Exec("DELETE FROM printer_categories WHERE printer_id = $1", printerID); err != nil {
+		return err
+	}
+	for _, categoryID := range categoryIDs {
+		_, err := tx.Exec(`
+			INSERT INTO printer_categories (printer_id, category_id)
+			VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+		`, printerID, categoryID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadPrinters returns printers with their routed categories
+func loadPrinters(q queryer, activeOnly bool) ([]models.Printer, error) {
+	query := `
+		SELECT id, name, host, port, role, is_active, created_at, updated_at
+		FROM printers
+	`
+	if activeOnly {
+		query += " WHERE is_active = true"
+	}
+	query += " ORDER BY role, name"
+
+	rows, err := q.Query(query)
+	if err != nil {
+		return nil, err
+	}
+
+	printers := []models.Printer{}
+	index := map[uuid.UUID]int{}
+	for rows.Next() {
+		var printer models.Printer
+		err := rows.Scan(&printer.ID, &printer.Name, &printer.Host, &printer.Port, &printer.Role,
+			&printer.IsActive, &printer.CreatedAt, &printer.UpdatedAt)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		printer.CategoryIDs = []uuid.UUID{}
+		index[printer.ID] = len(printers)
+		printers = append(printers, printer)
+	}
+	rows.Close()
+
+	rows, err = q.Query("SELECT printer_id, category_id FROM printer_categories")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var printerID, categoryID uuid.UUID
+		if err := rows.Scan(&printerID, &categoryID); err != nil {
+			return nil, err
+		}
+		if i, ok := index[printerID]; ok {
+			printers[i].CategoryIDs = append(printers[i].CategoryIDs, categoryID)
+		}
+	}
+
+	return printers, nil
+}
+
+func scanPrintJob(row rowScanner) (*models.PrintJob, error) {
+	var job models.PrintJob
+	err := row.Scan(
+		&job.ID, &job.PrinterID, &job.PrinterName, &job.OrderID, &job.Kind, &job.Status,
+		&job.Attempts, &job.MaxAttempts, &job.LastError, &job.NextAttemptAt,
+		&job.CreatedBy, &job.PrintedAt, &job.CreatedAt, &job.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// enqueueOrderPrint renders an order for every printer it is routed to and queues
+// the ESC/POS bytes. Receipts go to every receipt printer. Kitchen items go to the
+// kitchen and bar printers routed to their category; items in unrouted categories
+// go to the kitchen printers that have no routing.
+func enqueueOrderPrint(db *sql.DB, orders *OrderHandler, orderID uuid.UUID, kind string, requestedBy *uuid.UUID) ([]uuid.UUID, error) {
+	order, err := orders.getOrderByID(orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := loadSettings(db)
+	if err != nil {
+		return nil, err
+	}
+
+	printers, err := loadPrinters(db, true)
+	if err != nil {
+		return nil, err
+	}
+
+	type printout struct {
+		printerID uuid.UUID
+		doc       *receipt.Document
+	}
+	var printouts []printout
+
+	if kind == "receipt" {
+		for _, printer := range printers {
+			if printer.Role == "receipt" {
+				printouts = append(printouts, printout{printer.ID, nil})
+			}
+		}
+	} else {
+		itemCategories, err := orderItemCategories(db, orderID)
+		if err != nil {
+			return nil, err
+		}
+
+		routed := map[uuid.UUID]bool{}
+		for _, printer := range printers {
+			if printer.Role != "receipt" {
+				for _, categoryID := range printer.CategoryIDs {
+					routed[categoryID] = true
+				}
+			}
+		}
+
+		for _, printer := range printers {
+			if printer.Role == "receipt" {
+				continue
+			}
+			if len(printer.CategoryIDs) == 0 && printer.Role != "kitchen" {
+				continue
+			}
+
+			categories := map[uuid.UUID]bool{}
+			for _, categoryID := range printer.CategoryIDs {
+				categories[categoryID] = true
+			}
+
+			ticket := *order
+			ticket.Items = nil
+			for _, item := range order.Items {
+				categoryID, hasCategory := itemCategories[item.ID]
+				if len(printer.CategoryIDs) == 0 {
+					if !hasCategory || !routed[categoryID] {
+						ticket.Items = append(ticket.Items, item)
+					}
+				} else if hasCategory && categories[categoryID] {
+					ticket.Items = append(ticket.Items, item)
+				}
+			}
+
+			doc := receipt.NewKitchenTicket(&ticket, settings, false)
+			if len(doc.Items) == 0 {
+				continue
+			}
+			doc.Title = strings.ToUpper(printer.Role)
+			printouts = append(printouts, printout{printer.ID, doc})
+		}
+	}
+
+	if len(printouts) == 0 {
+		return nil, nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	reprint, err := recordPrint(tx, orderID, kind, "escpos", requestedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	var jobIDs []uuid.UUID
+	for _, p := range printouts {
+		doc := p.doc
+		if doc == nil {
+			doc = receipt.NewReceipt(order, settings, reprint)
+		}
+		doc.Copy = reprint
+
+		var jobID uuid.UUID
+		err := tx.QueryRow(`
+			INSERT INTO print_jobs (printer_id, order_id, kind, payload, created_by)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id
+		`, p.printerID, orderID, kind, receipt.RenderESCPOS(doc, receipt.DefaultWidth), requestedBy).Scan(&jobID)
+		if err != nil {
+			return nil, err
+		}
+		jobIDs = append(jobIDs, jobID)
+	}
+
+	return jobIDs, tx.Commit()
+}
+
+// orderItemCategories maps each product line of an order to its category
+func orderItemCategories(db *sql.DB, orderID uuid.UUID) (map[uuid.UUID]uuid.UUID, error) {
+	rows, err := db.Query(`
+		SELECT oi.id, p.category_id
+		FROM order_items oi
+		JOIN products p ON oi.product_id = p.id
+		WHERE oi.order_id = $1 AND p.category_id IS NOT NULL
+	`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := map[uuid.UUID]uuid.UUID{}
+	for rows.Next() {
+		var itemID, categoryID uuid.UUID
+		if err := rows.Scan(&itemID, &categoryID); err != nil {
+			return nil, err
+		}
+		categories[itemID] = categoryID
+	}
+	return categories, nil
+}
+
+// autoPrint queues an order document when the matching auto print setting is on.
+// Printing problems are logged and never fail the request that triggered them.
+func autoPrint(db *sql.DB, orderID uuid.UUID, kind string, requestedBy *uuid.UUID) {
+	settings, err := loadSettings(db)
+	if err != nil {
+		log.Printf("auto print: failed to load settings: %v", err)
+		return
+	}
+
+	if (kind == "receipt" && !settings.AutoPrintReceipts) || (kind == "kitchen" && !settings.AutoPrintKitchen) {
+		return
+	}
+
+	if _, err := enqueueOrderPrint(db, NewOrderHandler(db), orderID, kind, requestedBy); err != nil {
+		log.Printf("auto print: failed to queue %s for order %s: %v", kind, orderID, err)
+	}
+}
@@ -4,11 +4,15 @@ import (
 	"database/sql"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"pos-backend/internal/allergens"
 	"pos-backend/internal/models"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type ProductHandler struct {
@@ -45,11 +49,14 @@ func (h *ProductHandler) GetProducts(c *gin.Context) {
 	// Build query with filters
 	queryBuilder := `
 		SELECT p.id, p.category_id, p.name, p.description, p.price, p.image_url, 
-		       p.barcode, p.sku, p.is_available, p.preparation_time, p.sort_order,
+		       p.barcode, p.sku, p.is_available, p.preparation_time, p.sort_order, p.station_id,
 		       p.created_at, p.updated_at,
-		       c.name as category_name, c.color as category_color
+		       c.name as category_name, c.color as category_color,
+		       ` + eightySixedCondition + ` as is_86d,
+		       e86.remaining_count, p.allergens, p.dietary_tags
 		FROM products p
 		LEFT JOIN categories c ON p.category_id = c.id
+		` + eightySixJoin + `
 		WHERE 1=1
 	`
 
@@ -65,7 +72,7 @@ func (h *ProductHandler) GetProducts(c *gin.Context) {
 	}
 
 	if available == "true" {
-		queryBuilder += ` AND p.is_available = true`
+		queryBuilder += ` AND p.is_available = true AND NOT ` + eightySixedCondition
 	} else if available == "false" {
 		queryBuilder += ` AND p.is_available = false`
 	}
@@ -76,6 +83,54 @@ func (h *ProductHandler) GetProducts(c *gin.Context) {
 		args = append(args, "%"+search+"%")
 	}
 
+	// Comma-separated allergens the product must not contain
+	if allergenFree := c.Query("allergen_free"); allergenFree != "" {
+		excluded, err := allergens.Normalize(strings.Split(allergenFree, ","), allergens.Allergens, "allergen")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: err.Error(),
+				Error:   stringPtr("invalid_allergen"),
+			})
+			return
+		}
+		argIndex++
+		queryBuilder += ` AND NOT (p.allergens && $` + strconv.Itoa(argIndex) + `::text[])`
+		args = append(args, pq.Array(excluded))
+	}
+
+	// Comma-separated dietary tags the product must have
+	if dietary := c.Query("dietary"); dietary != "" {
+		tags, err := allergens.Normalize(strings.Split(dietary, ","), allergens.DietaryTags, "dietary tag")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: err.Error(),
+				Error:   stringPtr("invalid_dietary_tag"),
+			})
+			return
+		}
+		argIndex++
+		queryBuilder += ` AND p.dietary_tags @> $` + strconv.Itoa(argIndex) + `::text[]`
+		args = append(args, pq.Array(tags))
+	}
+
+	// Only what is on the menu in the current dayparts, unless a manager overrides it
+	if !daypartOverride(c) {
+		current, err := currentDayparts(h.db, time.Now())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to fetch dayparts",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+		argIndex++
+		queryBuilder += ` AND ` + daypartProductCondition("$"+strconv.Itoa(argIndex))
+		args = append(args, pq.Array(current))
+	}
+
 	// Count total records
 	countQuery := "SELECT COUNT(*) FROM (" + queryBuilder + ") as count_query"
 	var total int
@@ -117,9 +172,11 @@ func (h *ProductHandler) GetProducts(c *gin.Context) {
 		err := rows.Scan(
 			&product.ID, &product.CategoryID, &product.Name, &product.Description,
 			&product.Price, &product.ImageURL, &product.Barcode, &product.SKU,
-			&product.IsAvailable, &product.PreparationTime, &product.SortOrder,
+			&product.IsAvailable, &product.PreparationTime, &product.SortOrder, &product.StationID,
 			&product.CreatedAt, &product.UpdatedAt,
 			&categoryName, &categoryColor,
+			&product.EightySixed, &product.RemainingCount,
+			pq.Array(&product.Allergens), pq.Array(&product.DietaryTags),
 		)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.APIResponse{
@@ -142,6 +199,18 @@ func (h *ProductHandler) GetProducts(c *gin.Context) {
 		products = append(products, product)
 	}
 
+	// Show what the products cost under the price rules in effect now
+	rules, err := currentPriceRules(h.db, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch price rules",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	applyPriceRules(products, rules)
+
 	totalPages := (total + perPage - 1) / perPage
 
 	c.JSON(http.StatusOK, models.PaginatedResponse{
@@ -157,6 +226,13 @@ func (h *ProductHandler) GetProducts(c *gin.Context) {
 	})
 }
 
+// GetAllProducts lists products for menu management, including those outside
+// the current dayparts
+func (h *ProductHandler) GetAllProducts(c *gin.Context) {
+	c.Set(allDaypartsKey, true)
+	h.GetProducts(c)
+}
+
 // GetProduct retrieves a specific product by ID
 func (h *ProductHandler) GetProduct(c *gin.Context) {
 	productID, err := uuid.Parse(c.Param("id"))
@@ -174,20 +250,25 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 
 	query := `
 		SELECT p.id, p.category_id, p.name, p.description, p.price, p.image_url, 
-		       p.barcode, p.sku, p.is_available, p.preparation_time, p.sort_order,
+		       p.barcode, p.sku, p.is_available, p.preparation_time, p.sort_order, p.station_id,
 		       p.created_at, p.updated_at,
-		       c.name as category_name, c.color as category_color
+		       c.name as category_name, c.color as category_color,
+		       ` + eightySixedCondition + ` as is_86d,
+		       e86.remaining_count, p.allergens, p.dietary_tags
 		FROM products p
 		LEFT JOIN categories c ON p.category_id = c.id
+		` + eightySixJoin + `
 		WHERE p.id = $1
 	`
 
 	err = h.db.QueryRow(query, productID).Scan(
 		&product.ID, &product.CategoryID, &product.Name, &product.Description,
 		&product.Price, &product.ImageURL, &product.Barcode, &product.SKU,
-		&product.IsAvailable, &product.PreparationTime, &product.SortOrder,
+		&product.IsAvailable, &product.PreparationTime, &product.SortOrder, &product.StationID,
 		&product.CreatedAt, &product.UpdatedAt,
 		&categoryName, &categoryColor,
+		&product.EightySixed, &product.RemainingCount,
+		pq.Array(&product.Allergens), pq.Array(&product.DietaryTags),
 	)
 
 	if err == sql.ErrNoRows {
@@ -217,6 +298,29 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 		}
 	}
 
+	// Show what the product costs under the price rules in effect now
+	rules, err := currentPriceRules(h.db, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch price rules",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	setEffectivePrice(&product, rules)
+
+	// Add the modifiers that can be ordered with it
+	product.Modifiers, err = loadModifiers(h.db, &product.ID, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch product modifiers",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Message: "Product retrieved successfully",
@@ -229,17 +333,34 @@ func (h *ProductHandler) GetCategories(c *gin.Context) {
 	activeOnly := c.Query("active_only") == "true"
 	
 	query := `
-		SELECT id, name, description, color, sort_order, is_active, created_at, updated_at
+		SELECT id, name, description, color, sort_order, station_id, is_active, created_at, updated_at
 		FROM categories
+		WHERE 1=1
 	`
+	var args []interface{}
 	
 	if activeOnly {
-		query += ` WHERE is_active = true`
+		query += ` AND is_active = true`
+	}
+
+	// Only categories on the menu in the current dayparts, unless a manager overrides it
+	if !daypartOverride(c) {
+		current, err := currentDayparts(h.db, time.Now())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to fetch dayparts",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+		query += ` AND ` + daypartCategoryCondition("categories.id", "$1")
+		args = append(args, pq.Array(current))
 	}
 	
 	query += ` ORDER BY sort_order ASC, name ASC`
 
-	rows, err := h.db.Query(query)
+	rows, err := h.db.Query(query, args...)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
@@ -256,7 +377,7 @@ func (h *ProductHandler) GetCategories(c *gin.Context) {
 
 		err := rows.Scan(
 			&category.ID, &category.Name, &category.Description, &category.Color,
-			&category.SortOrder, &category.IsActive, &category.CreatedAt, &category.UpdatedAt,
+			&category.SortOrder, &category.StationID, &category.IsActive, &category.CreatedAt, &category.UpdatedAt,
 		)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.APIResponse{
@@ -293,21 +414,39 @@ func (h *ProductHandler) GetProductsByCategory(c *gin.Context) {
 
 	query := `
 		SELECT p.id, p.category_id, p.name, p.description, p.price, p.image_url, 
-		       p.barcode, p.sku, p.is_available, p.preparation_time, p.sort_order,
+		       p.barcode, p.sku, p.is_available, p.preparation_time, p.sort_order, p.station_id,
 		       p.created_at, p.updated_at,
-		       c.name as category_name, c.color as category_color
+		       c.name as category_name, c.color as category_color,
+		       ` + eightySixedCondition + ` as is_86d,
+		       e86.remaining_count, p.allergens, p.dietary_tags
 		FROM products p
 		JOIN categories c ON p.category_id = c.id
+		` + eightySixJoin + `
 		WHERE p.category_id = $1
 	`
 
 	if availableOnly {
-		query += ` AND p.is_available = true`
+		query += ` AND p.is_available = true AND NOT ` + eightySixedCondition
+	}
+
+	args := []interface{}{categoryID}
+	if !daypartOverride(c) {
+		current, err := currentDayparts(h.db, time.Now())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to fetch dayparts",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+		query += ` AND ` + daypartProductCondition("$2")
+		args = append(args, pq.Array(current))
 	}
 
 	query += ` ORDER BY p.sort_order ASC, p.name ASC`
 
-	rows, err := h.db.Query(query, categoryID)
+	rows, err := h.db.Query(query, args...)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
@@ -326,9 +465,11 @@ func (h *ProductHandler) GetProductsByCategory(c *gin.Context) {
 		err := rows.Scan(
 			&product.ID, &product.CategoryID, &product.Name, &product.Description,
 			&product.Price, &product.ImageURL, &product.Barcode, &product.SKU,
-			&product.IsAvailable, &product.PreparationTime, &product.SortOrder,
+			&product.IsAvailable, &product.PreparationTime, &product.SortOrder, &product.StationID,
 			&product.CreatedAt, &product.UpdatedAt,
 			&categoryName, &categoryColor,
+			&product.EightySixed, &product.RemainingCount,
+			pq.Array(&product.Allergens), pq.Array(&product.DietaryTags),
 		)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.APIResponse{
@@ -351,6 +492,18 @@ func (h *ProductHandler) GetProductsByCategory(c *gin.Context) {
 		products = append(products, product)
 	}
 
+	// Show what the products cost under the price rules in effect now
+	rules, err := currentPriceRules(h.db, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch price rules",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	applyPriceRules(products, rules)
+
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Message: "Products retrieved successfully",
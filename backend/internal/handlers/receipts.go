@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+
+	"pos-backend/internal/models"
+	"pos-backend/internal/receipt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Content types returned for each receipt format
+var receiptContentTypes = map[string]string{
+	"text":   "text/plain; charset=utf-8",
+	"escpos": "application/octet-stream",
+	"html":   "text/html; charset=utf-8",
+}
+
+type ReceiptHandler struct {
+	db     *sql.DB
+	orders *OrderHandler
+}
+
+func NewReceiptHandler(db *sql.DB) *ReceiptHandler {
+	return &ReceiptHandler{db: db, orders: NewOrderHandler(db)}
+}
+
+// GetOrderReceipt renders the customer receipt of an order
+func (h *ReceiptHandler) GetOrderReceipt(c *gin.Context) {
+	h.render(c, "receipt")
+}
+
+// GetKitchenTicket renders the kitchen ticket of an order
+func (h *ReceiptHandler) GetKitchenTicket(c *gin.Context) {
+	h.render(c, "kitchen")
+}
+
+func (h *ReceiptHandler) render(c *gin.Context, kind string) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid order ID",
+			Error:   stringPtr("invalid_uuid"),
+		})
+		return
+	}
+
+	format := c.DefaultQuery("format", "text")
+	contentType, ok := receiptContentTypes[format]
+	if !ok {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid format. Must be one of: text, escpos, html",
+			Error:   stringPtr("invalid_format"),
+		})
+		return
+	}
+
+	order, err := h.orders.getOrderByID(orderID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Order not found",
+			Error:   stringPtr("order_not_found"),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch order",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	settings, err := loadSettings(h.db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch restaurant settings",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	// Viewing a document is not a print; it is marked as a copy once one was printed
+	reprint, err := printedBefore(h.db, orderID, kind)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to check receipt prints",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	doc := buildDocument(order, settings, kind, reprint)
+
+	var body []byte
+	switch format {
+	case "escpos":
+		body = receipt.RenderESCPOS(doc, receipt.DefaultWidth)
+	case "html":
+		body, err = receipt.RenderHTML(doc, settings.LogoURL)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to render receipt",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+	default:
+		body = receipt.RenderText(doc, receipt.DefaultWidth)
+	}
+
+	c.Data(http.StatusOK, contentType, body)
+}
+
+// Helper functions
+
+// buildDocument lays out a receipt or kitchen ticket for an order
+func buildDocument(order *models.Order, settings *models.Settings, kind string, reprint bool) *receipt.Document {
+	if kind == "kitchen" {
+		return receipt.NewKitchenTicket(order, settings, reprint)
+	}
+	return receipt.NewReceipt(order, settings, reprint)
+}
+
+// printedBefore reports whether the order already had a document of this kind printed
+func printedBefore(q queryer, orderID uuid.UUID, kind string) (bool, error) {
+	var printed bool
+	err := q.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM receipt_prints WHERE order_id = $1 AND kind = $2)
+	`, orderID, kind).Scan(&printed)
+	return printed, err
+}
+
+// recordPrint logs a printed document and reports whether the order already had one of this kind
+func recordPrint(tx *sql.Tx, orderID uuid.UUID, kind, format string, printedBy *uuid.UUID) (bool, error) {
+	reprint, err := printedBefore(tx, orderID, kind)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO receipt_prints (order_id, kind, format, printed_by)
+		VALUES ($1, $2, $3, $4)
+	`, orderID, kind, format, printedBy)
+	if err != nil {
+		return false, err
+	}
+
+	return reprint, nil
+}
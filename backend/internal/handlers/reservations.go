@@ -0,0 +1,1122 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"pos-backend/internal/middleware"
+	"pos-backend/internal/models"
+	"pos-backend/internal/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultReservationMinutes is how long a table is booked when no duration is given
+	defaultReservationMinutes = 90
+	// alternativeSlotMinutes is the step between suggested times when the requested one is full
+	alternativeSlotMinutes = 15
+	// alternativeSlotRange is how far, in minutes, suggested times reach either side of the requested one
+	alternativeSlotRange = 120
+)
+
+var (
+	errTableTooSmall    = errors.New("table is too small for the party")
+	errTableUnavailable = errors.New("table is already booked at that time")
+	errNoTableAvailable = errors.New("no table is available for the party at that time")
+	errTableGrouped     = errors.New("table is part of a table group")
+	errTableOutOfOrder  = errors.New("table is out of service")
+)
+
+type ReservationHandler struct {
+	db *sql.DB
+}
+
+func NewReservationHandler(db *sql.DB) *ReservationHandler {
+	return &ReservationHandler{db: db}
+}
+
+// reservationQuery selects reservations with their table number for scanReservation
+const reservationQuery = `
+	SELECT r.id, r.guest_name, r.guest_phone, r.party_size, r.reserved_at, r.duration_minutes,
+	       r.table_id, t.table_number, r.status, r.notes, r.order_id, r.created_by,
+	       r.seated_at, r.cancelled_at, r.created_at, r.updated_at
+	FROM reservations r
+	LEFT JOIN dining_tables t ON r.table_id = t.id
+`
+
+func scanReservation(row rowScanner) (*models.Reservation, error) {
+	var reservation models.Reservation
+	err := row.Scan(
+		&reservation.ID, &reservation.GuestName, &reservation.GuestPhone, &reservation.PartySize,
+		&reservation.ReservedAt, &reservation.DurationMinutes, &reservation.TableID, &reservation.TableNumber,
+		&reservation.Status, &reservation.Notes, &reservation.OrderID, &reservation.CreatedBy,
+		&reservation.SeatedAt, &reservation.CancelledAt, &reservation.CreatedAt, &reservation.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// GetDayView returns the host's view of a day (?date=YYYY-MM-DD, default today):
+// every reservation in time order, the bookings of each table and totals
+func (h *ReservationHandler) GetDayView(c *gin.Context) {
+	location, err := util.StoreLocation(h.db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to load settings",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	day := time.Now().In(location)
+	if value := c.Query("date"); value != "" {
+		day, err = time.ParseInLocation("2006-01-02", value, location)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid date. Use YYYY-MM-DD",
+				Error:   stringPtr("invalid_date"),
+			})
+			return
+		}
+	}
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, location)
+	end := start.AddDate(0, 0, 1)
+
+	rows, err := h.db.Query(reservationQuery+`
+		WHERE r.reserved_at >= $1 AND r.reserved_at < $2
+		ORDER BY r.reserved_at, r.guest_name
+	`, start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch reservations",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	defer rows.Close()
+
+	reservations := []models.Reservation{}
+	byStatus := map[string]int{"booked": 0, "seated": 0, "no_show": 0, "cancelled": 0}
+	covers := 0
+	for rows.Next() {
+		reservation, err := scanReservation(rows)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to scan reservation",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+		reservations = append(reservations, *reservation)
+		byStatus[reservation.Status]++
+		if reservation.Status == "booked" || reservation.Status == "seated" {
+			covers += reservation.PartySize
+		}
+	}
+	rows.Close()
+
+	tableRows, err := h.db.Query(`
+		SELECT id, table_number, seating_capacity, location, status
+		FROM dining_tables
+		ORDER BY table_number
+	`)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch tables",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	defer tableRows.Close()
+
+	tables := []map[string]interface{}{}
+	for tableRows.Next() {
+		var tableID uuid.UUID
+		var tableNumber, status string
+		var capacity int
+		var tableLocation *string
+		if err := tableRows.Scan(&tableID, &tableNumber, &capacity, &tableLocation, &status); err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to scan table",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+
+		booked := []models.Reservation{}
+		for _, reservation := range reservations {
+			if reservation.TableID != nil && *reservation.TableID == tableID &&
+				(reservation.Status == "booked" || reservation.Status == "seated") {
+				booked = append(booked, reservation)
+			}
+		}
+		tables = append(tables, map[string]interface{}{
+			"id":               tableID,
+			"table_number":     tableNumber,
+			"seating_capacity": capacity,
+			"location":         tableLocation,
+			"status":           status,
+			"reservations":     booked,
+		})
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Reservation day view retrieved successfully",
+		Data: map[string]interface{}{
+			"date":         start.Format("2006-01-02"),
+			"timezone":     location.String(),
+			"reservations": reservations,
+			"tables":       tables,
+			"totals": map[string]interface{}{
+				"reservations": len(reservations),
+				"covers":       covers,
+				"by_status":    byStatus,
+			},
+		},
+	})
+}
+
+// GetReservation retrieves a reservation by ID
+func (h *ReservationHandler) GetReservation(c *gin.Context) {
+	reservationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid reservation ID",
+			Error:   stringPtr("invalid_uuid"),
+		})
+		return
+	}
+
+	reservation, err := scanReservation(h.db.QueryRow(reservationQuery+` WHERE r.id = $1`, reservationID))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Reservation not found",
+			Error:   stringPtr("reservation_not_found"),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch reservation",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Reservation retrieved successfully",
+		Data:    reservation,
+	})
+}
+
+// SearchAvailability lists the tables free for a party at a time
+// (?date=YYYY-MM-DD&time=HH:MM&party_size=&duration=&location=), smallest
+// first. When none is free, nearby times that have a table are suggested.
+func (h *ReservationHandler) SearchAvailability(c *gin.Context) {
+	location, err := util.StoreLocation(h.db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to load settings",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	start, err := time.ParseInLocation("2006-01-02 15:04", c.Query("date")+" "+c.Query("time"), location)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "date and time are required. Use YYYY-MM-DD and HH:MM",
+			Error:   stringPtr("invalid_date"),
+		})
+		return
+	}
+
+	partySize, err := strconv.Atoi(c.Query("party_size"))
+	if err != nil || partySize < 1 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "party_size must be a positive number",
+			Error:   stringPtr("invalid_party_size"),
+		})
+		return
+	}
+
+	minutes := defaultReservationMinutes
+	if value := c.Query("duration"); value != "" {
+		minutes, err = strconv.Atoi(value)
+		if err != nil || minutes < 1 {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "duration must be a positive number of minutes",
+				Error:   stringPtr("invalid_duration"),
+			})
+			return
+		}
+	}
+	tableLocation := c.Query("location")
+
+	tables, err := availableTables(h.db, start, minutes, partySize, tableLocation, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to search availability",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	alternatives := []time.Time{}
+	if len(tables) == 0 {
+		for offset := alternativeSlotMinutes; offset <= alternativeSlotRange; offset += alternativeSlotMinutes {
+			for _, slot := range []time.Time{start.Add(time.Duration(-offset) * time.Minute), start.Add(time.Duration(offset) * time.Minute)} {
+				if slot.Before(time.Now()) {
+					continue
+				}
+				free, err := availableTables(h.db, slot, minutes, partySize, tableLocation, nil)
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, models.APIResponse{
+						Success: false,
+						Message: "Failed to search availability",
+						Error:   stringPtr(err.Error()),
+					})
+					return
+				}
+				if len(free) > 0 {
+					alternatives = append(alternatives, slot.In(location))
+				}
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Availability retrieved successfully",
+		Data: map[string]interface{}{
+			"requested_at":     start,
+			"party_size":       partySize,
+			"duration_minutes": minutes,
+			"available":        len(tables) > 0,
+			"tables":           tables,
+			"alternatives":     alternatives,
+		},
+	})
+}
+
+// CreateReservation books a table for a party
+func (h *ReservationHandler) CreateReservation(c *gin.Context) {
+	userID, _, _, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Authentication required",
+			Error:   stringPtr("auth_required"),
+		})
+		return
+	}
+
+	var req models.CreateReservationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	minutes := defaultReservationMinutes
+	if req.DurationMinutes != nil {
+		minutes = *req.DurationMinutes
+	}
+	if message, code := validateReservation(req.GuestName, req.PartySize, minutes); code != "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   stringPtr(code),
+		})
+		return
+	}
+	if !req.ReservedAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Reservation time must be in the future",
+			Error:   stringPtr("invalid_reservation_time"),
+		})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to start transaction",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	tableID, err := assignTable(tx, req.TableID, req.ReservedAt, minutes, req.PartySize, nil)
+	if err != nil {
+		respondTableAssignmentError(c, err)
+		return
+	}
+
+	var reservationID uuid.UUID
+	err = tx.QueryRow(`
+		INSERT INTO reservations (guest_name, guest_phone, party_size, reserved_at, duration_minutes, table_id, notes, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`, strings.TrimSpace(req.GuestName), req.GuestPhone, req.PartySize, req.ReservedAt, minutes, tableID, req.Notes, userID).Scan(&reservationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to create reservation",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	reservation, err := scanReservation(tx.QueryRow(reservationQuery+` WHERE r.id = $1`, reservationID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch reservation",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to commit transaction",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Reservation created successfully",
+		Data:    reservation,
+	})
+}
+
+// UpdateReservation changes a booked reservation. A new time, duration, party
+// size or table is checked against the other bookings.
+func (h *ReservationHandler) UpdateReservation(c *gin.Context) {
+	reservationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid reservation ID",
+			Error:   stringPtr("invalid_uuid"),
+		})
+		return
+	}
+
+	var req models.UpdateReservationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to start transaction",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	reservation, err := scanReservation(tx.QueryRow(reservationQuery+` WHERE r.id = $1 FOR UPDATE OF r`, reservationID))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Reservation not found",
+			Error:   stringPtr("reservation_not_found"),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch reservation",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	if reservation.Status != "booked" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Only booked reservations can be changed - reservation is " + reservation.Status,
+			Error:   stringPtr("invalid_reservation_status"),
+		})
+		return
+	}
+
+	if req.GuestName != nil {
+		reservation.GuestName = strings.TrimSpace(*req.GuestName)
+	}
+	if req.GuestPhone != nil {
+		reservation.GuestPhone = req.GuestPhone
+	}
+	if req.Notes != nil {
+		reservation.Notes = req.Notes
+	}
+	rebook := false
+	if req.PartySize != nil {
+		reservation.PartySize = *req.PartySize
+		rebook = true
+	}
+	if req.DurationMinutes != nil {
+		reservation.DurationMinutes = *req.DurationMinutes
+		rebook = true
+	}
+	if req.ReservedAt != nil {
+		if !req.ReservedAt.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Reservation time must be in the future",
+				Error:   stringPtr("invalid_reservation_time"),
+			})
+			return
+		}
+		reservation.ReservedAt = *req.ReservedAt
+		rebook = true
+	}
+	if req.TableID != nil {
+		reservation.TableID = req.TableID
+		rebook = true
+	}
+
+	if message, code := validateReservation(reservation.GuestName, reservation.PartySize, reservation.DurationMinutes); code != "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   stringPtr(code),
+		})
+		return
+	}
+
+	if rebook {
+		tableID, err := assignTable(tx, reservation.TableID, reservation.ReservedAt, reservation.DurationMinutes, reservation.PartySize, &reservation.ID)
+		if err != nil {
+			respondTableAssignmentError(c, err)
+			return
+		}
+		if reservation.TableID == nil || *reservation.TableID != tableID {
+			if err := releaseTableHold(tx, reservation.ID); err != nil {
+				c.JSON(http.StatusInternalServerError, models.APIResponse{
+					Success: false,
+					Message: "Failed to release table",
+					Error:   stringPtr(err.Error()),
+				})
+				return
+			}
+		}
+		reservation.TableID = &tableID
+	}
+
+	_, err = tx.Exec(`
+		UPDATE reservations
+		SET guest_name = $1, guest_phone = $2, party_size = $3, reserved_at = $4, duration_minutes = $5,
+		    table_id = $6, notes = $7, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $8
+	`, reservation.GuestName, reservation.GuestPhone, reservation.PartySize, reservation.ReservedAt,
+		reservation.DurationMinutes, reservation.TableID, reservation.Notes, reservation.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to update reservation",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	// A table held for the old time is given back; the reservation worker holds
+	// it again when the new time approaches
+	if rebook {
+		if err := releaseTableHold(tx, reservation.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to release table",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+	}
+
+	updated, err := scanReservation(tx.QueryRow(reservationQuery+` WHERE r.id = $1`, reservation.ID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch reservation",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to commit transaction",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Reservation updated successfully",
+		Data:    updated,
+	})
+}
+
+// CancelReservation cancels a booked reservation and frees its table
+func (h *ReservationHandler) CancelReservation(c *gin.Context) {
+	h.closeReservation(c, "cancelled")
+}
+
+// MarkNoShow records that the party of a booked reservation did not arrive and frees its table
+func (h *ReservationHandler) MarkNoShow(c *gin.Context) {
+	h.closeReservation(c, "no_show")
+}
+
+func (h *ReservationHandler) closeReservation(c *gin.Context, status string) {
+	reservationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid reservation ID",
+			Error:   stringPtr("invalid_uuid"),
+		})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to start transaction",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	var currentStatus string
+	err = tx.QueryRow("SELECT status FROM reservations WHERE id = $1 FOR UPDATE", reservationID).Scan(&currentStatus)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Reservation not found",
+			Error:   stringPtr("reservation_not_found"),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch reservation",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	if currentStatus != "booked" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Only booked reservations can be closed - reservation is " + currentStatus,
+			Error:   stringPtr("invalid_reservation_status"),
+		})
+		return
+	}
+
+	query := "UPDATE reservations SET status = $1, updated_at = CURRENT_TIMESTAMP"
+	if status == "cancelled" {
+		query += ", cancelled_at = CURRENT_TIMESTAMP"
+	}
+	query += " WHERE id = $2"
+	if _, err := tx.Exec(query, status, reservationID); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to update reservation",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	if err := releaseTableHold(tx, reservationID); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to release table",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to commit transaction",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	message := "Reservation cancelled successfully"
+	if status == "no_show" {
+		message = "Reservation marked as no-show"
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: message,
+	})
+}
+
+// SeatReservation seats the party of a booked reservation and marks the table
+// occupied. The order the server takes for them is linked to the reservation
+// by CreateOrder.
+func (h *ReservationHandler) SeatReservation(c *gin.Context) {
+	reservationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid reservation ID",
+			Error:   stringPtr("invalid_uuid"),
+		})
+		return
+	}
+
+	if _, _, _, ok := middleware.GetUserFromContext(c); !ok {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Authentication required",
+			Error:   stringPtr("auth_required"),
+		})
+		return
+	}
+
+	var req models.SeatReservationRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid request body",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to start transaction",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	reservation, err := scanReservation(tx.QueryRow(reservationQuery+` WHERE r.id = $1 FOR UPDATE OF r`, reservationID))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Reservation not found",
+			Error:   stringPtr("reservation_not_found"),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch reservation",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	if reservation.Status != "booked" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Only booked reservations can be seated - reservation is " + reservation.Status,
+			Error:   stringPtr("invalid_reservation_status"),
+		})
+		return
+	}
+
+	tableID := reservation.TableID
+	if req.TableID != nil {
+		tableID = req.TableID
+	}
+	if tableID == nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "A table is required to seat the reservation",
+			Error:   stringPtr("table_required"),
+		})
+		return
+	}
+
+	var tableStatus string
+	var capacity int
+	var reservedFor *uuid.UUID
+	err = tx.QueryRow("SELECT status, seating_capacity, reserved_for FROM dining_tables WHERE id = $1 FOR UPDATE", *tableID).
+		Scan(&tableStatus, &capacity, &reservedFor)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Table not found",
+			Error:   stringPtr("table_not_found"),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch table",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	heldForOther := tableStatus == "reserved" && (reservedFor == nil || *reservedFor != reservation.ID)
+	if tableStatus == "occupied" || tableStatus == "out_of_service" || heldForOther {
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: "Table is " + strings.ReplaceAll(tableStatus, "_", " "),
+			Error:   stringPtr("table_unavailable"),
+		})
+		return
+	}
+	if capacity < reservation.PartySize {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Table is too small for the party",
+			Error:   stringPtr("table_too_small"),
+		})
+		return
+	}
+
+	// Give back a table held for the reservation that the party was not seated at
+	if err := releaseTableHold(tx, reservation.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to release table",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	if err := seatTable(tx, *tableID); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to update table status",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	_, err = tx.Exec(`
+		UPDATE reservations
+		SET status = 'seated', table_id = $1, seated_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+	`, *tableID, reservation.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to update reservation",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to commit transaction",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Reservation seated successfully",
+		Data: map[string]interface{}{
+			"reservation_id": reservation.ID,
+			"table_id":       *tableID,
+		},
+	})
+}
+
+// validateReservation checks the guest details and returns a message and error
+// code when they are invalid
+func validateReservation(guestName string, partySize, minutes int) (string, string) {
+	if strings.TrimSpace(guestName) == "" {
+		return "Guest name is required", "invalid_guest_name"
+	}
+	if partySize < 1 {
+		return "Party size must be at least 1", "invalid_party_size"
+	}
+	if minutes < 1 {
+		return "Duration must be a positive number of minutes", "invalid_duration"
+	}
+	return "", ""
+}
+
+// availableTables returns the tables, smallest first, that seat the party and
+// have no booked or seated reservation overlapping the slot. Tables out of
+// service or in a table group are never offered, and occupied ones only for
+// slots starting after the party at the table is expected to have left.
+func availableTables(q queryer, start time.Time, minutes, partySize int, location string, excludeID *uuid.UUID) ([]models.DiningTable, error) {
+	query := `
+		SELECT t.id, t.table_number, t.seating_capacity, t.location, t.status, t.created_at, t.updated_at
+		FROM dining_tables t
+		WHERE t.seating_capacity >= $1
+		  AND t.status <> 'out_of_service'
+		  AND t.is_group = false AND t.joined_to IS NULL
+		  AND NOT (t.status = 'occupied' AND $2 < CURRENT_TIMESTAMP + $3::float8 * INTERVAL '1 minute')
+		  AND NOT EXISTS (
+			SELECT 1 FROM reservations r
+			WHERE r.table_id = t.id
+			  AND r.status IN ('booked', 'seated')
+			  AND r.id IS DISTINCT FROM $4
+			  AND r.reserved_at < $2 + $3::float8 * INTERVAL '1 minute'
+			  AND r.reserved_at + r.duration_minutes * INTERVAL '1 minute' > $2
+		  )
+	`
+	args := []interface{}{partySize, start, minutes, excludeID}
+	if location != "" {
+		args = append(args, location)
+		query += fmt.Sprintf(` AND t.location = $%d`, len(args))
+	}
+	query += ` ORDER BY t.seating_capacity, t.table_number`
+
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tables := []models.DiningTable{}
+	for rows.Next() {
+		var table models.DiningTable
+		err := rows.Scan(&table.ID, &table.TableNumber, &table.SeatingCapacity, &table.Location,
+			&table.Status, &table.CreatedAt, &table.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	return tables, rows.Err()
+}
+
+// assignTable checks that the requested table, or else the smallest free one,
+// can take the party for the slot. The chosen table is locked so two bookings
+// cannot take it at once.
+func assignTable(tx *sql.Tx, tableID *uuid.UUID, start time.Time, minutes, partySize int, excludeID *uuid.UUID) (uuid.UUID, error) {
+	candidates := []uuid.UUID{}
+	if tableID != nil {
+		var capacity int
+		var status string
+		var isGroup bool
+		var joinedTo *uuid.UUID
+		err := tx.QueryRow("SELECT seating_capacity, status, is_group, joined_to FROM dining_tables WHERE id = $1 FOR UPDATE", *tableID).
+			Scan(&capacity, &status, &isGroup, &joinedTo)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		if status == "out_of_service" {
+			return uuid.Nil, errTableOutOfOrder
+		}
+		// Groups only last until they are split, so they cannot be booked ahead
+		if isGroup || joinedTo != nil {
+			return uuid.Nil, errTableGrouped
+		}
+		if capacity < partySize {
+			return uuid.Nil, errTableTooSmall
+		}
+		candidates = append(candidates, *tableID)
+	} else {
+		tables, err := availableTables(tx, start, minutes, partySize, "", excludeID)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		for _, table := range tables {
+			candidates = append(candidates, table.ID)
+		}
+	}
+
+	for _, candidate := range candidates {
+		if tableID == nil {
+			if _, err := tx.Exec("SELECT 1 FROM dining_tables WHERE id = $1 FOR UPDATE", candidate); err != nil {
+				return uuid.Nil, err
+			}
+		}
+		var conflict bool
+		err := tx.QueryRow(`
+			SELECT EXISTS (
+				SELECT 1 FROM reservations
+				WHERE table_id = $1
+				  AND status IN ('booked', 'seated')
+				  AND id IS DISTINCT FROM $2
+				  AND reserved_at < $3 + $4::float8 * INTERVAL '1 minute'
+				  AND reserved_at + duration_minutes * INTERVAL '1 minute' > $3
+			)
+		`, candidate, excludeID, start, minutes).Scan(&conflict)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		if !conflict {
+			return candidate, nil
+		}
+	}
+
+	if tableID != nil {
+		return uuid.Nil, errTableUnavailable
+	}
+	return uuid.Nil, errNoTableAvailable
+}
+
+// respondTableAssignmentError reports why a reservation could not get a table
+func respondTableAssignmentError(c *gin.Context, err error) {
+	switch err {
+	case sql.ErrNoRows:
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Table not found",
+			Error:   stringPtr("table_not_found"),
+		})
+	case errTableTooSmall:
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Table is too small for the party",
+			Error:   stringPtr("table_too_small"),
+		})
+	case errTableUnavailable:
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: "Table is already booked at that time",
+			Error:   stringPtr("table_unavailable"),
+		})
+	case errTableGrouped:
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: "Table is part of a table group and cannot be reserved",
+			Error:   stringPtr("table_grouped"),
+		})
+	case errTableOutOfOrder:
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: "Table is out of service",
+			Error:   stringPtr("table_out_of_service"),
+		})
+	case errNoTableAvailable:
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: "No table is available for the party at that time",
+			Error:   stringPtr("no_table_available"),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to assign table",
+			Error:   stringPtr(err.Error()),
+		})
+	}
+}
+
+// releaseTableHold gives back a table held for a reservation
+func releaseTableHold(tx *sql.Tx, reservationID uuid.UUID) error {
+	_, err := tx.Exec(`
+		UPDATE dining_tables
+		SET status = CASE WHEN status = 'reserved' THEN 'available' ELSE status END,
+		    reserved_for = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE reserved_for = $1
+	`, reservationID)
+	return err
+}
+
+// seatTable marks a table occupied by a party seated before their order is
+// taken. The order keeps the table occupied and frees it once it is closed.
+func seatTable(tx *sql.Tx, tableID uuid.UUID) error {
+	_, err := tx.Exec(`
+		UPDATE dining_tables
+		SET status = 'occupied', reserved_for = NULL, status_changed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, tableID)
+	return err
+}
+
+// seatedParty is a party seated at a table that has no order yet. The first
+// order taken for them is linked to them.
+type seatedParty struct {
+	TableID *uuid.UUID
+	Name    string
+	Size    int
+	Notes   *string
+}
+
+// applyTo fills in the order type, table, customer name, covers and notes the
+// server left out of the party's order
+func (p seatedParty) applyTo(req *models.CreateOrderRequest) {
+	if req.OrderType == "" {
+		req.OrderType = "dine_in"
+	}
+	if req.TableID == nil {
+		req.TableID = p.TableID
+	}
+	if req.CustomerName == nil {
+		req.CustomerName = &p.Name
+	}
+	if req.Covers == nil {
+		req.Covers = &p.Size
+	}
+	if req.Notes == nil {
+		req.Notes = p.Notes
+	}
+}
+
+// seatedReservation locks a seated reservation that has no order yet.
+// sql.ErrNoRows means there is no such reservation.
+func seatedReservation(tx *sql.Tx, reservationID uuid.UUID) (seatedParty, error) {
+	var party seatedParty
+	err := tx.QueryRow(`
+		SELECT table_id, guest_name, party_size, notes
+		FROM reservations
+		WHERE id = $1 AND status = 'seated' AND order_id IS NULL
+		FOR UPDATE
+	`, reservationID).Scan(&party.TableID, &party.Name, &party.Size, &party.Notes)
+	return party, err
+}
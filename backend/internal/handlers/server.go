@@ -24,11 +24,15 @@ func (h *ServerHandler) CreateDineInOrder(c *gin.Context) {
 		TableID      *string `json:"table_id"`
 		CustomerName *string `json:"customer_name"`
 		Items        []struct {
-			ProductID           string  `json:"product_id"`
-			Quantity            int     `json:"quantity"`
-			SpecialInstructions *string `json:"special_instructions"`
+			ProductID           string   `json:"product_id"`
+			Quantity            int      `json:"quantity"`
+			SpecialInstructions *string  `json:"special_instructions"`
+			ModifierIDs         []string `json:"modifier_ids"`
 		} `json:"items"`
-		Notes *string `json:"notes"`
+		Notes           *string  `json:"notes"`
+		GuestAllergies  []string `json:"guest_allergies"`
+		Covers          *int     `json:"covers"`
+		OverrideDaypart bool     `json:"override_daypart"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -45,11 +49,14 @@ func (h *ServerHandler) CreateDineInOrder(c *gin.Context) {
 
 	// Create order request with forced dine_in type
 	createOrderReq := map[string]interface{}{
-		"table_id":      req.TableID,
-		"customer_name": req.CustomerName,
-		"order_type":    "dine_in", // Force dine-in for servers
-		"items":         req.Items,
-		"notes":         req.Notes,
+		"table_id":         req.TableID,
+		"customer_name":    req.CustomerName,
+		"order_type":       "dine_in", // Force dine-in for servers
+		"items":            req.Items,
+		"notes":            req.Notes,
+		"guest_allergies":  req.GuestAllergies,
+		"covers":           req.Covers,
+		"override_daypart": req.OverrideDaypart,
 	}
 
 	// Convert to JSON and back to simulate the request
@@ -26,7 +26,7 @@ func (h *SettingsHandler) GetSettings(c *gin.Context) {
 		SELECT id, name, description, address, phone, email, website, logo_url,
 		       currency, tax_rate, service_charge_rate, opening_time, closing_time,
 		       timezone, default_order_type, auto_print_receipts, auto_print_kitchen,
-		       receipt_footer, is_active, created_at, updated_at
+		       auto_email_receipts, receipt_footer, is_active, guest_order_approval, created_at, updated_at
 		FROM setting
 		ORDER BY created_at DESC
 		LIMIT 1
@@ -39,7 +39,8 @@ func (h *SettingsHandler) GetSettings(c *gin.Context) {
 		&settings.Currency, &settings.TaxRate, &settings.ServiceChargeRate,
 		&settings.OpeningTime, &settings.ClosingTime, &settings.Timezone,
 		&settings.DefaultOrderType, &settings.AutoPrintReceipts, &settings.AutoPrintKitchen,
-		&settings.ReceiptFooter, &settings.IsActive, &settings.CreatedAt, &settings.UpdatedAt,
+		&settings.AutoEmailReceipts, &settings.ReceiptFooter, &settings.IsActive, &settings.GuestOrderApproval,
+		&settings.CreatedAt, &settings.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -93,9 +94,9 @@ func (h *SettingsHandler) UpdateSettings(c *gin.Context) {
 				id, name, description, address, phone, email, website, logo_url,
 				currency, tax_rate, service_charge_rate, opening_time, closing_time,
 				timezone, default_order_type, auto_print_receipts, auto_print_kitchen,
-				receipt_footer, is_active, created_at, updated_at
+				auto_email_receipts, receipt_footer, is_active, guest_order_approval, created_at, updated_at
 			) VALUES (
-				$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21
+				$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23
 			)
 		`
 		
@@ -118,8 +119,10 @@ func (h *SettingsHandler) UpdateSettings(c *gin.Context) {
 			getStringValue(req.DefaultOrderType, "dine_in"),
 			getBoolValue(req.AutoPrintReceipts, false),
 			getBoolValue(req.AutoPrintKitchen, false),
+			getBoolValue(req.AutoEmailReceipts, false),
 			req.ReceiptFooter,
 			getBoolValue(req.IsActive, true),
+			getBoolValue(req.GuestOrderApproval, false),
 			time.Now(),
 			time.Now(),
 		}
@@ -131,18 +134,19 @@ func (h *SettingsHandler) UpdateSettings(c *gin.Context) {
 				website = $6, logo_url = $7, currency = $8, tax_rate = $9,
 				service_charge_rate = $10, opening_time = $11, closing_time = $12,
 				timezone = $13, default_order_type = $14, auto_print_receipts = $15,
-				auto_print_kitchen = $16, receipt_footer = $17, is_active = $18,
-				updated_at = $19
-			WHERE id = $20
+				auto_print_kitchen = $16, auto_email_receipts = $17, receipt_footer = $18,
+				is_active = $19, guest_order_approval = $20, updated_at = $21
+			WHERE id = $22
 		`
 		
 		// Get current values to preserve unchanged fields
 		var currentSettings models.Settings
-		h.db.QueryRow("SELECT name, currency, tax_rate, service_charge_rate, timezone, default_order_type, auto_print_receipts, auto_print_kitchen, is_active FROM settings WHERE id = $1", existingID).Scan(
+		h.db.QueryRow("SELECT name, currency, tax_rate, service_charge_rate, timezone, default_order_type, auto_print_receipts, auto_print_kitchen, auto_email_receipts, is_active, guest_order_approval FROM settings WHERE id = $1", existingID).Scan(
 			&currentSettings.Name, &currentSettings.Currency, &currentSettings.TaxRate,
 			&currentSettings.ServiceChargeRate, &currentSettings.Timezone,
 			&currentSettings.DefaultOrderType, &currentSettings.AutoPrintReceipts,
-			&currentSettings.AutoPrintKitchen, &currentSettings.IsActive,
+			&currentSettings.AutoPrintKitchen, &currentSettings.AutoEmailReceipts, &currentSettings.IsActive,
+			&currentSettings.GuestOrderApproval,
 		)
 
 		args = []interface{}{
@@ -162,8 +166,10 @@ func (h *SettingsHandler) UpdateSettings(c *gin.Context) {
 			getStringValue(req.DefaultOrderType, currentSettings.DefaultOrderType),
 			getBoolValue(req.AutoPrintReceipts, currentSettings.AutoPrintReceipts),
 			getBoolValue(req.AutoPrintKitchen, currentSettings.AutoPrintKitchen),
+			getBoolValue(req.AutoEmailReceipts, currentSettings.AutoEmailReceipts),
 			req.ReceiptFooter,
 			getBoolValue(req.IsActive, currentSettings.IsActive),
+			getBoolValue(req.GuestOrderApproval, currentSettings.GuestOrderApproval),
 			time.Now(),
 			existingID,
 		}
@@ -214,3 +220,38 @@ func getBoolValue(ptr *bool, defaultValue bool) bool {
 	return defaultValue
 }
 
+
+// loadSettings returns the current restaurant settings, falling back to defaults
+// when none have been configured yet
+func loadSettings(q queryer) (*models.Settings, error) {
+	settings := models.Settings{
+		Name:             "Restaurant",
+		Currency:         "USD",
+		Timezone:         "UTC",
+		DefaultOrderType: "dine_in",
+		IsActive:         true,
+	}
+
+	err := q.QueryRow(`
+		SELECT id, name, description, address, phone, email, website, logo_url,
+		       currency, tax_rate, service_charge_rate, opening_time, closing_time,
+		       timezone, default_order_type, auto_print_receipts, auto_print_kitchen,
+		       auto_email_receipts, receipt_footer, is_active, guest_order_approval, created_at, updated_at
+		FROM settings
+		ORDER BY created_at DESC
+		LIMIT 1
+	`).Scan(
+		&settings.ID, &settings.Name, &settings.Description, &settings.Address,
+		&settings.Phone, &settings.Email, &settings.Website, &settings.LogoURL,
+		&settings.Currency, &settings.TaxRate, &settings.ServiceChargeRate,
+		&settings.OpeningTime, &settings.ClosingTime, &settings.Timezone,
+		&settings.DefaultOrderType, &settings.AutoPrintReceipts, &settings.AutoPrintKitchen,
+		&settings.AutoEmailReceipts, &settings.ReceiptFooter, &settings.IsActive, &settings.GuestOrderApproval,
+		&settings.CreatedAt, &settings.UpdatedAt,
+	)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return &settings, nil
+}
@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"pos-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type StationHandler struct {
+	db *sql.DB
+}
+
+func NewStationHandler(db *sql.DB) *StationHandler {
+	return &StationHandler{db: db}
+}
+
+// GetStations returns the kitchen stations in display order
+func (h *StationHandler) GetStations(c *gin.Context) {
+	query := `
+		SELECT id, name, color, sort_order, is_active, created_at, updated_at
+		FROM kitchen_stations
+	`
+	if c.Query("active_only") == "true" {
+		query += ` WHERE is_active = true`
+	}
+	query += ` ORDER BY sort_order, name`
+
+	rows, err := h.db.Query(query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch kitchen stations",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	defer rows.Close()
+
+	stations := []models.KitchenStation{}
+	for rows.Next() {
+		var station models.KitchenStation
+		err := rows.Scan(
+			&station.ID, &station.Name, &station.Color, &station.SortOrder,
+			&station.IsActive, &station.CreatedAt, &station.UpdatedAt,
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to scan kitchen station",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+		stations = append(stations, station)
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Kitchen stations retrieved successfully",
+		Data:    stations,
+	})
+}
+
+// CreateStation adds a kitchen station
+func (h *StationHandler) CreateStation(c *gin.Context) {
+	var req models.CreateKitchenStationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	name := strings.ToLower(strings.TrimSpace(req.Name))
+	if name == "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Station name is required",
+			Error:   stringPtr("invalid_station"),
+		})
+		return
+	}
+
+	var stationID uuid.UUID
+	err := h.db.QueryRow(`
+		INSERT INTO kitchen_stations (name, color, sort_order, is_active)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, name, req.Color, req.SortOrder, getBoolValue(req.IsActive, true)).Scan(&stationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to create kitchen station",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Kitchen station created successfully",
+		Data:    map[string]interface{}{"id": stationID},
+	})
+}
+
+// UpdateStation updates a kitchen station
+func (h *StationHandler) UpdateStation(c *gin.Context) {
+	stationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid station ID",
+			Error:   stringPtr("invalid_uuid"),
+		})
+		return
+	}
+
+	var req models.UpdateKitchenStationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	// Build dynamic update query
+	updates := []string{}
+	args := []interface{}{}
+	argCount := 1
+
+	if req.Name != nil {
+		updates = append(updates, fmt.Sprintf("name = $%d", argCount))
+		args = append(args, strings.ToLower(strings.TrimSpace(*req.Name)))
+		argCount++
+	}
+	if req.Color != nil {
+		updates = append(updates, fmt.Sprintf("color = $%d", argCount))
+		args = append(args, req.Color)
+		argCount++
+	}
+	if req.SortOrder != nil {
+		updates = append(updates, fmt.Sprintf("sort_order = $%d", argCount))
+		args = append(args, *req.SortOrder)
+		argCount++
+	}
+	if req.IsActive != nil {
+		updates = append(updates, fmt.Sprintf("is_active = $%d", argCount))
+		args = append(args, *req.IsActive)
+		argCount++
+	}
+
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "No fields to update",
+			Error:   stringPtr("no_updates"),
+		})
+		return
+	}
+
+	updates = append(updates, "updated_at = CURRENT_TIMESTAMP")
+	args = append(args, stationID)
+
+	query := fmt.Sprintf("UPDATE kitchen_stations SET %s WHERE id = $%d", strings.Join(updates, ", "), argCount)
+	result, err := h.db.Exec(query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to update kitchen station",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Kitchen station not found",
+			Error:   stringPtr("station_not_found"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Kitchen station updated successfully",
+	})
+}
+
+// DeleteStation removes a kitchen station. Categories and products routed to it
+// become unassigned; items already sent keep their station for reporting.
+func (h *StationHandler) DeleteStation(c *gin.Context) {
+	stationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid station ID",
+			Error:   stringPtr("invalid_uuid"),
+		})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to start transaction",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM kitchen_stations WHERE id = $1", stationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to delete kitchen station",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Kitchen station not found",
+			Error:   stringPtr("station_not_found"),
+		})
+		return
+	}
+
+	for _, table := range []string{"categories", "products"} {
+		if _, err := tx.Exec("UPDATE "+table+" SET station_id = NULL WHERE station_id = $1", stationID); err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to unassign kitchen station",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to commit transaction",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Kitchen station deleted successfully",
+	})
+}
+
+// resolveStation looks up a station by ID or by name
+func resolveStation(q queryer, value string) (uuid.UUID, error) {
+	var stationID uuid.UUID
+	if id, err := uuid.Parse(value); err == nil {
+		err := q.QueryRow("SELECT id FROM kitchen_stations WHERE id = $1", id).Scan(&stationID)
+		return stationID, err
+	}
+	err := q.QueryRow("SELECT id FROM kitchen_stations WHERE name = $1", strings.ToLower(strings.TrimSpace(value))).Scan(&stationID)
+	return stationID, err
+}
+
+// stationArg turns an optional station ID from a request into a query argument;
+// an empty string clears the assignment
+func stationArg(value *string) interface{} {
+	if value == nil || *value == "" {
+		return nil
+	}
+	return *value
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"pos-backend/internal/events"
+	"pos-backend/internal/middleware"
+	"pos-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// streamRetry tells EventSource clients how long to wait before reconnecting
+	streamRetry = 3 * time.Second
+	// streamHeartbeat keeps idle connections open through proxies
+	streamHeartbeat = 15 * time.Second
+	// streamReplayLimit caps how many missed events a reconnecting client receives
+	streamReplayLimit = 1000
+)
+
+type StreamHandler struct {
+	db     *sql.DB
+	broker *events.Broker
+}
+
+func NewStreamHandler(db *sql.DB, broker *events.Broker) *StreamHandler {
+	return &StreamHandler{db: db, broker: broker}
+}
+
+// KitchenStream pushes the events the kitchen display reacts to
+func (h *StreamHandler) KitchenStream(c *gin.Context) {
+	h.stream(c, func(event events.Event) bool {
+		switch event.Type {
+		case events.OrderCreated, events.ItemStatusChanged, events.OrderCancelled, events.OrderReady,
+			events.OrderLate, events.OrderRecalled, events.ItemsRemade, events.AllergiesChanged,
+			events.ProductEightySixed, events.ProductRestored:
+			return true
+		}
+		return false
+	})
+}
+
+// CounterStream pushes every order event
+func (h *StreamHandler) CounterStream(c *gin.Context) {
+	h.stream(c, func(events.Event) bool {
+		return true
+	})
+}
+
+// ServerStream pushes events for the orders taken by the signed-in server and
+// menu availability changes
+func (h *StreamHandler) ServerStream(c *gin.Context) {
+	userID, _, _, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Authentication required",
+			Error:   stringPtr("auth_required"),
+		})
+		return
+	}
+
+	h.stream(c, func(event events.Event) bool {
+		if !event.IsOrderEvent() {
+			return true
+		}
+		return event.UserID != nil && *event.UserID == userID
+	})
+}
+
+// stream serves a Server-Sent Events connection. A client that reconnects with
+// Last-Event-ID (or ?last_event_id= for the first connection) first receives the
+// events it missed, then live events.
+func (h *StreamHandler) stream(c *gin.Context, include func(events.Event) bool) {
+	lastID := int64(0)
+	if value := c.GetHeader("Last-Event-ID"); value != "" {
+		lastID, _ = strconv.ParseInt(value, 10, 64)
+	} else if value := c.Query("last_event_id"); value != "" {
+		lastID, _ = strconv.ParseInt(value, 10, 64)
+	}
+
+	// Subscribe before reading the backlog so nothing published in between is lost;
+	// duplicates are skipped by comparing IDs
+	live, cancel := h.broker.Subscribe()
+	defer cancel()
+
+	var missed []events.Event
+	if lastID > 0 {
+		var err error
+		missed, err = events.Since(h.db, lastID, streamReplayLimit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to load missed events",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	w := c.Writer
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry.Milliseconds())
+
+	for _, event := range missed {
+		lastID = event.ID
+		if include(event) {
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+		}
+	}
+	w.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+
+		case event, ok := <-live:
+			if !ok {
+				// Dropped by the broker; the client reconnects and resumes
+				return
+			}
+			if event.ID <= lastID {
+				continue
+			}
+			lastID = event.ID
+			if !include(event) {
+				continue
+			}
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+			w.Flush()
+
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			w.Flush()
+		}
+	}
+}
+
+func writeEvent(w gin.ResponseWriter, event events.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
@@ -0,0 +1,365 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"pos-backend/internal/models"
+	"pos-backend/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tableSeatingsQuery selects the dine-in orders closed between $1 and $2 as
+// seatings, optionally only at location $3. Orders seated at a table group
+// that has since been split are attributed to the group as it was recorded.
+// Gift cards sold on an order are not revenue.
+const tableSeatingsQuery = `
+	WITH seatings AS (
+		SELECT o.id, o.created_at AS seated_at, o.completed_at AS left_at, o.covers, ` + orderRevenue + ` AS revenue,
+		       COALESCE(t.table_number, g.table_number, '') AS table_number,
+		       COALESCE(t.location, g.location, 'main_floor') AS location
+		FROM orders o
+		LEFT JOIN dining_tables t ON o.table_id = t.id
+		LEFT JOIN table_groups g ON o.table_id = g.id
+		WHERE o.order_type = 'dine_in' AND o.status = 'completed'
+		  AND o.table_id IS NOT NULL AND o.completed_at IS NOT NULL
+		  AND o.completed_at >= $1 AND o.completed_at < $2
+		  AND ($3::text = '' OR COALESCE(t.location, g.location, 'main_floor') = $3)
+	)
+`
+
+// tableStatusPeriodsQuery turns the status history of physical tables into
+// periods ending at the next change, optionally only at location $3
+const tableStatusPeriodsQuery = `
+	WITH periods AS (
+		SELECT h.table_id, t.table_number, t.seating_capacity, h.status, h.changed_at,
+		       COALESCE(LEAD(h.changed_at) OVER (PARTITION BY h.table_id ORDER BY h.changed_at), CURRENT_TIMESTAMP) AS until
+		FROM table_status_history h
+		JOIN dining_tables t ON h.table_id = t.id
+		WHERE t.is_group = false AND h.changed_at < $2
+		  AND ($3::text = '' OR COALESCE(t.location, 'main_floor') = $3)
+	)
+`
+
+// turnTimeGroups maps the turn time breakdowns to their grouping key and the
+// order the groups are listed in
+var turnTimeGroups = map[string][2]string{
+	"by_table":      {"table_number", "MIN(location), table_number"},
+	"by_location":   {"location", "location"},
+	"by_party_size": {"COALESCE(covers::text, 'unknown')", "MIN(covers) NULLS LAST"},
+}
+
+// GetTableAnalytics reports how the tables were used between ?from= and ?to=
+// (YYYY-MM-DD, inclusive, default the last 7 days), optionally for one
+// ?location=. Turn times and revenue come from the dine-in orders closed in the
+// range; occupancy by hour of day and idle time between seatings come from the
+// table status history. Revenue per seat-hour divides revenue by the seat-hours
+// of tables in service during opening hours.
+func (h *TableHandler) GetTableAnalytics(c *gin.Context) {
+	settings, err := loadSettings(h.db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to load settings",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	location := util.LocationOrUTC(settings.Timezone)
+
+	start, end, err := parseReportRange(c, location, 7)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: err.Error(),
+			Error:   stringPtr("invalid_date"),
+		})
+		return
+	}
+
+	tableLocation := c.Query("location")
+	if tableLocation != "" && !tableLocations[tableLocation] {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid location",
+			Error:   stringPtr("invalid_location"),
+		})
+		return
+	}
+
+	var seatings, covers int
+	var avgTurn, revenue float64
+	err = h.db.QueryRow(tableSeatingsQuery+`
+		SELECT COUNT(*), COALESCE(SUM(covers), 0),
+		       COALESCE(AVG(EXTRACT(EPOCH FROM left_at - seated_at) / 60), 0),
+		       COALESCE(SUM(revenue), 0)
+		FROM seatings
+	`, start, end, tableLocation).Scan(&seatings, &covers, &avgTurn, &revenue)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to calculate turn times",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	turnTimes := map[string]interface{}{}
+	for name, group := range turnTimeGroups {
+		rows, err := turnTimeBreakdown(h.db, group, start, end, tableLocation)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to calculate turn times",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+		turnTimes[name] = rows
+	}
+
+	byHour, seatHours, occupancy, err := occupancyByHour(h.db, settings, start, end, tableLocation, location)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to calculate occupancy",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	idle, avgIdle, err := idleTimeByTable(h.db, start, end, tableLocation, location)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to calculate idle time",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	revenuePerSeatHour := 0.0
+	if seatHours > 0 {
+		revenuePerSeatHour = roundAmount(revenue / seatHours)
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Table analytics retrieved successfully",
+		Data: map[string]interface{}{
+			"from":     start,
+			"to":       end,
+			"location": tableLocation,
+			"summary": map[string]interface{}{
+				"seatings":              seatings,
+				"covers":                covers,
+				"avg_turn_minutes":      roundMinutes(avgTurn),
+				"avg_idle_minutes":      roundMinutes(avgIdle),
+				"revenue":               roundAmount(revenue),
+				"seat_hours":            roundTenth(seatHours),
+				"revenue_per_seat_hour": revenuePerSeatHour,
+				"occupancy_rate":        occupancy,
+			},
+			"turn_time":         turnTimes,
+			"occupancy_by_hour": byHour,
+			"idle_time":         idle,
+		},
+	})
+}
+
+func turnTimeBreakdown(q queryer, group [2]string, start, end time.Time, tableLocation string) ([]map[string]interface{}, error) {
+	query := tableSeatingsQuery + fmt.Sprintf(`
+		SELECT %[1]s AS group_key, COUNT(*), COALESCE(SUM(covers), 0),
+		       COALESCE(AVG(EXTRACT(EPOCH FROM left_at - seated_at) / 60), 0),
+		       COALESCE(MIN(EXTRACT(EPOCH FROM left_at - seated_at) / 60), 0),
+		       COALESCE(MAX(EXTRACT(EPOCH FROM left_at - seated_at) / 60), 0),
+		       COALESCE(SUM(revenue), 0)
+		FROM seatings
+		GROUP BY %[1]s
+		ORDER BY %[2]s
+	`, group[0], group[1])
+
+	rows, err := q.Query(query, start, end, tableLocation)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := []map[string]interface{}{}
+	for rows.Next() {
+		var key string
+		var seatings, covers int
+		var avgTurn, minTurn, maxTurn, revenue float64
+		if err := rows.Scan(&key, &seatings, &covers, &avgTurn, &minTurn, &maxTurn, &revenue); err != nil {
+			return nil, err
+		}
+		groups = append(groups, map[string]interface{}{
+			"key":              key,
+			"seatings":         seatings,
+			"covers":           covers,
+			"avg_turn_minutes": roundMinutes(avgTurn),
+			"min_turn_minutes": roundMinutes(minTurn),
+			"max_turn_minutes": roundMinutes(maxTurn),
+			"revenue":          roundAmount(revenue),
+		})
+	}
+	return groups, rows.Err()
+}
+
+// occupancyByHour splits the status history into the hours of the range and
+// returns, per hour of the day, the share of in-service table time spent
+// occupied. It also returns the seat-hours in service during opening hours and
+// the occupancy rate over those hours.
+func occupancyByHour(q queryer, settings *models.Settings, start, end time.Time, tableLocation string, location *time.Location) ([]map[string]interface{}, float64, float64, error) {
+	rows, err := q.Query(tableStatusPeriodsQuery+`
+		, hours AS (
+			SELECT hour_start, hour_start + INTERVAL '1 hour' AS hour_end
+			FROM generate_series($1::timestamptz, $2::timestamptz - INTERVAL '1 hour', INTERVAL '1 hour') hour_start
+			WHERE hour_start < CURRENT_TIMESTAMP
+		), slices AS (
+			SELECT EXTRACT(HOUR FROM hours.hour_start AT TIME ZONE $4)::int AS hour, p.status, p.seating_capacity,
+			       EXTRACT(EPOCH FROM LEAST(p.until, hours.hour_end) - GREATEST(p.changed_at, hours.hour_start)) AS seconds
+			FROM hours
+			JOIN periods p ON p.changed_at < hours.hour_end AND p.until > hours.hour_start
+		)
+		SELECT hour,
+		       COALESCE(SUM(seconds) FILTER (WHERE status <> 'out_of_service'), 0),
+		       COALESCE(SUM(seconds) FILTER (WHERE status = 'occupied'), 0),
+		       COALESCE(SUM(seconds * seating_capacity) FILTER (WHERE status <> 'out_of_service'), 0)
+		FROM slices
+		GROUP BY hour
+		ORDER BY hour
+	`, start, end, tableLocation, location.String())
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	defer rows.Close()
+
+	byHour := []map[string]interface{}{}
+	var seatSeconds, inService, occupied float64
+	for rows.Next() {
+		var hour int
+		var serviceSeconds, occupiedSeconds, hourSeatSeconds float64
+		if err := rows.Scan(&hour, &serviceSeconds, &occupiedSeconds, &hourSeatSeconds); err != nil {
+			return nil, 0, 0, err
+		}
+		byHour = append(byHour, map[string]interface{}{
+			"hour":           hour,
+			"table_hours":    roundTenth(serviceSeconds / 3600),
+			"occupied_hours": roundTenth(occupiedSeconds / 3600),
+			"occupancy_rate": percentage(occupiedSeconds, serviceSeconds),
+		})
+		if tradingHour(settings, hour) {
+			seatSeconds += hourSeatSeconds
+			inService += serviceSeconds
+			occupied += occupiedSeconds
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, 0, err
+	}
+	return byHour, seatSeconds / 3600, percentage(occupied, inService), nil
+}
+
+// idleTimeByTable returns how long tables stood empty between one party
+// leaving and the next being seated the same day, per table and overall
+func idleTimeByTable(q queryer, start, end time.Time, tableLocation string, location *time.Location) ([]map[string]interface{}, float64, error) {
+	rows, err := q.Query(tableStatusPeriodsQuery+`
+		, seatings AS (
+			SELECT table_id, table_number, changed_at AS seated_at,
+			       LAG(until) OVER (PARTITION BY table_id ORDER BY changed_at) AS previous_left_at
+			FROM periods
+			WHERE status = 'occupied'
+		)
+		SELECT table_number, COUNT(*),
+		       SUM(EXTRACT(EPOCH FROM seated_at - previous_left_at) / 60),
+		       MAX(EXTRACT(EPOCH FROM seated_at - previous_left_at) / 60)
+		FROM seatings
+		WHERE seated_at >= $1 AND previous_left_at IS NOT NULL
+		  AND (previous_left_at AT TIME ZONE $4)::date = (seated_at AT TIME ZONE $4)::date
+		GROUP BY table_number
+		ORDER BY table_number
+	`, start, end, tableLocation, location.String())
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	tables := []map[string]interface{}{}
+	var gaps int
+	var totalIdle float64
+	for rows.Next() {
+		var tableNumber string
+		var count int
+		var idle, maxIdle float64
+		if err := rows.Scan(&tableNumber, &count, &idle, &maxIdle); err != nil {
+			return nil, 0, err
+		}
+		tables = append(tables, map[string]interface{}{
+			"table_number":     tableNumber,
+			"gaps":             count,
+			"avg_idle_minutes": roundMinutes(idle / float64(count)),
+			"max_idle_minutes": roundMinutes(maxIdle),
+		})
+		gaps += count
+		totalIdle += idle
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	avgIdle := 0.0
+	if gaps > 0 {
+		avgIdle = totalIdle / float64(gaps)
+	}
+	return tables, avgIdle, nil
+}
+
+// tradingHour reports whether an hour of the day falls within the opening
+// hours in settings. Opening hours may run past midnight, and without them
+// every hour counts.
+func tradingHour(settings *models.Settings, hour int) bool {
+	if settings.OpeningTime == nil || settings.ClosingTime == nil {
+		return true
+	}
+	opening, err := time.Parse("15:04", *settings.OpeningTime)
+	if err != nil {
+		return true
+	}
+	closing, err := time.Parse("15:04", *settings.ClosingTime)
+	if err != nil {
+		return true
+	}
+
+	openHour, closeHour := opening.Hour(), closing.Hour()
+	if closing.Minute() > 0 {
+		closeHour++
+	}
+	switch {
+	case openHour == closeHour:
+		return true
+	case openHour < closeHour:
+		return hour >= openHour && hour < closeHour
+	default:
+		return hour >= openHour || hour < closeHour
+	}
+}
+
+func percentage(part, whole float64) float64 {
+	if whole <= 0 {
+		return 0
+	}
+	return roundTenth(part / whole * 100)
+}
+
+// roundTenth rounds hours and percentages to one decimal, like roundMinutes
+func roundTenth(value float64) float64 {
+	return math.Round(value*10) / 10
+}
+
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,474 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+
+	"pos-backend/internal/middleware"
+	"pos-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// maxTableNumberLength is the size of dining_tables.table_number
+const maxTableNumberLength = 20
+
+var errGroupInUse = errors.New("table group has open orders")
+
+// loadTableMembers returns the tables pushed into each of the given groups
+func loadTableMembers(q queryer, groupIDs []uuid.UUID) (map[uuid.UUID][]models.TableMember, error) {
+	members := map[uuid.UUID][]models.TableMember{}
+	if len(groupIDs) == 0 {
+		return members, nil
+	}
+
+	ids := []string{}
+	for _, groupID := range groupIDs {
+		ids = append(ids, groupID.String())
+	}
+	rows, err := q.Query(`
+		SELECT joined_to, id, table_number, seating_capacity
+		FROM dining_tables
+		WHERE joined_to = ANY($1)
+		ORDER BY table_number
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var groupID uuid.UUID
+		var member models.TableMember
+		if err := rows.Scan(&groupID, &member.ID, &member.TableNumber, &member.SeatingCapacity); err != nil {
+			return nil, err
+		}
+		members[groupID] = append(members[groupID], member)
+	}
+	return members, rows.Err()
+}
+
+// attachTableMembers fills in the members of the table groups in tables
+func attachTableMembers(q queryer, tables []models.DiningTable) error {
+	groupIDs := []uuid.UUID{}
+	for _, table := range tables {
+		if table.IsGroup {
+			groupIDs = append(groupIDs, table.ID)
+		}
+	}
+	members, err := loadTableMembers(q, groupIDs)
+	if err != nil {
+		return err
+	}
+	for i := range tables {
+		if tables[i].IsGroup {
+			tables[i].Members = members[tables[i].ID]
+		}
+	}
+	return nil
+}
+
+// splitTableGroup dissolves a table group: its members are released with the
+// given status and the group table itself is removed. The group stays on
+// record in table_groups so orders seated at it can be traced to its tables.
+func splitTableGroup(tx *sql.Tx, groupID uuid.UUID, status string) error {
+	var open int
+	err := tx.QueryRow(`
+		SELECT COUNT(*) FROM orders
+		WHERE table_id = $1 AND status NOT IN ('completed', 'cancelled')
+	`, groupID).Scan(&open)
+	if err != nil {
+		return err
+	}
+	if open > 0 {
+		return errGroupInUse
+	}
+
+	if status == "occupied" || status == "reserved" {
+		status = "available"
+	}
+	_, err = tx.Exec(`
+		UPDATE dining_tables
+		SET joined_to = NULL, status = $2,
+		    status_changed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE joined_to = $1
+	`, groupID, status)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM server_section_tables WHERE table_id = $1", groupID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM dining_tables WHERE id = $1 AND is_group = true", groupID); err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE table_groups SET split_at = CURRENT_TIMESTAMP WHERE id = $1", groupID)
+	return err
+}
+
+// GetTableGroups lists the table groups currently on the floor
+func (h *TableHandler) GetTableGroups(c *gin.Context) {
+	rows, err := h.db.Query(tableLayoutQuery + ` WHERE t.is_group = true ORDER BY t.location, t.table_number`)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch table groups",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	defer rows.Close()
+
+	groups := []models.DiningTable{}
+	for rows.Next() {
+		table, err := scanTableLayout(rows)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to scan table group",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+		groups = append(groups, *table)
+	}
+	rows.Close()
+
+	if err := attachTableMembers(h.db, groups); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch group tables",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Table groups retrieved successfully",
+		Data:    groups,
+	})
+}
+
+// JoinTables pushes available tables of one location together into a group
+// with their combined capacity. The group is a table of its own until it is
+// split, so a party can be seated and ordered for at it like any other table.
+func (h *TableHandler) JoinTables(c *gin.Context) {
+	userID, _, _, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Authentication required",
+			Error:   stringPtr("auth_required"),
+		})
+		return
+	}
+
+	var req models.JoinTablesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	tableIDs := []string{}
+	seen := map[uuid.UUID]bool{}
+	for _, id := range req.TableIDs {
+		if !seen[id] {
+			seen[id] = true
+			tableIDs = append(tableIDs, id.String())
+		}
+	}
+	if len(tableIDs) < 2 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "At least two different tables are needed to join",
+			Error:   stringPtr("invalid_table_group"),
+		})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to start transaction",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT id, table_number, seating_capacity, location, status, is_group, joined_to,
+		       pos_x, pos_y, width, height
+		FROM dining_tables
+		WHERE id = ANY($1)
+		ORDER BY table_number
+		FOR UPDATE
+	`, pq.Array(tableIDs))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch tables",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	var members []models.TableMember
+	var location *string
+	var capacity int
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	var problem string
+	for rows.Next() {
+		var member models.TableMember
+		var tableLocation *string
+		var status string
+		var isGroup bool
+		var joinedTo *uuid.UUID
+		var posX, posY, width, height float64
+		err := rows.Scan(&member.ID, &member.TableNumber, &member.SeatingCapacity, &tableLocation, &status,
+			&isGroup, &joinedTo, &posX, &posY, &width, &height)
+		if err != nil {
+			rows.Close()
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to scan table",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+
+		switch {
+		case problem != "":
+		case isGroup || joinedTo != nil:
+			problem = "Table " + member.TableNumber + " is already part of a table group"
+		case status != "available":
+			problem = "Table " + member.TableNumber + " is not available - table is " + status
+		case len(members) > 0 && !sameLocation(location, tableLocation):
+			problem = "Only tables in the same location can be joined"
+		}
+
+		if len(members) == 0 {
+			location = tableLocation
+		}
+		members = append(members, member)
+		capacity += member.SeatingCapacity
+		minX, minY = math.Min(minX, posX), math.Min(minY, posY)
+		maxX, maxY = math.Max(maxX, posX+width), math.Max(maxY, posY+height)
+	}
+	rows.Close()
+
+	if len(members) != len(tableIDs) {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Table not found",
+			Error:   stringPtr("table_not_found"),
+		})
+		return
+	}
+	if problem != "" {
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: problem,
+			Error:   stringPtr("table_unavailable"),
+		})
+		return
+	}
+
+	groupID := uuid.New()
+	numbers := make([]string, len(members))
+	for i, member := range members {
+		numbers[i] = member.TableNumber
+	}
+	tableNumber := strings.Join(numbers, "+")
+	if len(tableNumber) > maxTableNumberLength {
+		tableNumber = "GRP-" + strings.ToUpper(groupID.String()[:8])
+	}
+
+	group := models.DiningTable{
+		ID:              groupID,
+		TableNumber:     tableNumber,
+		SeatingCapacity: capacity,
+		Location:        location,
+		Status:          "available",
+		Layout: &models.TableLayout{
+			PosX:   minX,
+			PosY:   minY,
+			Width:  maxX - minX,
+			Height: maxY - minY,
+			Shape:  "rectangle",
+		},
+		IsGroup: true,
+		Members: members,
+	}
+	err = tx.QueryRow(`
+		INSERT INTO dining_tables (id, table_number, seating_capacity, location, status, is_group,
+		                           pos_x, pos_y, width, height, shape, rotation, status_changed_at)
+		VALUES ($1, $2, $3, $4, 'available', true, $5, $6, $7, $8, 'rectangle', 0, CURRENT_TIMESTAMP)
+		RETURNING created_at, updated_at
+	`, groupID, tableNumber, capacity, location,
+		group.Layout.PosX, group.Layout.PosY, group.Layout.Width, group.Layout.Height,
+	).Scan(&group.CreatedAt, &group.UpdatedAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to create table group",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	// The tables are in use by the group until it is split
+	_, err = tx.Exec(`
+		UPDATE dining_tables
+		SET joined_to = $1, status = 'occupied', reserved_for = NULL,
+		    status_changed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ANY($2)
+	`, groupID, pq.Array(tableIDs))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to join tables",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	// The group belongs to every server section its tables are in
+	_, err = tx.Exec(`
+		INSERT INTO server_section_tables (section_id, table_id)
+		SELECT DISTINCT section_id, $1::uuid FROM server_section_tables WHERE table_id = ANY($2)
+		ON CONFLICT DO NOTHING
+	`, groupID, pq.Array(tableIDs))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to assign table group to sections",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO table_groups (id, table_number, location, seating_capacity, member_ids, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, groupID, tableNumber, location, capacity, pq.Array(tableIDs), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to record table group",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to commit transaction",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Tables joined successfully",
+		Data:    group,
+	})
+}
+
+// SplitTableGroup separates the tables of a group before anyone is seated at
+// it. Groups split by themselves once the order seated at them closes.
+func (h *TableHandler) SplitTableGroup(c *gin.Context) {
+	groupID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid table group ID",
+			Error:   stringPtr("invalid_uuid"),
+		})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to start transaction",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRow("SELECT status FROM dining_tables WHERE id = $1 AND is_group = true FOR UPDATE", groupID).Scan(&status)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Table group not found",
+			Error:   stringPtr("table_group_not_found"),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch table group",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	err = splitTableGroup(tx, groupID, status)
+	if err == errGroupInUse {
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: "Table group has open orders",
+			Error:   stringPtr("table_group_in_use"),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to split table group",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to commit transaction",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Table group split successfully",
+	})
+}
+
+func sameLocation(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -2,8 +2,10 @@ package handlers
 
 import (
 	"database/sql"
+	"fmt"
 	"net/http"
 
+	"pos-backend/internal/middleware"
 	"pos-backend/internal/models"
 
 	"github.com/gin-gonic/gin"
@@ -18,16 +20,65 @@ func NewTableHandler(db *sql.DB) *TableHandler {
 	return &TableHandler{db: db}
 }
 
-// GetTables retrieves all dining tables
+// tableLayoutQuery selects tables with their floor plan layout, the server
+// section they belong to for the shift in progress and the table group they
+// are joined to, for scanTableLayout
+const tableLayoutQuery = `
+	SELECT t.id, t.table_number, t.seating_capacity, t.location, t.status,
+	       t.pos_x, t.pos_y, t.width, t.height, t.shape, t.rotation,
+	       s.id, s.name, s.color, s.server_id, s.server_name,
+	       t.is_group, t.joined_to, t.created_at, t.updated_at
+	FROM dining_tables t
+	LEFT JOIN LATERAL (
+		SELECT ss.id, ss.name, ss.color, ss.server_id,
+		       COALESCE(u.first_name || ' ' || u.last_name, '') AS server_name
+		FROM server_section_tables sst
+		JOIN server_sections ss ON sst.section_id = ss.id
+		LEFT JOIN users u ON ss.server_id = u.id
+		WHERE sst.table_id = t.id
+		  AND ss.shift_start <= CURRENT_TIMESTAMP AND ss.shift_end > CURRENT_TIMESTAMP
+		ORDER BY ss.shift_start DESC
+		LIMIT 1
+	) s ON true
+`
+
+func scanTableLayout(row rowScanner) (*models.DiningTable, error) {
+	var table models.DiningTable
+	var layout models.TableLayout
+	var sectionID, serverID *uuid.UUID
+	var sectionName, serverName *string
+	var color *string
+
+	err := row.Scan(
+		&table.ID, &table.TableNumber, &table.SeatingCapacity, &table.Location, &table.Status,
+		&layout.PosX, &layout.PosY, &layout.Width, &layout.Height, &layout.Shape, &layout.Rotation,
+		&sectionID, &sectionName, &color, &serverID, &serverName,
+		&table.IsGroup, &table.JoinedTo, &table.CreatedAt, &table.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	table.Layout = &layout
+	if sectionID != nil {
+		table.Section = &models.TableSection{
+			ID:         *sectionID,
+			Name:       *sectionName,
+			Color:      color,
+			ServerID:   *serverID,
+			ServerName: *serverName,
+		}
+	}
+	return &table, nil
+}
+
+// GetTables retrieves all dining tables with their layout and assigned server.
+// ?location= filters by location, ?server_id= by the server whose section the
+// table is in, and ?section=mine (or a section ID) by section.
 func (h *TableHandler) GetTables(c *gin.Context) {
 	location := c.Query("location")
 
-	queryBuilder := `
-		SELECT t.id, t.table_number, t.seating_capacity, t.location, t.status,
-		       t.created_at, t.updated_at,
-		       o.id as order_id, o.order_number, o.customer_name, o.status as order_status,
-		       o.created_at as order_created_at, o.total_amount
-		FROM dining_tables t
+	queryBuilder := tableLayoutQuery + `
 		WHERE 1=1
 	`
 
@@ -36,10 +87,55 @@ func (h *TableHandler) GetTables(c *gin.Context) {
 
 	if location != "" {
 		argIndex++
-		queryBuilder += ` AND t.location ILIKE $` + string(rune(argIndex+'0'))
+		queryBuilder += fmt.Sprintf(` AND t.location ILIKE $%d`, argIndex)
 		args = append(args, "%"+location+"%")
 	}
 
+	if value := c.Query("server_id"); value != "" {
+		serverID, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid server ID",
+				Error:   stringPtr("invalid_uuid"),
+			})
+			return
+		}
+		argIndex++
+		queryBuilder += fmt.Sprintf(` AND s.server_id = $%d`, argIndex)
+		args = append(args, serverID)
+	}
+
+	if value := c.Query("section"); value != "" {
+		if value == "mine" {
+			userID, _, _, ok := middleware.GetUserFromContext(c)
+			if !ok {
+				c.JSON(http.StatusUnauthorized, models.APIResponse{
+					Success: false,
+					Message: "Authentication required",
+					Error:   stringPtr("auth_required"),
+				})
+				return
+			}
+			argIndex++
+			queryBuilder += fmt.Sprintf(` AND s.server_id = $%d`, argIndex)
+			args = append(args, userID)
+		} else {
+			sectionID, err := uuid.Parse(value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, models.APIResponse{
+					Success: false,
+					Message: "Invalid section. Use mine or a section ID",
+					Error:   stringPtr("invalid_section"),
+				})
+				return
+			}
+			argIndex++
+			queryBuilder += fmt.Sprintf(` AND s.id = $%d`, argIndex)
+			args = append(args, sectionID)
+		}
+	}
+
 	queryBuilder += ` ORDER BY t.table_number ASC`
 
 	rows, err := h.db.Query(queryBuilder, args...)
@@ -53,18 +149,9 @@ func (h *TableHandler) GetTables(c *gin.Context) {
 	}
 	defer rows.Close()
 
-	var tables []models.DiningTable
+	tables := []models.DiningTable{}
 	for rows.Next() {
-		var table models.DiningTable
-		var orderID, orderNumber, customerName, orderStatus sql.NullString
-		var orderCreatedAt sql.NullTime
-		var totalAmount sql.NullFloat64
-
-		err := rows.Scan(
-			&table.ID, &table.TableNumber, &table.SeatingCapacity, &table.Location, &table.Status,
-			&table.CreatedAt, &table.UpdatedAt,
-			&orderID, &orderNumber, &customerName, &orderStatus, &orderCreatedAt, &totalAmount,
-		)
+		table, err := scanTableLayout(rows)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.APIResponse{
 				Success: false,
@@ -73,39 +160,109 @@ func (h *TableHandler) GetTables(c *gin.Context) {
 			})
 			return
 		}
+		tables = append(tables, *table)
+	}
+	rows.Close()
+
+	if err := attachTableMembers(h.db, tables); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch group tables",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Tables retrieved successfully",
+		Data:    tables,
+	})
+}
+
+// GetFloorPlans returns the floor plan of each location with its tables laid
+// out on it. ?location= returns a single location.
+func (h *TableHandler) GetFloorPlans(c *gin.Context) {
+	query := `
+		SELECT id, location, name, width, height, background_url, created_at, updated_at
+		FROM floor_plans
+	`
+	args := []interface{}{}
+	if location := c.Query("location"); location != "" {
+		query += ` WHERE location = $1`
+		args = append(args, location)
+	}
+	query += ` ORDER BY location`
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch floor plans",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	defer rows.Close()
+
+	plans := []models.FloorPlan{}
+	for rows.Next() {
+		var plan models.FloorPlan
+		err := rows.Scan(&plan.ID, &plan.Location, &plan.Name, &plan.Width, &plan.Height,
+			&plan.BackgroundURL, &plan.CreatedAt, &plan.UpdatedAt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to scan floor plan",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+		plans = append(plans, plan)
+	}
+	rows.Close()
 
-		// Create a map to hold table data including current order info
-		tableData := map[string]interface{}{
-			"id":               table.ID,
-			"table_number":     table.TableNumber,
-			"seating_capacity": table.SeatingCapacity,
-			"location":         table.Location,
-			"status":           table.Status,
-			"created_at":       table.CreatedAt,
-			"updated_at":       table.UpdatedAt,
-			"current_order":    nil,
+	for i := range plans {
+		tableRows, err := h.db.Query(tableLayoutQuery+` WHERE t.location = $1 ORDER BY t.table_number`, plans[i].Location)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to fetch tables",
+				Error:   stringPtr(err.Error()),
+			})
+			return
 		}
 
-		// Add current order info if available
-		if orderID.Valid {
-			tableData["current_order"] = map[string]interface{}{
-				"id":            orderID.String,
-				"order_number":  orderNumber.String,
-				"customer_name": customerName.String,
-				"status":        orderStatus.String,
-				"created_at":    orderCreatedAt.Time,
-				"total_amount":  totalAmount.Float64,
+		plans[i].Tables = []models.DiningTable{}
+		for tableRows.Next() {
+			table, err := scanTableLayout(tableRows)
+			if err != nil {
+				tableRows.Close()
+				c.JSON(http.StatusInternalServerError, models.APIResponse{
+					Success: false,
+					Message: "Failed to scan table",
+					Error:   stringPtr(err.Error()),
+				})
+				return
 			}
+			plans[i].Tables = append(plans[i].Tables, *table)
 		}
+		tableRows.Close()
 
-		// Convert to table struct for consistent response
-		tables = append(tables, table)
+		if err := attachTableMembers(h.db, plans[i].Tables); err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to fetch group tables",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
 	}
 
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
-		Message: "Tables retrieved successfully",
-		Data:    tables,
+		Message: "Floor plans retrieved successfully",
+		Data:    plans,
 	})
 }
 
@@ -124,14 +281,14 @@ func (h *TableHandler) GetTable(c *gin.Context) {
 	var table models.DiningTable
 
 	query := `
-		SELECT id, table_number, seating_capacity, location, is_occupied, created_at, updated_at
+		SELECT id, table_number, seating_capacity, location, status, is_group, joined_to, created_at, updated_at
 		FROM dining_tables
 		WHERE id = $1
 	`
 
 	err = h.db.QueryRow(query, tableID).Scan(
 		&table.ID, &table.TableNumber, &table.SeatingCapacity, &table.Location,
-		&table.Status, &table.CreatedAt, &table.UpdatedAt,
+		&table.Status, &table.IsGroup, &table.JoinedTo, &table.CreatedAt, &table.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -152,6 +309,19 @@ func (h *TableHandler) GetTable(c *gin.Context) {
 		return
 	}
 
+	if table.IsGroup {
+		members, err := loadTableMembers(h.db, []uuid.UUID{table.ID})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to fetch group tables",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+		table.Members = members[table.ID]
+	}
+
 	// Get current active order for this table
 	var currentOrder *models.Order
 	orderQuery := `
@@ -183,6 +353,9 @@ func (h *TableHandler) GetTable(c *gin.Context) {
 		"seating_capacity": table.SeatingCapacity,
 		"location":         table.Location,
 		"status":           table.Status,
+		"is_group":         table.IsGroup,
+		"joined_to":        table.JoinedTo,
+		"members":          table.Members,
 		"created_at":       table.CreatedAt,
 		"updated_at":       table.UpdatedAt,
 		"current_order":    currentOrder,
@@ -267,16 +440,21 @@ func (h *TableHandler) GetTablesByLocation(c *gin.Context) {
 	})
 }
 
-// GetTableStatus retrieves the status overview of all tables
+// GetTableStatus retrieves the status overview of all tables. Tables pushed
+// together count with the status of their group, and the group itself is not
+// counted as a table of its own.
 func (h *TableHandler) GetTableStatus(c *gin.Context) {
 	query := `
 		SELECT 
 		    COUNT(*) as total,
-		    COALESCE(location, 'main_floor') as location,
-				SUM(CASE WHEN status != 'available' THEN 1 ELSE 0 END) as occupied,
-				SUM(CASE WHEN status == 'available' THEN 1 ELSE 0 END) as available,
-		FROM dining_tables
-		GROUP BY COALESCE(location, 'main_floor')
+		    COALESCE(t.location, 'main_floor') as location,
+				SUM(CASE WHEN COALESCE(g.status, t.status) != 'available' THEN 1 ELSE 0 END) as occupied,
+				SUM(CASE WHEN COALESCE(g.status, t.status) = 'available' THEN 1 ELSE 0 END) as available,
+				SUM(CASE WHEN t.joined_to IS NOT NULL THEN 1 ELSE 0 END) as joined
+		FROM dining_tables t
+		LEFT JOIN dining_tables g ON t.joined_to = g.id
+		WHERE t.is_group = false
+		GROUP BY COALESCE(t.location, 'main_floor')
 		ORDER BY location
 	`
 
@@ -292,13 +470,13 @@ func (h *TableHandler) GetTableStatus(c *gin.Context) {
 	defer rows.Close()
 
 	var locationStats []map[string]interface{}
-	var totalTables, totalOccupied, totalAvailable int
+	var totalTables, totalOccupied, totalAvailable, totalJoined int
 
 	for rows.Next() {
-		var total, occupied, available int
+		var total, occupied, available, joined int
 		var location string
 
-		err := rows.Scan(&total, &location, &occupied, &available)
+		err := rows.Scan(&total, &location, &occupied, &available, &joined)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.APIResponse{
 				Success: false,
@@ -313,19 +491,22 @@ func (h *TableHandler) GetTableStatus(c *gin.Context) {
 			"total_tables":     total,
 			"occupied_tables":  occupied,
 			"available_tables": available,
-			"occupancy_rate":   float64(occupied) / float64(total) * 100,
+			"joined_tables":    joined,
+			"occupancy_rate":   percentage(float64(occupied), float64(total)),
 		})
 
 		totalTables += total
 		totalOccupied += occupied
 		totalAvailable += available
+		totalJoined += joined
 	}
 
 	response := map[string]interface{}{
 		"total_tables":     totalTables,
 		"occupied_tables":  totalOccupied,
 		"available_tables": totalAvailable,
-		"occupancy_rate":   float64(totalOccupied) / float64(totalTables) * 100,
+		"joined_tables":    totalJoined,
+		"occupancy_rate":   percentage(float64(totalOccupied), float64(totalTables)),
 		"by_location":      locationStats,
 	}
 
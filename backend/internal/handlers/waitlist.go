@@ -0,0 +1,995 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"pos-backend/internal/middleware"
+	"pos-backend/internal/models"
+	"pos-backend/internal/sms"
+	"pos-backend/internal/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultTurnMinutes is the assumed table turn time until enough orders have completed
+	defaultTurnMinutes = 60
+	// turnHistoryDays is how far back completed orders are used for turn times
+	turnHistoryDays = 28
+	// minTurnSamples is the number of completed orders needed before their turn time is trusted
+	minTurnSamples = 5
+	// overdueTurnMinutes is how much longer a table that has run past its turn time is expected to take
+	overdueTurnMinutes = 5
+	// quoteStepMinutes is what quoted waits are rounded up to
+	quoteStepMinutes = 5
+)
+
+// waitlistRemoveReasons are the reasons a party can be taken off the waitlist
+var waitlistRemoveReasons = map[string]bool{
+	"left":        true,
+	"no_response": true,
+	"declined":    true,
+	"other":       true,
+}
+
+type WaitlistHandler struct {
+	db  *sql.DB
+	sms sms.Provider
+}
+
+func NewWaitlistHandler(db *sql.DB, provider sms.Provider) *WaitlistHandler {
+	return &WaitlistHandler{db: db, sms: provider}
+}
+
+// waitlistQuery selects waitlist entries with their table number for scanWaitlistEntry
+const waitlistQuery = `
+	SELECT w.id, w.party_name, w.party_size, w.phone, w.notes, w.status, w.quoted_wait_minutes,
+	       w.table_id, t.table_number, w.order_id, w.remove_reason, w.notify_count, w.created_by,
+	       w.added_at, w.notified_at, w.seated_at, w.removed_at, w.created_at, w.updated_at
+	FROM waitlist_entries w
+	LEFT JOIN dining_tables t ON w.table_id = t.id
+`
+
+func scanWaitlistEntry(row rowScanner) (*models.WaitlistEntry, error) {
+	var entry models.WaitlistEntry
+	err := row.Scan(
+		&entry.ID, &entry.PartyName, &entry.PartySize, &entry.Phone, &entry.Notes, &entry.Status,
+		&entry.QuotedWaitMinutes, &entry.TableID, &entry.TableNumber, &entry.OrderID, &entry.RemoveReason,
+		&entry.NotifyCount, &entry.CreatedBy, &entry.AddedAt, &entry.NotifiedAt, &entry.SeatedAt,
+		&entry.RemovedAt, &entry.CreatedAt, &entry.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// tableOccupancy is a table that can be given to a walk-in and how long it has been in use
+type tableOccupancy struct {
+	capacity   int
+	free       bool
+	occupiedAt *time.Time
+}
+
+// waitEstimator quotes waits from the tables in use and how long parties keep
+// a table. Turn times are cached per party size, so one estimator can quote a
+// whole waitlist.
+type waitEstimator struct {
+	q      queryer
+	now    time.Time
+	tables []tableOccupancy
+	turns  map[int]float64
+}
+
+// newWaitEstimator loads the tables that are in service and not held for a reservation
+func newWaitEstimator(q queryer) (*waitEstimator, error) {
+	// A party seated before their order is taken holds the table from when it
+	// was marked occupied
+	rows, err := q.Query(`
+		SELECT t.seating_capacity, t.status,
+		       COALESCE((SELECT MIN(o.created_at) FROM orders o
+		                 WHERE o.table_id = t.id AND o.status NOT IN ('completed', 'cancelled')),
+		                CASE WHEN t.status = 'occupied' THEN t.status_changed_at END)
+		FROM dining_tables t
+		WHERE t.status <> 'out_of_service' AND t.reserved_for IS NULL AND t.status <> 'reserved'
+		  AND t.is_group = false AND t.joined_to IS NULL
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	estimator := &waitEstimator{q: q, now: time.Now(), turns: map[int]float64{}}
+	for rows.Next() {
+		var table tableOccupancy
+		var status string
+		if err := rows.Scan(&table.capacity, &status, &table.occupiedAt); err != nil {
+			return nil, err
+		}
+		table.free = status == "available" && table.occupiedAt == nil
+		estimator.tables = append(estimator.tables, table)
+	}
+	return estimator, rows.Err()
+}
+
+// turnMinutes returns how long parties of the size keep a table on average,
+// from dine-in orders completed over the last turnHistoryDays days at tables
+// that could seat them. Without enough history all tables are used, and
+// failing that defaultTurnMinutes.
+func (e *waitEstimator) turnMinutes(partySize int) (float64, error) {
+	if turn, ok := e.turns[partySize]; ok {
+		return turn, nil
+	}
+
+	turn := float64(defaultTurnMinutes)
+	for _, minCapacity := range []int{partySize, 0} {
+		var average float64
+		var samples int
+		err := e.q.QueryRow(`
+			SELECT COALESCE(AVG(EXTRACT(EPOCH FROM o.completed_at - o.created_at)) / 60, 0), COUNT(*)
+			FROM orders o
+			JOIN dining_tables t ON o.table_id = t.id
+			WHERE o.status = 'completed'
+			  AND o.completed_at IS NOT NULL
+			  AND t.seating_capacity >= $1
+			  AND o.created_at >= CURRENT_TIMESTAMP - $2::float8 * INTERVAL '1 day'
+		`, minCapacity, turnHistoryDays).Scan(&average, &samples)
+		if err != nil {
+			return 0, err
+		}
+		if samples >= minTurnSamples && average > 0 {
+			turn = average
+			break
+		}
+	}
+
+	e.turns[partySize] = turn
+	return turn, nil
+}
+
+// quote estimates the wait in minutes for a party with the given parties ahead
+// of it. ok is false when no table in service can seat the party.
+func (e *waitEstimator) quote(partySize int, ahead []int) (int, bool, error) {
+	turn, err := e.turnMinutes(partySize)
+	if err != nil {
+		return 0, false, err
+	}
+
+	remaining := []float64{}
+	maxCapacity := 0
+	for _, table := range e.tables {
+		if table.capacity < partySize {
+			continue
+		}
+		if table.capacity > maxCapacity {
+			maxCapacity = table.capacity
+		}
+		switch {
+		case table.free:
+			remaining = append(remaining, 0)
+		case table.occupiedAt == nil:
+			// Not seated but not free: being cleared or waiting to be reset
+			remaining = append(remaining, overdueTurnMinutes)
+		default:
+			left := turn - e.now.Sub(*table.occupiedAt).Minutes()
+			remaining = append(remaining, math.Max(left, overdueTurnMinutes))
+		}
+	}
+	if len(remaining) == 0 {
+		return 0, false, nil
+	}
+
+	// Only parties that could take one of the same tables are ahead of this one
+	queued := 0
+	for _, size := range ahead {
+		if size <= maxCapacity {
+			queued++
+		}
+	}
+
+	return quoteWait(remaining, queued, turn), true, nil
+}
+
+// quoteWait returns when the table the party will get frees up: each party
+// ahead takes the next table to free up, and a table handed out frees again
+// a turn later. The result is rounded up to quoteStepMinutes.
+func quoteWait(remaining []float64, ahead int, turn float64) int {
+	sort.Float64s(remaining)
+	n := len(remaining)
+	wait := remaining[ahead%n] + turn*float64(ahead/n)
+	if wait <= 0 {
+		return 0
+	}
+	return int(math.Ceil(wait/quoteStepMinutes)) * quoteStepMinutes
+}
+
+// GetWaitlist returns the parties still waiting in the order they were added,
+// with their position, time waited so far and a fresh wait estimate
+func (h *WaitlistHandler) GetWaitlist(c *gin.Context) {
+	rows, err := h.db.Query(waitlistQuery + `
+		WHERE w.status IN ('waiting', 'notified')
+		ORDER BY w.added_at
+	`)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch waitlist",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	defer rows.Close()
+
+	entries := []models.WaitlistEntry{}
+	for rows.Next() {
+		entry, err := scanWaitlistEntry(rows)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to scan waitlist entry",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+		entries = append(entries, *entry)
+	}
+	rows.Close()
+
+	estimator, err := newWaitEstimator(h.db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to estimate waits",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	ahead := []int{}
+	for i := range entries {
+		entries[i].Position = i + 1
+		entries[i].WaitedMinutes = int(time.Since(entries[i].AddedAt).Minutes())
+		wait, ok, err := estimator.quote(entries[i].PartySize, ahead)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to estimate waits",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+		if ok {
+			entries[i].EstimatedWaitMinutes = &wait
+		}
+		ahead = append(ahead, entries[i].PartySize)
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Waitlist retrieved successfully",
+		Data:    entries,
+	})
+}
+
+// QuoteWait estimates the wait for a party of ?party_size= joining the waitlist now
+func (h *WaitlistHandler) QuoteWait(c *gin.Context) {
+	partySize, err := strconv.Atoi(c.Query("party_size"))
+	if err != nil || partySize < 1 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "party_size must be a positive number",
+			Error:   stringPtr("invalid_party_size"),
+		})
+		return
+	}
+
+	wait, ok, ahead, err := h.quoteNewParty(h.db, partySize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to estimate wait",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "No table can seat a party of " + strconv.Itoa(partySize),
+			Error:   stringPtr("party_too_large"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Wait time estimated successfully",
+		Data: map[string]interface{}{
+			"party_size":          partySize,
+			"parties_ahead":       ahead,
+			"quoted_wait_minutes": wait,
+		},
+	})
+}
+
+// quoteNewParty estimates the wait for a party joining the end of the waitlist
+func (h *WaitlistHandler) quoteNewParty(q queryer, partySize int) (int, bool, int, error) {
+	rows, err := q.Query(`
+		SELECT party_size FROM waitlist_entries
+		WHERE status IN ('waiting', 'notified')
+		ORDER BY added_at
+	`)
+	if err != nil {
+		return 0, false, 0, err
+	}
+	defer rows.Close()
+
+	ahead := []int{}
+	for rows.Next() {
+		var size int
+		if err := rows.Scan(&size); err != nil {
+			return 0, false, 0, err
+		}
+		ahead = append(ahead, size)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, false, 0, err
+	}
+	rows.Close()
+
+	estimator, err := newWaitEstimator(q)
+	if err != nil {
+		return 0, false, 0, err
+	}
+	wait, ok, err := estimator.quote(partySize, ahead)
+	return wait, ok, len(ahead), err
+}
+
+// AddToWaitlist adds a walk-in party and quotes its wait
+func (h *WaitlistHandler) AddToWaitlist(c *gin.Context) {
+	userID, _, _, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Authentication required",
+			Error:   stringPtr("auth_required"),
+		})
+		return
+	}
+
+	var req models.CreateWaitlistEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	if strings.TrimSpace(req.PartyName) == "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Party name is required",
+			Error:   stringPtr("invalid_party_name"),
+		})
+		return
+	}
+	if req.PartySize < 1 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Party size must be at least 1",
+			Error:   stringPtr("invalid_party_size"),
+		})
+		return
+	}
+
+	wait, fits, _, err := h.quoteNewParty(h.db, req.PartySize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to estimate wait",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	if !fits {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "No table can seat a party of " + strconv.Itoa(req.PartySize),
+			Error:   stringPtr("party_too_large"),
+		})
+		return
+	}
+
+	var entryID uuid.UUID
+	err = h.db.QueryRow(`
+		INSERT INTO waitlist_entries (party_name, party_size, phone, notes, quoted_wait_minutes, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, strings.TrimSpace(req.PartyName), req.PartySize, req.Phone, req.Notes, wait, userID).Scan(&entryID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to add party to waitlist",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	entry, err := scanWaitlistEntry(h.db.QueryRow(waitlistQuery+` WHERE w.id = $1`, entryID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch waitlist entry",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Party added to waitlist",
+		Data:    entry,
+	})
+}
+
+// NotifyParty texts a waiting party that their table is ready
+func (h *WaitlistHandler) NotifyParty(c *gin.Context) {
+	userID, _, _, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Authentication required",
+			Error:   stringPtr("auth_required"),
+		})
+		return
+	}
+
+	entry, ok := h.activeEntry(c)
+	if !ok {
+		return
+	}
+	if entry.Phone == nil || strings.TrimSpace(*entry.Phone) == "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Party has no phone number",
+			Error:   stringPtr("phone_required"),
+		})
+		return
+	}
+
+	var req models.NotifyWaitlistEntryRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid request body",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+	}
+
+	body := ""
+	if req.Message != nil && strings.TrimSpace(*req.Message) != "" {
+		body = strings.TrimSpace(*req.Message)
+	} else {
+		settings, err := loadSettings(h.db)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to load settings",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+		body = fmt.Sprintf("Hi %s, your table at %s is ready. Please come to the host stand.", entry.PartyName, settings.Name)
+	}
+
+	result, sendErr := h.sms.Send(context.Background(), sms.Message{To: *entry.Phone, Body: body})
+	status := "sent"
+	var messageID, errorMessage *string
+	if sendErr != nil {
+		status = "failed"
+		errorMessage = stringPtr(sendErr.Error())
+	} else {
+		messageID = &result.MessageID
+	}
+
+	_, err := h.db.Exec(`
+		INSERT INTO waitlist_notifications (entry_id, phone, body, provider, provider_message_id, status, error_message, sent_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, entry.ID, *entry.Phone, body, h.sms.Name(), messageID, status, errorMessage, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to record notification",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	if sendErr != nil {
+		c.JSON(http.StatusBadGateway, models.APIResponse{
+			Success: false,
+			Message: "Failed to send text message",
+			Error:   stringPtr(sendErr.Error()),
+		})
+		return
+	}
+
+	_, err = h.db.Exec(`
+		UPDATE waitlist_entries
+		SET status = 'notified', notified_at = CURRENT_TIMESTAMP, notify_count = notify_count + 1,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, entry.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to update waitlist entry",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Party notified",
+		Data: map[string]interface{}{
+			"message_id": result.MessageID,
+			"provider":   h.sms.Name(),
+		},
+	})
+}
+
+// SeatParty seats a waiting party at a table and marks the table occupied. The
+// order the server takes for them is linked to the entry by CreateOrder.
+func (h *WaitlistHandler) SeatParty(c *gin.Context) {
+	if _, _, _, ok := middleware.GetUserFromContext(c); !ok {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Authentication required",
+			Error:   stringPtr("auth_required"),
+		})
+		return
+	}
+
+	var req models.SeatWaitlistEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	entry, ok := h.activeEntry(c)
+	if !ok {
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to start transaction",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRow("SELECT status FROM waitlist_entries WHERE id = $1 FOR UPDATE", entry.ID).Scan(&status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch waitlist entry",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	if status != "waiting" && status != "notified" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Party is no longer waiting - entry is " + status,
+			Error:   stringPtr("invalid_waitlist_status"),
+		})
+		return
+	}
+
+	var tableStatus string
+	var capacity int
+	err = tx.QueryRow("SELECT status, seating_capacity FROM dining_tables WHERE id = $1 FOR UPDATE", req.TableID).Scan(&tableStatus, &capacity)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Table not found",
+			Error:   stringPtr("table_not_found"),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch table",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	if tableStatus != "available" {
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: "Table is " + strings.ReplaceAll(tableStatus, "_", " "),
+			Error:   stringPtr("table_unavailable"),
+		})
+		return
+	}
+	if capacity < entry.PartySize {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Table is too small for the party",
+			Error:   stringPtr("table_too_small"),
+		})
+		return
+	}
+
+	if err := seatTable(tx, req.TableID); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to update table status",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	_, err = tx.Exec(`
+		UPDATE waitlist_entries
+		SET status = 'seated', table_id = $1, seated_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+	`, req.TableID, entry.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to update waitlist entry",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to commit transaction",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Party seated successfully",
+		Data: map[string]interface{}{
+			"waitlist_entry_id": entry.ID,
+			"table_id":          req.TableID,
+			"waited_minutes":    int(time.Since(entry.AddedAt).Minutes()),
+		},
+	})
+}
+
+// seatedWaitlistEntry locks a seated waitlist entry that has no order yet.
+// sql.ErrNoRows means there is no such entry.
+func seatedWaitlistEntry(tx *sql.Tx, entryID uuid.UUID) (seatedParty, error) {
+	var party seatedParty
+	err := tx.QueryRow(`
+		SELECT table_id, party_name, party_size, notes
+		FROM waitlist_entries
+		WHERE id = $1 AND status = 'seated' AND order_id IS NULL
+		FOR UPDATE
+	`, entryID).Scan(&party.TableID, &party.Name, &party.Size, &party.Notes)
+	return party, err
+}
+
+// RemoveParty takes a party off the waitlist without seating them
+func (h *WaitlistHandler) RemoveParty(c *gin.Context) {
+	var req models.RemoveWaitlistEntryRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid request body",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+	}
+	if req.Reason == "" {
+		req.Reason = "left"
+	}
+	if !waitlistRemoveReasons[req.Reason] {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid reason. Use left, no_response, declined or other",
+			Error:   stringPtr("invalid_reason"),
+		})
+		return
+	}
+
+	entry, ok := h.activeEntry(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.db.Exec(`
+		UPDATE waitlist_entries
+		SET status = 'removed', remove_reason = $1, removed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND status IN ('waiting', 'notified')
+	`, req.Reason, entry.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to remove party",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Party is no longer waiting",
+			Error:   stringPtr("invalid_waitlist_status"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Party removed from waitlist",
+	})
+}
+
+// activeEntry loads the waitlist entry in the :id parameter, responding with
+// an error unless the party is still waiting
+func (h *WaitlistHandler) activeEntry(c *gin.Context) (*models.WaitlistEntry, bool) {
+	entryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid waitlist entry ID",
+			Error:   stringPtr("invalid_uuid"),
+		})
+		return nil, false
+	}
+
+	entry, err := scanWaitlistEntry(h.db.QueryRow(waitlistQuery+` WHERE w.id = $1`, entryID))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Waitlist entry not found",
+			Error:   stringPtr("waitlist_entry_not_found"),
+		})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch waitlist entry",
+			Error:   stringPtr(err.Error()),
+		})
+		return nil, false
+	}
+	if entry.Status != "waiting" && entry.Status != "notified" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Party is no longer waiting - entry is " + entry.Status,
+			Error:   stringPtr("invalid_waitlist_status"),
+		})
+		return nil, false
+	}
+	return entry, true
+}
+
+// GetWaitlistMetrics reports on parties added between ?from= and ?to=
+// (YYYY-MM-DD, inclusive, default the last 7 days): how many were seated or
+// walked away, how long they actually waited compared with their quote, and
+// the same broken down by hour of the day and party size
+func (h *WaitlistHandler) GetWaitlistMetrics(c *gin.Context) {
+	location, err := util.StoreLocation(h.db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to load settings",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	start, end, err := parseReportRange(c, location, 7)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: err.Error(),
+			Error:   stringPtr("invalid_date"),
+		})
+		return
+	}
+
+	var parties, seated, removed, waiting, covers int
+	var avgQuoted, avgWaited, avgError, withinQuote float64
+	err = h.db.QueryRow(`
+		SELECT COUNT(*),
+		       COUNT(*) FILTER (WHERE status = 'seated'),
+		       COUNT(*) FILTER (WHERE status = 'removed'),
+		       COUNT(*) FILTER (WHERE status IN ('waiting', 'notified')),
+		       COALESCE(SUM(party_size) FILTER (WHERE status = 'seated'), 0),
+		       COALESCE(AVG(quoted_wait_minutes), 0),
+		       COALESCE(AVG(EXTRACT(EPOCH FROM seated_at - added_at) / 60) FILTER (WHERE status = 'seated'), 0),
+		       COALESCE(AVG(EXTRACT(EPOCH FROM seated_at - added_at) / 60 - quoted_wait_minutes) FILTER (WHERE status = 'seated'), 0),
+		       COALESCE(AVG(CASE WHEN seated_at - added_at <= quoted_wait_minutes * INTERVAL '1 minute' THEN 1.0 ELSE 0.0 END)
+		                FILTER (WHERE status = 'seated'), 0)
+		FROM waitlist_entries
+		WHERE added_at >= $1 AND added_at < $2
+	`, start, end).Scan(&parties, &seated, &removed, &waiting, &covers, &avgQuoted, &avgWaited, &avgError, &withinQuote)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to calculate waitlist metrics",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	removeReasons := map[string]int{}
+	reasonRows, err := h.db.Query(`
+		SELECT COALESCE(remove_reason, 'other'), COUNT(*)
+		FROM waitlist_entries
+		WHERE status = 'removed' AND added_at >= $1 AND added_at < $2
+		GROUP BY 1
+	`, start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to calculate waitlist metrics",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	defer reasonRows.Close()
+	for reasonRows.Next() {
+		var reason string
+		var count int
+		if err := reasonRows.Scan(&reason, &count); err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to scan waitlist metrics",
+				Error:   stringPtr(err.Error()),
+			})
+			return
+		}
+		removeReasons[reason] = count
+	}
+
+	var sent, failed int
+	err = h.db.QueryRow(`
+		SELECT COUNT(*) FILTER (WHERE n.status = 'sent'), COUNT(*) FILTER (WHERE n.status = 'failed')
+		FROM waitlist_notifications n
+		JOIN waitlist_entries w ON n.entry_id = w.id
+		WHERE w.added_at >= $1 AND w.added_at < $2
+	`, start, end).Scan(&sent, &failed)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to calculate waitlist metrics",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	byHour, err := waitlistBreakdown(h.db, `EXTRACT(HOUR FROM added_at AT TIME ZONE $3)::int`, start, end, location.String())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to calculate waitlist metrics",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+	byPartySize, err := waitlistBreakdown(h.db, `party_size`, start, end, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to calculate waitlist metrics",
+			Error:   stringPtr(err.Error()),
+		})
+		return
+	}
+
+	walkAwayRate := 0.0
+	if parties > 0 {
+		walkAwayRate = math.Round(float64(removed)/float64(parties)*1000) / 10
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Waitlist metrics retrieved successfully",
+		Data: map[string]interface{}{
+			"from":     start.Format("2006-01-02"),
+			"to":       end.AddDate(0, 0, -1).Format("2006-01-02"),
+			"timezone": location.String(),
+			"totals": map[string]interface{}{
+				"parties":                  parties,
+				"seated":                   seated,
+				"removed":                  removed,
+				"still_waiting":            waiting,
+				"covers_seated":            covers,
+				"walk_away_rate":           walkAwayRate,
+				"avg_quoted_minutes":       roundMinutes(avgQuoted),
+				"avg_waited_minutes":       roundMinutes(avgWaited),
+				"avg_quote_error_minutes":  roundMinutes(avgError),
+				"seated_within_quote_rate": math.Round(withinQuote*1000) / 10,
+				"notifications_sent":       sent,
+				"notifications_failed":     failed,
+			},
+			"remove_reasons": removeReasons,
+			"by_hour":        byHour,
+			"by_party_size":  byPartySize,
+		},
+	})
+}
+
+// waitlistBreakdown groups the parties added in the range by a SQL expression.
+// The expression may use $3, bound to extra when it is not nil.
+func waitlistBreakdown(q queryer, groupExpr string, start, end time.Time, extra interface{}) ([]map[string]interface{}, error) {
+	query := fmt.Sprintf(`
+		SELECT %s AS group_key,
+		       COUNT(*),
+		       COUNT(*) FILTER (WHERE status = 'seated'),
+		       COUNT(*) FILTER (WHERE status = 'removed'),
+		       COALESCE(AVG(quoted_wait_minutes), 0),
+		       COALESCE(AVG(EXTRACT(EPOCH FROM seated_at - added_at) / 60) FILTER (WHERE status = 'seated'), 0)
+		FROM waitlist_entries
+		WHERE added_at >= $1 AND added_at < $2
+		GROUP BY group_key
+		ORDER BY group_key
+	`, groupExpr)
+	args := []interface{}{start, end}
+	if extra != nil {
+		args = append(args, extra)
+	}
+
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := []map[string]interface{}{}
+	for rows.Next() {
+		var key, parties, seated, removed int
+		var avgQuoted, avgWaited float64
+		if err := rows.Scan(&key, &parties, &seated, &removed, &avgQuoted, &avgWaited); err != nil {
+			return nil, err
+		}
+		groups = append(groups, map[string]interface{}{
+			"key":                key,
+			"parties":            parties,
+			"seated":             seated,
+			"removed":            removed,
+			"avg_quoted_minutes": roundMinutes(avgQuoted),
+			"avg_waited_minutes": roundMinutes(avgWaited),
+		})
+	}
+	return groups, rows.Err()
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import "testing"
+
+func TestQuoteWait(t *testing.T) {
+	tests := []struct {
+		name      string
+		remaining []float64
+		ahead     int
+		want      int
+	}{
+		{"free table", []float64{0, 20}, 0, 0},
+		{"rounded up to the step", []float64{12, 3}, 0, 5},
+		{"next table to free up", []float64{12, 3}, 1, 15},
+		{"table handed out frees a turn later", []float64{12, 3}, 2, 65},
+		{"second round", []float64{12, 3}, 3, 75},
+		{"exact step", []float64{10}, 0, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := quoteWait(tt.remaining, tt.ahead, 60); got != tt.want {
+				t.Errorf("quoteWait(%v, %d, 60) = %d, want %d", tt.remaining, tt.ahead, got, tt.want)
+			}
+		})
+	}
+}
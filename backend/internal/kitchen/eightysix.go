@@ -0,0 +1,105 @@
+package kitchen
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"pos-backend/internal/events"
+	"pos-backend/internal/util"
+
+	"github.com/google/uuid"
+)
+
+// Restorer puts 86'd products back on the menu when their restore time passes
+// and clears the whole 86 list once a day at ResetTime, in the restaurant's
+// timezone, so a new service never starts with yesterday's sold out items.
+type Restorer struct {
+	db        *sql.DB
+	Interval  time.Duration
+	ResetTime string // HH:MM
+}
+
+// NewRestorer creates a restorer configured from KITCHEN_86_INTERVAL and KITCHEN_86_RESET_TIME
+func NewRestorer(db *sql.DB) *Restorer {
+	return &Restorer{
+		db:        db,
+		Interval:  util.DurationFromEnv("KITCHEN_86_INTERVAL", time.Minute),
+		ResetTime: util.FromEnv("KITCHEN_86_RESET_TIME", "04:00"),
+	}
+}
+
+// Start runs the restorer in the background until ctx is cancelled
+func (r *Restorer) Start(ctx context.Context) {
+	util.RunEvery(ctx, r.Interval, "86 list restorer", r.restore)
+}
+
+// lastReset returns the most recent daily reset at or before now
+func lastReset(now time.Time, resetTime string, location *time.Location) time.Time {
+	clock, err := time.Parse("15:04", resetTime)
+	if err != nil {
+		clock, _ = time.Parse("15:04", "04:00")
+	}
+
+	local := now.In(location)
+	reset := time.Date(local.Year(), local.Month(), local.Day(), clock.Hour(), clock.Minute(), 0, 0, location)
+	if reset.After(local) {
+		reset = reset.AddDate(0, 0, -1)
+	}
+	return reset
+}
+
+// restore removes expired entries and entries marked before the last daily
+// reset, publishing a product.restored event for each
+func (r *Restorer) restore() error {
+	location, err := util.StoreLocation(r.db)
+	if err != nil {
+		return err
+	}
+	reset := lastReset(time.Now(), r.ResetTime, location)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		DELETE FROM product_86
+		WHERE restore_at <= CURRENT_TIMESTAMP OR created_at < $1
+		RETURNING product_id, COALESCE(restore_at <= CURRENT_TIMESTAMP, false)
+	`, reset)
+	if err != nil {
+		return err
+	}
+
+	triggers := map[uuid.UUID]string{}
+	var productIDs []uuid.UUID
+	for rows.Next() {
+		var productID uuid.UUID
+		var expired bool
+		if err := rows.Scan(&productID, &expired); err != nil {
+			rows.Close()
+			return err
+		}
+		productIDs = append(productIDs, productID)
+		triggers[productID] = "daily_reset"
+		if expired {
+			triggers[productID] = "restore_time"
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, productID := range productIDs {
+		if err := events.PublishProduct(tx, events.ProductRestored, productID, map[string]interface{}{
+			"trigger": triggers[productID],
+		}); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,106 @@
+package kitchen
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"pos-backend/internal/events"
+	"pos-backend/internal/util"
+
+	"github.com/google/uuid"
+)
+
+// LateThreshold is the grace period, configured with KITCHEN_LATE_THRESHOLD, after
+// an item's expected preparation time before its ticket is flagged late
+func LateThreshold() time.Duration {
+	return util.DurationFromEnv("KITCHEN_LATE_THRESHOLD", 5*time.Minute)
+}
+
+// IsLate reports whether an item that is still being prepared has run over.
+// Items without an expected preparation time are never late.
+func IsLate(status string, expectedMinutes int, firedAt, now time.Time, threshold time.Duration) bool {
+	if (status != "pending" && status != "preparing") || expectedMinutes <= 0 {
+		return false
+	}
+	return now.Sub(firedAt) > time.Duration(expectedMinutes)*time.Minute+threshold
+}
+
+// Watcher flags items that run over their expected preparation time and
+// publishes an order.late event once per item, so kitchen displays can
+// highlight the ticket without polling.
+type Watcher struct {
+	db        *sql.DB
+	Interval  time.Duration
+	Threshold time.Duration
+}
+
+// NewWatcher creates a watcher configured from KITCHEN_LATE_INTERVAL and KITCHEN_LATE_THRESHOLD
+func NewWatcher(db *sql.DB) *Watcher {
+	return &Watcher{
+		db:        db,
+		Interval:  util.DurationFromEnv("KITCHEN_LATE_INTERVAL", 30*time.Second),
+		Threshold: LateThreshold(),
+	}
+}
+
+// Start runs the watcher in the background until ctx is cancelled
+func (w *Watcher) Start(ctx context.Context) {
+	util.RunEvery(ctx, w.Interval, "late ticket watcher", w.flagLate)
+}
+
+// flagLate marks newly late items and publishes one event per order. Marking and
+// publishing share a transaction, so with several instances each item is
+// reported exactly once.
+func (w *Watcher) flagLate() error {
+	tx, err := w.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		UPDATE order_items oi
+		SET late_at = CURRENT_TIMESTAMP
+		FROM orders o
+		WHERE oi.order_id = o.id
+		  AND oi.item_type = 'product'
+		  AND oi.status IN ('pending', 'preparing')
+		  AND oi.late_at IS NULL
+		  AND oi.expected_prep_time > 0
+		  AND o.status NOT IN ('completed', 'cancelled')
+		  AND oi.created_at + oi.expected_prep_time * INTERVAL '1 minute' + $1::float8 * INTERVAL '1 second' < CURRENT_TIMESTAMP
+		RETURNING oi.order_id, oi.id
+	`, w.Threshold.Seconds())
+	if err != nil {
+		return err
+	}
+
+	lateItems := map[uuid.UUID][]uuid.UUID{}
+	var orderIDs []uuid.UUID
+	for rows.Next() {
+		var orderID, itemID uuid.UUID
+		if err := rows.Scan(&orderID, &itemID); err != nil {
+			rows.Close()
+			return err
+		}
+		if _, ok := lateItems[orderID]; !ok {
+			orderIDs = append(orderIDs, orderID)
+		}
+		lateItems[orderID] = append(lateItems[orderID], itemID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, orderID := range orderIDs {
+		if err := events.Publish(tx, events.OrderLate, orderID, map[string]interface{}{
+			"item_ids": lateItems[orderID],
+		}); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
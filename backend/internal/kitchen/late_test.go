@@ -0,0 +1,36 @@
+package kitchen
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsLate(t *testing.T) {
+	fired := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	threshold := 5 * time.Minute
+
+	tests := []struct {
+		name     string
+		status   string
+		expected int
+		elapsed  time.Duration
+		want     bool
+	}{
+		{"within prep time", "preparing", 10, 9 * time.Minute, false},
+		{"within threshold", "preparing", 10, 15 * time.Minute, false},
+		{"past threshold", "preparing", 10, 15*time.Minute + time.Second, true},
+		{"pending counts too", "pending", 10, 20 * time.Minute, true},
+		{"ready is never late", "ready", 10, time.Hour, false},
+		{"served is never late", "served", 10, time.Hour, false},
+		{"no expected prep time", "preparing", 0, time.Hour, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := IsLate(tt.status, tt.expected, fired, fired.Add(tt.elapsed), threshold)
+			if got != tt.want {
+				t.Errorf("IsLate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
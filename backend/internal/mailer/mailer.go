@@ -0,0 +1,157 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"pos-backend/internal/util"
+)
+
+// ErrNotConfigured is returned when no SMTP host has been configured
+var ErrNotConfigured = errors.New("smtp is not configured")
+
+// Message is a rendered email with plain text and HTML alternatives
+type Message struct {
+	FromName string
+	ReplyTo  string
+	To       string
+	Subject  string
+	Text     string
+	HTML     string
+}
+
+// Transport delivers a message
+type Transport interface {
+	Send(msg Message) error
+}
+
+// SMTPTransport delivers messages through an SMTP relay. STARTTLS is used
+// whenever the server offers it; authentication is skipped without a username.
+type SMTPTransport struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// NewSMTPTransportFromEnv configures the transport from SMTP_HOST, SMTP_PORT,
+// SMTP_USERNAME, SMTP_PASSWORD and SMTP_FROM. A local catcher such as MailHog
+// only needs SMTP_HOST=localhost and SMTP_PORT=1025.
+func NewSMTPTransportFromEnv() *SMTPTransport {
+	port, err := strconv.Atoi(util.FromEnv("SMTP_PORT", "587"))
+	if err != nil {
+		port = 587
+	}
+
+	return &SMTPTransport{
+		Host:     util.FromEnv("SMTP_HOST", ""),
+		Port:     port,
+		Username: util.FromEnv("SMTP_USERNAME", ""),
+		Password: util.FromEnv("SMTP_PASSWORD", ""),
+		From:     util.FromEnv("SMTP_FROM", "receipts@localhost"),
+	}
+}
+
+func (t *SMTPTransport) Send(msg Message) error {
+	if t.Host == "" {
+		return ErrNotConfigured
+	}
+
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient: %w", err)
+	}
+
+	body, err := t.build(msg, to)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if t.Username != "" {
+		auth = smtp.PlainAuth("", t.Username, t.Password, t.Host)
+	}
+
+	address := net.JoinHostPort(t.Host, strconv.Itoa(t.Port))
+	return smtp.SendMail(address, auth, t.From, []string{to.Address}, body)
+}
+
+// build encodes the message as multipart/alternative MIME
+func (t *SMTPTransport) build(msg Message, to *mail.Address) ([]byte, error) {
+	boundary, err := randomBoundary()
+	if err != nil {
+		return nil, err
+	}
+
+	from := mail.Address{Name: msg.FromName, Address: t.From}
+
+	var b bytes.Buffer
+	headers := []string{
+		"From: " + from.String(),
+		"To: " + to.String(),
+		"Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"Message-ID: <" + boundary + "@" + domain(t.From) + ">",
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + boundary,
+	}
+	if msg.ReplyTo != "" {
+		headers = append(headers, "Reply-To: "+msg.ReplyTo)
+	}
+	b.WriteString(strings.Join(headers, "\r\n") + "\r\n\r\n")
+
+	parts := []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	}
+	for _, part := range parts {
+		if part.body == "" {
+			continue
+		}
+		b.WriteString("--" + boundary + "\r\n")
+		b.WriteString("Content-Type: " + part.contentType + "\r\n")
+		b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+		w := quotedprintable.NewWriter(&b)
+		if _, err := w.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		b.WriteString("\r\n")
+	}
+	b.WriteString("--" + boundary + "--\r\n")
+
+	return b.Bytes(), nil
+}
+
+func randomBoundary() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func domain(address string) string {
+	if i := strings.LastIndex(address, "@"); i >= 0 {
+		return address[i+1:]
+	}
+	return "localhost"
+}
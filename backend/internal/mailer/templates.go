@@ -0,0 +1,121 @@
+package mailer
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+
+	"pos-backend/internal/models"
+	"pos-backend/internal/receipt"
+)
+
+// Template is an admin-editable email template. Subject and Text use
+// text/template; HTML uses html/template so order data is escaped.
+type Template struct {
+	Subject string `json:"subject"`
+	Text    string `json:"text_body"`
+	HTML    string `json:"html_body"`
+}
+
+// ReceiptData is passed to the receipt templates
+type ReceiptData struct {
+	Store       *models.Settings
+	Order       *models.Order
+	Receipt     *receipt.Document
+	ReceiptText string
+}
+
+// DefaultTemplates are used until an admin saves a custom version
+var DefaultTemplates = map[string]Template{
+	"receipt": {
+		Subject: `Your receipt from {{.Store.Name}} - order {{.Order.OrderNumber}}`,
+		Text: `Hi{{with .Order.CustomerName}} {{.}}{{end}},
+
+Thank you for your order. Your receipt is below.
+
+{{.ReceiptText}}
+`,
+		HTML: `<!DOCTYPE html>
+<html>
+<body style="font-family: Arial, sans-serif; color: #222;">
+<div style="max-width: 420px; margin: 0 auto;">
+  <p>Hi{{with .Order.CustomerName}} {{.}}{{end}},</p>
+  <p>Thank you for your order. Your receipt is below.</p>
+  <div style="font-family: monospace; border: 1px solid #ddd; padding: 16px;">
+    {{range .Receipt.Header}}<div style="text-align: center;">{{.}}</div>{{end}}
+    <hr>
+    <table style="width: 100%;">
+      {{range .Receipt.Meta}}<tr><td>{{.Label}}</td><td style="text-align: right;">{{.Value}}</td></tr>{{end}}
+    </table>
+    <hr>
+    <table style="width: 100%;">
+      {{range .Receipt.Items}}<tr><td>{{.Quantity}} x {{.Name}}{{range .Modifiers}}<br><small>+ {{.}}</small>{{end}}</td><td style="text-align: right; vertical-align: top;">{{.Amount}}</td></tr>{{end}}
+    </table>
+    <hr>
+    <table style="width: 100%;">
+      {{range .Receipt.Totals}}<tr{{if .Bold}} style="font-weight: bold;"{{end}}><td>{{.Label}}</td><td style="text-align: right;">{{.Value}}</td></tr>{{end}}
+      {{range .Receipt.Payments}}<tr{{if .Bold}} style="font-weight: bold;"{{end}}><td>{{.Label}}</td><td style="text-align: right;">{{.Value}}</td></tr>{{end}}
+    </table>
+    {{if .Receipt.Footer}}<hr>{{range .Receipt.Footer}}<div style="text-align: center;">{{.}}</div>{{end}}{{end}}
+  </div>
+</div>
+</body>
+</html>
+`,
+	},
+}
+
+// Validate parses every part of the template without executing it
+func (t Template) Validate() error {
+	if _, err := texttemplate.New("subject").Parse(t.Subject); err != nil {
+		return err
+	}
+	if _, err := texttemplate.New("text").Parse(t.Text); err != nil {
+		return err
+	}
+	_, err := htmltemplate.New("html").Parse(t.HTML)
+	return err
+}
+
+// Render executes the template and returns a message without sender or recipient
+func (t Template) Render(data interface{}) (Message, error) {
+	var msg Message
+
+	subject, err := renderText("subject", t.Subject, data)
+	if err != nil {
+		return msg, err
+	}
+	// Headers cannot span lines
+	msg.Subject = strings.Join(strings.Fields(subject), " ")
+
+	if msg.Text, err = renderText("text", t.Text, data); err != nil {
+		return msg, err
+	}
+
+	if t.HTML != "" {
+		tmpl, err := htmltemplate.New("html").Parse(t.HTML)
+		if err != nil {
+			return msg, err
+		}
+		var b bytes.Buffer
+		if err := tmpl.Execute(&b, data); err != nil {
+			return msg, err
+		}
+		msg.HTML = b.String()
+	}
+
+	return msg, nil
+}
+
+func renderText(name, text string, data interface{}) (string, error) {
+	tmpl, err := texttemplate.New(name).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
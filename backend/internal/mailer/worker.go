@@ -0,0 +1,128 @@
+package mailer
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"pos-backend/internal/util"
+
+	"github.com/google/uuid"
+)
+
+// Email job statuses shared with the email_jobs table
+const (
+	StatusPending = "pending"
+	StatusSending = "sending"
+	StatusSent    = "sent"
+	StatusFailed  = "failed"
+)
+
+// Worker drains the email_jobs queue, retrying failed deliveries with backoff
+type Worker struct {
+	db        *sql.DB
+	transport Transport
+	Interval  time.Duration
+}
+
+// NewWorker creates a worker that polls every EMAIL_POLL_INTERVAL
+func NewWorker(db *sql.DB, transport Transport) *Worker {
+	return &Worker{db: db, transport: transport, Interval: util.DurationFromEnv("EMAIL_POLL_INTERVAL", 5*time.Second)}
+}
+
+// Start runs the worker in the background until ctx is cancelled
+func (w *Worker) Start(ctx context.Context) {
+	// Jobs left in sending by a crashed process are handed back to the queue
+	if _, err := w.db.Exec("UPDATE email_jobs SET status = 'pending', updated_at = CURRENT_TIMESTAMP WHERE status = 'sending'"); err != nil {
+		log.Printf("email worker: failed to requeue interrupted jobs: %v", err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(w.Interval)
+		defer ticker.Stop()
+
+		for {
+			for {
+				processed, err := w.processNext()
+				if err != nil {
+					log.Printf("email worker: %v", err)
+					break
+				}
+				if !processed {
+					break
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// processNext claims and sends a single due job, reporting whether one was found
+func (w *Worker) processNext() (bool, error) {
+	var jobID uuid.UUID
+	var msg Message
+	var replyTo sql.NullString
+	var attempts, maxAttempts int
+
+	err := w.db.QueryRow(`
+		UPDATE email_jobs
+		SET status = 'sending', attempts = attempts + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = (
+		    SELECT id
+		    FROM email_jobs
+		    WHERE status = 'pending' AND next_attempt_at <= CURRENT_TIMESTAMP
+		    ORDER BY created_at
+		    LIMIT 1
+		    FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, from_name, reply_to, recipient, subject, text_body, html_body, attempts, max_attempts
+	`).Scan(&jobID, &msg.FromName, &replyTo, &msg.To, &msg.Subject, &msg.Text, &msg.HTML, &attempts, &maxAttempts)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to claim email job: %w", err)
+	}
+	msg.ReplyTo = replyTo.String
+
+	if err := w.transport.Send(msg); err != nil {
+		return true, w.fail(jobID, attempts, maxAttempts, err)
+	}
+
+	_, err = w.db.Exec(`
+		UPDATE email_jobs
+		SET status = 'sent', last_error = NULL, sent_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, jobID)
+	if err != nil {
+		return true, fmt.Errorf("failed to complete email job %s: %w", jobID, err)
+	}
+	return true, nil
+}
+
+// fail schedules a retry with quadratic backoff, or gives up after the last attempt
+func (w *Worker) fail(jobID uuid.UUID, attempts, maxAttempts int, cause error) error {
+	status := StatusPending
+	if attempts >= maxAttempts {
+		status = StatusFailed
+	}
+	backoff := time.Duration(attempts*attempts) * 30 * time.Second
+
+	_, err := w.db.Exec(`
+		UPDATE email_jobs
+		SET status = $2, last_error = $3, next_attempt_at = CURRENT_TIMESTAMP + $4::interval,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, jobID, status, cause.Error(), fmt.Sprintf("%d seconds", int(backoff.Seconds())))
+	if err != nil {
+		return fmt.Errorf("failed to reschedule email job %s: %w", jobID, err)
+	}
+	return nil
+}
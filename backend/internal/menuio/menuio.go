@@ -0,0 +1,177 @@
+// Package menuio reads and writes the menu as CSV for bulk export and import.
+// Each line is a category, product or modifier named by its type column; lists
+// such as allergens are separated by "|".
+package menuio
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"pos-backend/internal/models"
+)
+
+// Columns are the CSV columns, in the order they are written
+var Columns = []string{
+	"type", "name", "sku", "category", "product_sku", "description", "price", "barcode", "image_url",
+	"color", "preparation_time", "sort_order", "is_available", "allergens", "dietary_tags",
+}
+
+// listSeparator separates the values of list columns
+const listSeparator = "|"
+
+// Record is a line read from a CSV file. Err says why it could not be read;
+// the other lines are still read.
+type Record struct {
+	Line int
+	Row  models.MenuRow
+	Err  error
+}
+
+// WriteCSV writes rows with a header line
+func WriteCSV(w io.Writer, rows []models.MenuRow) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(Columns); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		isAvailable := ""
+		if row.IsAvailable != nil {
+			isAvailable = strconv.FormatBool(*row.IsAvailable)
+		}
+		err := writer.Write([]string{
+			row.Type, row.Name, row.SKU, row.Category, row.ProductSKU, row.Description,
+			strconv.FormatFloat(row.Price, 'f', 2, 64), row.Barcode, row.ImageURL, row.Color,
+			strconv.Itoa(row.PreparationTime), strconv.Itoa(row.SortOrder), isAvailable,
+			strings.Join(row.Allergens, listSeparator), strings.Join(row.DietaryTags, listSeparator),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// ReadCSV reads the lines of a CSV file written by WriteCSV. Columns are
+// matched by the header, so they can be in any order and all but type and
+// name can be left out. It fails only when the file itself cannot be read.
+func ReadCSV(r io.Reader) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	index := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\uFEFF")))
+		known := false
+		for _, column := range Columns {
+			if column == name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		index[name] = i
+	}
+	if _, ok := index["type"]; !ok {
+		return nil, errors.New("missing column \"type\"")
+	}
+	if _, ok := index["name"]; !ok {
+		return nil, errors.New("missing column \"name\"")
+	}
+
+	records := []Record{}
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, err
+			}
+			records = append(records, Record{Line: parseErr.StartLine, Err: parseErr.Err})
+			continue
+		}
+		if len(fields) == 1 && strings.TrimSpace(fields[0]) == "" {
+			continue
+		}
+
+		line, _ := reader.FieldPos(0)
+		record := Record{Line: line}
+		record.Row, record.Err = parseRow(fields, index)
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func parseRow(fields []string, index map[string]int) (models.MenuRow, error) {
+	value := func(column string) string {
+		i, ok := index[column]
+		if !ok || i >= len(fields) {
+			return ""
+		}
+		return strings.TrimSpace(fields[i])
+	}
+
+	row := models.MenuRow{
+		Type:        strings.ToLower(value("type")),
+		Name:        value("name"),
+		SKU:         value("sku"),
+		Category:    value("category"),
+		ProductSKU:  value("product_sku"),
+		Description: value("description"),
+		Barcode:     value("barcode"),
+		ImageURL:    value("image_url"),
+		Color:       value("color"),
+		Allergens:   splitList(value("allergens")),
+		DietaryTags: splitList(value("dietary_tags")),
+	}
+
+	var err error
+	if v := value("price"); v != "" {
+		if row.Price, err = strconv.ParseFloat(v, 64); err != nil {
+			return row, fmt.Errorf("invalid price %q", v)
+		}
+	}
+	if v := value("preparation_time"); v != "" {
+		if row.PreparationTime, err = strconv.Atoi(v); err != nil {
+			return row, fmt.Errorf("invalid preparation_time %q", v)
+		}
+	}
+	if v := value("sort_order"); v != "" {
+		if row.SortOrder, err = strconv.Atoi(v); err != nil {
+			return row, fmt.Errorf("invalid sort_order %q", v)
+		}
+	}
+	if v := value("is_available"); v != "" {
+		isAvailable, err := strconv.ParseBool(v)
+		if err != nil {
+			return row, fmt.Errorf("invalid is_available %q", v)
+		}
+		row.IsAvailable = &isAvailable
+	}
+	return row, nil
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, listSeparator)
+}
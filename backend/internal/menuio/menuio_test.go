@@ -0,0 +1,101 @@
+package menuio
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"pos-backend/internal/models"
+)
+
+func TestCSVRoundTrip(t *testing.T) {
+	available, unavailable := true, false
+	rows := []models.MenuRow{
+		{Type: "category", Name: "Drinks", Description: "Hot, cold and \"fizzy\"", Color: "#3366ff", SortOrder: 2, IsAvailable: &available},
+		{
+			Type: "product", Name: "Flat White", SKU: "COF-FW", Category: "Drinks", Description: "Double shot,\nsteamed milk",
+			Price: 4.5, Barcode: "0123456789", ImageURL: "https://example.com/fw.png", PreparationTime: 4, SortOrder: 1,
+			IsAvailable: &unavailable, Allergens: []string{"dairy"}, DietaryTags: []string{"vegetarian", "gluten_free"},
+		},
+		{Type: "modifier", Name: "Oat milk", ProductSKU: "COF-FW", Price: 0.6, Allergens: []string{"gluten"}},
+		{Type: "modifier", Name: "Extra shot", Price: 1},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, rows); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+
+	records, err := ReadCSV(&buf)
+	if err != nil {
+		t.Fatalf("ReadCSV: %v", err)
+	}
+	if len(records) != len(rows) {
+		t.Fatalf("read %d records, want %d", len(records), len(rows))
+	}
+	for i, record := range records {
+		if record.Err != nil {
+			t.Errorf("record %d: %v", i, record.Err)
+		}
+		if !reflect.DeepEqual(record.Row, rows[i]) {
+			t.Errorf("record %d = %+v, want %+v", i, record.Row, rows[i])
+		}
+	}
+	// The product description spans two lines of the file
+	if records[1].Line != 3 || records[2].Line != 5 {
+		t.Errorf("lines = %d, %d, want 3, 5", records[1].Line, records[2].Line)
+	}
+}
+
+func TestReadCSVHeader(t *testing.T) {
+	input := "\uFEFFName, TYPE ,price\nDrinks,category,\nEspresso,product,3\n\n"
+	records, err := ReadCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadCSV: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("read %d records, want 2", len(records))
+	}
+	if row := records[1].Row; row.Type != "product" || row.Name != "Espresso" || row.Price != 3 {
+		t.Errorf("row = %+v", row)
+	}
+
+	for input, want := range map[string]string{
+		"":                 "file is empty",
+		"name,price\n":     `missing column "type"`,
+		"type,sku\n":       `missing column "name"`,
+		"type,name,cost\n": `unknown column "cost"`,
+	} {
+		if _, err := ReadCSV(strings.NewReader(input)); err == nil || err.Error() != want {
+			t.Errorf("ReadCSV(%q) error = %v, want %s", input, err, want)
+		}
+	}
+}
+
+func TestReadCSVKeepsReadingAfterBadLines(t *testing.T) {
+	input := "type,name,price,is_available\n" +
+		"product,Espresso,cheap,\n" +
+		"product,Mocha,5,maybe\n" +
+		"product,Latte,4,true\n" +
+		"product,\"Cortado,4,true\n"
+	records, err := ReadCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadCSV: %v", err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("read %d records, want 4", len(records))
+	}
+	if records[0].Err == nil || records[0].Err.Error() != `invalid price "cheap"` || records[0].Line != 2 {
+		t.Errorf("record 0 = %+v", records[0])
+	}
+	if records[1].Err == nil || records[1].Line != 3 {
+		t.Errorf("record 1 = %+v", records[1])
+	}
+	if records[2].Err != nil || records[2].Row.Name != "Latte" || !*records[2].Row.IsAvailable {
+		t.Errorf("record 2 = %+v", records[2])
+	}
+	if records[3].Err == nil || records[3].Line != 5 {
+		t.Errorf("unterminated quote = %+v", records[3])
+	}
+}
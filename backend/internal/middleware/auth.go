@@ -76,6 +76,14 @@ func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get token from Authorization header
 		authHeader := c.GetHeader("Authorization")
+
+		// Browsers cannot set headers on EventSource connections, so event
+		// streams may pass the token as a query parameter instead
+		if authHeader == "" && strings.Contains(c.GetHeader("Accept"), "text/event-stream") {
+			if token := c.Query("access_token"); token != "" {
+				authHeader = "Bearer " + token
+			}
+		}
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, models.APIResponse{
 				Success: false,
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"pos-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rateWindow counts the requests of one client in the current window
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// RateLimit returns a middleware that allows each client IP at most limit
+// requests per window. It is meant for the public endpoints guests reach
+// without logging in; counters are kept in memory per backend instance.
+// Forwarded client IPs are only used from the proxies in TRUSTED_PROXIES.
+func RateLimit(limit int, window time.Duration) gin.HandlerFunc {
+	var mu sync.Mutex
+	clients := map[string]*rateWindow{}
+	lastSweep := time.Now()
+
+	return func(c *gin.Context) {
+		now := time.Now()
+		ip := c.ClientIP()
+
+		mu.Lock()
+		// Forget clients whose window ran out so the map does not keep growing
+		if now.Sub(lastSweep) > window {
+			for key, w := range clients {
+				if now.Sub(w.start) >= window {
+					delete(clients, key)
+				}
+			}
+			lastSweep = now
+		}
+
+		w, ok := clients[ip]
+		if !ok || now.Sub(w.start) >= window {
+			w = &rateWindow{start: now}
+			clients[ip] = w
+		}
+		w.count++
+		count, retryAfter := w.count, w.start.Add(window).Sub(now)
+		mu.Unlock()
+
+		if count > limit {
+			c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			c.JSON(http.StatusTooManyRequests, models.APIResponse{
+				Success: false,
+				Message: "Too many requests, please try again shortly",
+				Error:   stringPtr("rate_limited"),
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func rateLimitedRouter(limit int, window time.Duration) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.SetTrustedProxies(nil)
+	router.GET("/menu", RateLimit(limit, window), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func get(router *gin.Engine, remoteAddr, forwardedFor string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/menu", nil)
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimit(t *testing.T) {
+	router := rateLimitedRouter(2, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if w := get(router, "192.0.2.1:1000", ""); w.Code != http.StatusOK {
+			t.Fatalf("request %d = %d, want 200", i+1, w.Code)
+		}
+	}
+
+	w := get(router, "192.0.2.1:1001", "")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("request over the limit = %d, want 429", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("Retry-After header is missing")
+	}
+
+	if w := get(router, "192.0.2.2:1000", ""); w.Code != http.StatusOK {
+		t.Errorf("other client = %d, want 200", w.Code)
+	}
+}
+
+func TestRateLimitIgnoresUntrustedForwardedFor(t *testing.T) {
+	router := rateLimitedRouter(1, time.Minute)
+
+	get(router, "192.0.2.1:1000", "198.51.100.1")
+	if w := get(router, "192.0.2.1:1000", "198.51.100.2"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("spoofed X-Forwarded-For = %d, want 429", w.Code)
+	}
+}
+
+func TestRateLimitWindowResets(t *testing.T) {
+	router := rateLimitedRouter(1, 50*time.Millisecond)
+
+	get(router, "192.0.2.1:1000", "")
+	if w := get(router, "192.0.2.1:1000", ""); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request = %d, want 429", w.Code)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if w := get(router, "192.0.2.1:1000", ""); w.Code != http.StatusOK {
+		t.Errorf("request in the next window = %d, want 200", w.Code)
+	}
+}
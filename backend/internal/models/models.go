@@ -22,14 +22,15 @@ type User struct {
 
 // Category represents a product category
 type Category struct {
-	ID          uuid.UUID `json:"id"`
-	Name        string    `json:"name"`
-	Description *string   `json:"description"`
-	Color       *string   `json:"color"`
-	SortOrder   int       `json:"sort_order"`
-	IsActive    bool      `json:"is_active"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          uuid.UUID  `json:"id"`
+	Name        string     `json:"name"`
+	Description *string    `json:"description"`
+	Color       *string    `json:"color"`
+	SortOrder   int        `json:"sort_order"`
+	StationID   *uuid.UUID `json:"station_id"`
+	IsActive    bool       `json:"is_active"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
 }
 
 // Product represents a menu item/product
@@ -45,69 +46,224 @@ type Product struct {
 	IsAvailable     bool       `json:"is_available"`
 	PreparationTime int        `json:"preparation_time"` // in minutes
 	SortOrder       int        `json:"sort_order"`
+	StationID       *uuid.UUID `json:"station_id"`      // overrides the category's station
+	EightySixed     bool       `json:"is_86d"`          // sold out on the kitchen's 86 list
+	RemainingCount  *int       `json:"remaining_count"` // portions left before the product is 86'd
+	Allergens       []string   `json:"allergens"`
+	DietaryTags     []string   `json:"dietary_tags"`
+	EffectivePrice  float64    `json:"effective_price"`      // price after the price rule in effect now
+	PriceRuleName   *string    `json:"price_rule,omitempty"` // the rule setting effective_price
 	CreatedAt       time.Time  `json:"created_at"`
 	UpdatedAt       time.Time  `json:"updated_at"`
 	Category        *Category  `json:"category,omitempty"`
+	Modifiers       []Modifier `json:"modifiers,omitempty"`
+}
+
+// Modifier customizes a product, e.g. "extra cheese". Modifiers without a
+// product can be applied to any product.
+type Modifier struct {
+	ID          uuid.UUID  `json:"id"`
+	ProductID   *uuid.UUID `json:"product_id"`
+	Name        string     `json:"name"`
+	Price       float64    `json:"price"` // added to the product's price
+	Allergens   []string   `json:"allergens"`
+	DietaryTags []string   `json:"dietary_tags"`
+	IsAvailable bool       `json:"is_available"`
+	SortOrder   int        `json:"sort_order"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
 }
 
 // DiningTable represents a table or dining area
 type DiningTable struct {
+	ID              uuid.UUID     `json:"id"`
+	TableNumber     string        `json:"table_number"`
+	SeatingCapacity int           `json:"seating_capacity"`
+	Location        *string       `json:"location"`
+	Status          string        `json:"status"`
+	Layout          *TableLayout  `json:"layout,omitempty"`
+	Section         *TableSection `json:"section,omitempty"`
+	IsGroup         bool          `json:"is_group,omitempty"`
+	JoinedTo        *uuid.UUID    `json:"joined_to,omitempty"` // Group the table is pushed into
+	Members         []TableMember `json:"members,omitempty"`   // Tables pushed together into a group
+	CreatedAt       time.Time     `json:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+}
+
+// TableMember is one of the tables pushed together into a table group
+type TableMember struct {
 	ID              uuid.UUID `json:"id"`
 	TableNumber     string    `json:"table_number"`
 	SeatingCapacity int       `json:"seating_capacity"`
-	Location        *string   `json:"location"`
-	Status          string    `json:"status"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// TableLayout places a table on the floor plan of its location
+type TableLayout struct {
+	PosX     float64 `json:"pos_x"`
+	PosY     float64 `json:"pos_y"`
+	Width    float64 `json:"width"`
+	Height   float64 `json:"height"`
+	Shape    string  `json:"shape"`    // square, rectangle, round, oval, booth
+	Rotation int     `json:"rotation"` // degrees clockwise, 0-359
+}
+
+// TableSection is the server section a table belongs to for the current shift
+type TableSection struct {
+	ID         uuid.UUID `json:"id"`
+	Name       string    `json:"name"`
+	Color      *string   `json:"color"`
+	ServerID   uuid.UUID `json:"server_id"`
+	ServerName string    `json:"server_name"`
+}
+
+// FloorPlan is the drawing area for the tables of a location
+type FloorPlan struct {
+	ID            uuid.UUID     `json:"id"`
+	Location      string        `json:"location"`
+	Name          string        `json:"name"`
+	Width         int           `json:"width"`
+	Height        int           `json:"height"`
+	BackgroundURL *string       `json:"background_url"`
+	Tables        []DiningTable `json:"tables"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+}
+
+// ServerSection is a group of tables assigned to a server for a shift
+type ServerSection struct {
+	ID         uuid.UUID   `json:"id"`
+	Name       string      `json:"name"`
+	ServerID   uuid.UUID   `json:"server_id"`
+	ServerName string      `json:"server_name"`
+	Color      *string     `json:"color"`
+	ShiftStart time.Time   `json:"shift_start"`
+	ShiftEnd   time.Time   `json:"shift_end"`
+	Notes      *string     `json:"notes"`
+	TableIDs   []uuid.UUID `json:"table_ids"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+// FloorTable is a table on the live floor view with what is happening at it
+type FloorTable struct {
+	DiningTable
+	StatusSince    *time.Time     `json:"status_since"`
+	Server         *FloorServer   `json:"server"`
+	OpenOrder      *FloorOrder    `json:"open_order"`
+	Covers         int            `json:"covers"`
+	SeatedMinutes  *int           `json:"seated_minutes"`
+	KitchenState   string         `json:"kitchen_state"` // none, ordering, in_kitchen, late, ready_to_serve, served
+	ItemCounts     map[string]int `json:"item_counts"`
+	AmountDue      float64        `json:"amount_due"`
+	AmountPaid     float64        `json:"amount_paid"`
+	OpenOrderCount int            `json:"open_order_count"`
+}
+
+// FloorServer is the server looking after a table, from its section or else
+// the staff member who opened the order
+type FloorServer struct {
+	ID     uuid.UUID `json:"id"`
+	Name   string    `json:"name"`
+	Source string    `json:"source"` // section or order
+}
+
+// FloorOrder is the open order at a table on the live floor view
+type FloorOrder struct {
+	ID           uuid.UUID `json:"id"`
+	OrderNumber  string    `json:"order_number"`
+	CustomerName *string   `json:"customer_name"`
+	Status       string    `json:"status"`
+	TotalAmount  float64   `json:"total_amount"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// JoinTablesRequest pushes tables together into a group seated as one table
+type JoinTablesRequest struct {
+	TableIDs []uuid.UUID `json:"table_ids" binding:"required,min=2"`
 }
 
 // Order represents a customer order
 type Order struct {
-	ID             uuid.UUID    `json:"id"`
-	OrderNumber    string       `json:"order_number"`
-	TableID        *uuid.UUID   `json:"table_id"`
-	UserID         *uuid.UUID   `json:"user_id"`
-	CustomerName   *string      `json:"customer_name"`
-	OrderType      string       `json:"order_type"` // dine_in, takeout, delivery
-	Status         string       `json:"status"`     // pending, confirmed, preparing, ready, served, completed, cancelled
-	Subtotal       float64      `json:"subtotal"`
-	TaxAmount      float64      `json:"tax_amount"`
-	DiscountAmount float64      `json:"discount_amount"`
-	TotalAmount    float64      `json:"total_amount"`
-	Notes          *string      `json:"notes"`
-	CreatedAt      time.Time    `json:"created_at"`
-	UpdatedAt      time.Time    `json:"updated_at"`
-	ServedAt       *time.Time   `json:"served_at"`
-	CompletedAt    *time.Time   `json:"completed_at"`
-	Table          *DiningTable `json:"table,omitempty"`
-	User           *User        `json:"user,omitempty"`
-	Items          []OrderItem  `json:"items,omitempty"`
-	Payments       []Payment    `json:"payments,omitempty"`
+	ID                  uuid.UUID    `json:"id"`
+	OrderNumber         string       `json:"order_number"`
+	TableID             *uuid.UUID   `json:"table_id"`
+	UserID              *uuid.UUID   `json:"user_id"`
+	CustomerName        *string      `json:"customer_name"`
+	CustomerEmail       *string      `json:"customer_email"`
+	OrderType           string       `json:"order_type"` // dine_in, takeout, delivery
+	Status              string       `json:"status"`     // awaiting_approval, pending, confirmed, preparing, ready, served, completed, cancelled
+	Subtotal            float64      `json:"subtotal"`
+	TaxAmount           float64      `json:"tax_amount"`
+	ServiceChargeAmount float64      `json:"service_charge_amount"`
+	DiscountAmount      float64      `json:"discount_amount"`
+	TotalAmount         float64      `json:"total_amount"`
+	Notes               *string      `json:"notes"`
+	GuestAllergies      []string     `json:"guest_allergies"`
+	Covers              *int         `json:"covers"`
+	Channel             string       `json:"channel"` // staff, guest
+	FiscalNumber        *int64       `json:"fiscal_number,omitempty"`
+	CreatedAt           time.Time    `json:"created_at"`
+	UpdatedAt           time.Time    `json:"updated_at"`
+	ServedAt            *time.Time   `json:"served_at"`
+	CompletedAt         *time.Time   `json:"completed_at"`
+	Table               *DiningTable `json:"table,omitempty"`
+	User                *User        `json:"user,omitempty"`
+	Items               []OrderItem  `json:"items,omitempty"`
+	Payments            []Payment    `json:"payments,omitempty"`
 }
 
 // OrderItem represents an item within an order
 type OrderItem struct {
-	ID                  uuid.UUID `json:"id"`
-	OrderID             uuid.UUID `json:"order_id"`
-	ProductID           uuid.UUID `json:"product_id"`
-	Quantity            int       `json:"quantity"`
-	UnitPrice           float64   `json:"unit_price"`
-	TotalPrice          float64   `json:"total_price"`
-	SpecialInstructions *string   `json:"special_instructions"`
-	Status              string    `json:"status"` // pending, preparing, ready, served
-	CreatedAt           time.Time `json:"created_at"`
-	UpdatedAt           time.Time `json:"updated_at"`
-	Product             *Product  `json:"product,omitempty"`
+	ID                  uuid.UUID           `json:"id"`
+	OrderID             uuid.UUID           `json:"order_id"`
+	ProductID           *uuid.UUID          `json:"product_id"`
+	ItemType            string              `json:"item_type"` // product, gift_card
+	GiftCardID          *uuid.UUID          `json:"gift_card_id,omitempty"`
+	GiftCardCode        *string             `json:"gift_card_code,omitempty"`
+	Quantity            int                 `json:"quantity"`
+	OriginalUnitPrice   float64             `json:"original_unit_price"` // list price before any price rule
+	UnitPrice           float64             `json:"unit_price"`          // price charged
+	TotalPrice          float64             `json:"total_price"`
+	PriceRuleID         *uuid.UUID          `json:"price_rule_id,omitempty"`
+	PriceRuleName       *string             `json:"price_rule_name,omitempty"`
+	SpecialInstructions *string             `json:"special_instructions"`
+	Status              string              `json:"status"` // pending, preparing, ready, served
+	StationID           *uuid.UUID          `json:"station_id"`
+	IsRemake            bool                `json:"is_remake"`
+	RemakeOf            *uuid.UUID          `json:"remake_of,omitempty"`
+	RemakeReason        *string             `json:"remake_reason,omitempty"`
+	Allergens           []string            `json:"allergens"` // of the product and its modifiers when ordered
+	AllergenConflicts   []string            `json:"allergen_conflicts,omitempty"`
+	CreatedAt           time.Time           `json:"created_at"`
+	UpdatedAt           time.Time           `json:"updated_at"`
+	Product             *Product            `json:"product,omitempty"`
+	Modifiers           []OrderItemModifier `json:"modifiers,omitempty"`
+}
+
+// OrderItemModifier is a modifier chosen for an order item, as it was when ordered
+type OrderItemModifier struct {
+	ID         uuid.UUID  `json:"id"`
+	ModifierID *uuid.UUID `json:"modifier_id"`
+	Name       string     `json:"name"`
+	Price      float64    `json:"price"`
+	Allergens  []string   `json:"allergens"`
 }
 
 // Payment represents a payment transaction
 type Payment struct {
 	ID              uuid.UUID  `json:"id"`
 	OrderID         uuid.UUID  `json:"order_id"`
-	PaymentMethod   string     `json:"payment_method"` // cash, credit_card, debit_card, digital_wallet
+	PaymentMethod   string     `json:"payment_method"` // cash, credit_card, debit_card, digital_wallet, gift_card
 	Amount          float64    `json:"amount"`
+	TipAmount       float64    `json:"tip_amount"` // charged on top of amount, not part of the order total
+	TenderedAmount  *float64   `json:"tendered_amount,omitempty"`
 	ReferenceNumber *string    `json:"reference_number"`
-	Status          string     `json:"status"` // pending, completed, failed, refunded
+	GiftCardID      *uuid.UUID `json:"gift_card_id,omitempty"`
+	Status          string     `json:"status"` // pending, processing, completed, failed, refunded
+	Provider        *string    `json:"provider"`
+	TransactionID   *string    `json:"transaction_id"`
+	FailureReason   *string    `json:"failure_reason"`
+	RefundedAmount  float64    `json:"refunded_amount"`
 	ProcessedBy     *uuid.UUID `json:"processed_by"`
 	ProcessedAt     *time.Time `json:"processed_at"`
 	CreatedAt       time.Time  `json:"created_at"`
@@ -128,6 +284,31 @@ type Inventory struct {
 	Product         *Product   `json:"product,omitempty"`
 }
 
+// GiftCard represents a stored-value card sold and redeemed at the counter
+type GiftCard struct {
+	ID          uuid.UUID  `json:"id"`
+	Code        string     `json:"code"`
+	Balance     float64    `json:"balance"`
+	Status      string     `json:"status"` // pending, active, disabled
+	IssuedBy    *uuid.UUID `json:"issued_by"`
+	ActivatedAt *time.Time `json:"activated_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// GiftCardTransaction is an immutable ledger entry for a gift card
+type GiftCardTransaction struct {
+	ID              uuid.UUID  `json:"id"`
+	GiftCardID      uuid.UUID  `json:"gift_card_id"`
+	TransactionType string     `json:"transaction_type"` // issue, reload, redeem, refund
+	Amount          float64    `json:"amount"`
+	BalanceAfter    float64    `json:"balance_after"`
+	OrderID         *uuid.UUID `json:"order_id"`
+	PaymentID       *uuid.UUID `json:"payment_id"`
+	CreatedBy       *uuid.UUID `json:"created_by"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
 // OrderStatusHistory tracks order status changes
 type OrderStatusHistory struct {
 	ID             uuid.UUID  `json:"id"`
@@ -140,22 +321,357 @@ type OrderStatusHistory struct {
 	ChangedByUser  *User      `json:"changed_by_user,omitempty"`
 }
 
+// ShiftReport summarizes sales activity for a business-day period (X or Z report)
+type ShiftReport struct {
+	ReportType     string               `json:"report_type"` // X, Z
+	ZNumber        *int                 `json:"z_number,omitempty"`
+	PeriodStart    time.Time            `json:"period_start"`
+	PeriodEnd      time.Time            `json:"period_end"`
+	OrderCount     int                  `json:"order_count"`
+	GrossSales     float64              `json:"gross_sales"`
+	Discounts      float64              `json:"discounts"`
+	Refunds        float64              `json:"refunds"`
+	RefundCount    int                  `json:"refund_count"`
+	NetSales       float64              `json:"net_sales"`
+	TaxCollected   float64              `json:"tax_collected"`
+	GiftCardsSold  float64              `json:"gift_cards_sold"`
+	TotalCollected float64              `json:"total_collected"`
+	TaxByRate      []TaxRateTotal       `json:"tax_by_rate"`
+	Voids          OrderTotal           `json:"voids"`
+	PaymentMethods []PaymentMethodTotal `json:"payment_methods"`
+	OrderTypes     []OrderTypeTotal     `json:"order_types"`
+	Channels       []ChannelTotal       `json:"channels"`
+	HappyHour      PriceRuleTotal       `json:"happy_hour"` // items sold under a price rule
+	PriceRules     []PriceRuleTotal     `json:"price_rules"`
+	OpenOrders     OrderTotal           `json:"open_orders"`
+}
+
+// TaxRateTotal groups taxable sales by effective tax rate (percent)
+type TaxRateTotal struct {
+	Rate          float64 `json:"rate"`
+	TaxableAmount float64 `json:"taxable_amount"`
+	TaxAmount     float64 `json:"tax_amount"`
+}
+
+// PaymentMethodTotal sums collected and refunded amounts for a payment method
+type PaymentMethodTotal struct {
+	PaymentMethod string  `json:"payment_method"`
+	Count         int     `json:"count"`
+	Amount        float64 `json:"amount"`
+	Refunded      float64 `json:"refunded"`
+}
+
+// OrderTypeTotal counts completed orders for an order type
+type OrderTypeTotal struct {
+	OrderType string  `json:"order_type"`
+	Count     int     `json:"count"`
+	Amount    float64 `json:"amount"`
+}
+
+// ChannelTotal groups completed orders by where they were taken (staff, guest)
+type ChannelTotal struct {
+	Channel string  `json:"channel"`
+	Count   int     `json:"count"`
+	Amount  float64 `json:"amount"`
+}
+
+// PriceRuleTotal sums the items of completed orders sold under a price rule;
+// discount is what they would have cost more at list price
+type PriceRuleTotal struct {
+	Name     string  `json:"name,omitempty"`
+	Quantity int     `json:"quantity"`
+	Revenue  float64 `json:"revenue"`
+	Discount float64 `json:"discount"`
+}
+
+// OrderTotal is a count of orders with their combined value
+type OrderTotal struct {
+	Count  int     `json:"count"`
+	Amount float64 `json:"amount"`
+}
+
+// ZReport is a persisted, numbered business-day close
+type ZReport struct {
+	ID        uuid.UUID   `json:"id"`
+	ZNumber   int         `json:"z_number"`
+	OpenedAt  time.Time   `json:"opened_at"`
+	ClosedAt  time.Time   `json:"closed_at"`
+	ClosedBy  *uuid.UUID  `json:"closed_by"`
+	Report    ShiftReport `json:"report"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// KitchenStation is a preparation area in the kitchen with its own display
+type KitchenStation struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Color     *string   `json:"color"`
+	SortOrder int       `json:"sort_order"`
+	IsActive  bool      `json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Menu groups the dayparts a part of the menu is served in, e.g. breakfast
+type Menu struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Description *string   `json:"description"`
+	SortOrder   int       `json:"sort_order"`
+	IsActive    bool      `json:"is_active"`
+	Dayparts    []Daypart `json:"dayparts"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Daypart is a weekly time window in the store's timezone when a menu is served
+type Daypart struct {
+	ID          uuid.UUID   `json:"id"`
+	MenuID      uuid.UUID   `json:"menu_id"`
+	Name        string      `json:"name"`
+	DaysOfWeek  []int64     `json:"days_of_week"` // 0 = Sunday ... 6 = Saturday
+	StartTime   string      `json:"start_time"`   // HH:MM
+	EndTime     string      `json:"end_time"`     // HH:MM; before start_time runs past midnight
+	IsActive    bool        `json:"is_active"`
+	IsCurrent   bool        `json:"is_current"` // being served right now
+	CategoryIDs []uuid.UUID `json:"category_ids"`
+	ProductIDs  []uuid.UUID `json:"product_ids"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+// PriceRule changes the price of a product, the products of a category or,
+// when neither is set, every product during a weekly window in the store's
+// timezone, e.g. half-price beverages 16:00-18:00 on weekdays
+type PriceRule struct {
+	ID              uuid.UUID  `json:"id"`
+	Name            string     `json:"name"`
+	DaysOfWeek      []int64    `json:"days_of_week"` // 0 = Sunday ... 6 = Saturday
+	StartTime       string     `json:"start_time"`   // HH:MM
+	EndTime         string     `json:"end_time"`     // HH:MM; before start_time runs past midnight
+	CategoryID      *uuid.UUID `json:"category_id"`
+	ProductID       *uuid.UUID `json:"product_id"`
+	AdjustmentType  string     `json:"adjustment_type"` // percent_off, amount_off, fixed_price
+	AdjustmentValue float64    `json:"adjustment_value"`
+	IsActive        bool       `json:"is_active"`
+	IsCurrent       bool       `json:"is_current"` // in effect right now
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// Printer is a network ESC/POS printer reached over raw TCP
+type Printer struct {
+	ID          uuid.UUID   `json:"id"`
+	Name        string      `json:"name"`
+	Host        string      `json:"host"`
+	Port        int         `json:"port"`
+	Role        string      `json:"role"` // receipt, kitchen, bar
+	CategoryIDs []uuid.UUID `json:"category_ids"`
+	IsActive    bool        `json:"is_active"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+// PrintJob is a rendered document waiting in the print queue
+type PrintJob struct {
+	ID            uuid.UUID  `json:"id"`
+	PrinterID     uuid.UUID  `json:"printer_id"`
+	PrinterName   string     `json:"printer_name"`
+	OrderID       *uuid.UUID `json:"order_id"`
+	Kind          string     `json:"kind"`   // receipt, kitchen, test
+	Status        string     `json:"status"` // pending, printing, completed, failed
+	Attempts      int        `json:"attempts"`
+	MaxAttempts   int        `json:"max_attempts"`
+	LastError     *string    `json:"last_error"`
+	NextAttemptAt *time.Time `json:"next_attempt_at"`
+	CreatedBy     *uuid.UUID `json:"created_by"`
+	PrintedAt     *time.Time `json:"printed_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// EmailTemplate is the current version of an email template
+type EmailTemplate struct {
+	Name      string     `json:"name"`
+	Subject   string     `json:"subject"`
+	TextBody  string     `json:"text_body"`
+	HTMLBody  string     `json:"html_body"`
+	IsDefault bool       `json:"is_default"`
+	UpdatedBy *uuid.UUID `json:"updated_by"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+// EmailJob is a rendered email waiting in the outgoing queue
+type EmailJob struct {
+	ID            uuid.UUID  `json:"id"`
+	OrderID       *uuid.UUID `json:"order_id"`
+	Template      string     `json:"template"`
+	Recipient     string     `json:"recipient"`
+	Subject       string     `json:"subject"`
+	Status        string     `json:"status"` // pending, sending, sent, failed
+	Attempts      int        `json:"attempts"`
+	MaxAttempts   int        `json:"max_attempts"`
+	LastError     *string    `json:"last_error"`
+	NextAttemptAt *time.Time `json:"next_attempt_at"`
+	CreatedBy     *uuid.UUID `json:"created_by"`
+	SentAt        *time.Time `json:"sent_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// Reservation is a booking for a party at a table
+type Reservation struct {
+	ID              uuid.UUID  `json:"id"`
+	GuestName       string     `json:"guest_name"`
+	GuestPhone      *string    `json:"guest_phone"`
+	PartySize       int        `json:"party_size"`
+	ReservedAt      time.Time  `json:"reserved_at"`
+	DurationMinutes int        `json:"duration_minutes"`
+	TableID         *uuid.UUID `json:"table_id"`
+	TableNumber     *string    `json:"table_number,omitempty"`
+	Status          string     `json:"status"` // booked, seated, no_show, cancelled
+	Notes           *string    `json:"notes"`
+	OrderID         *uuid.UUID `json:"order_id"`
+	CreatedBy       *uuid.UUID `json:"created_by"`
+	SeatedAt        *time.Time `json:"seated_at"`
+	CancelledAt     *time.Time `json:"cancelled_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// WaitlistEntry is a walk-in party waiting for a table
+type WaitlistEntry struct {
+	ID                uuid.UUID  `json:"id"`
+	PartyName         string     `json:"party_name"`
+	PartySize         int        `json:"party_size"`
+	Phone             *string    `json:"phone"`
+	Notes             *string    `json:"notes"`
+	Status            string     `json:"status"` // waiting, notified, seated, removed
+	QuotedWaitMinutes int        `json:"quoted_wait_minutes"`
+	TableID           *uuid.UUID `json:"table_id"`
+	TableNumber       *string    `json:"table_number,omitempty"`
+	OrderID           *uuid.UUID `json:"order_id"`
+	RemoveReason      *string    `json:"remove_reason"`
+	NotifyCount       int        `json:"notify_count"`
+	CreatedBy         *uuid.UUID `json:"created_by"`
+	AddedAt           time.Time  `json:"added_at"`
+	NotifiedAt        *time.Time `json:"notified_at"`
+	SeatedAt          *time.Time `json:"seated_at"`
+	RemovedAt         *time.Time `json:"removed_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+
+	// Live values for parties still waiting
+	Position             int  `json:"position,omitempty"`
+	WaitedMinutes        int  `json:"waited_minutes"`
+	EstimatedWaitMinutes *int `json:"estimated_wait_minutes,omitempty"`
+}
+
 // Request/Response DTOs
 
 // CreateOrderRequest represents the request to create a new order
 type CreateOrderRequest struct {
-	TableID      *uuid.UUID        `json:"table_id"`
-	CustomerName *string           `json:"customer_name"`
-	OrderType    string            `json:"order_type"`
-	Items        []CreateOrderItem `json:"items"`
-	Notes        *string           `json:"notes"`
+	TableID         *uuid.UUID           `json:"table_id"`
+	CustomerName    *string              `json:"customer_name"`
+	CustomerEmail   *string              `json:"customer_email"`
+	OrderType       string               `json:"order_type"`
+	Items           []CreateOrderItem    `json:"items"`
+	GiftCards       []CreateGiftCardItem `json:"gift_cards"`
+	Notes           *string              `json:"notes"`
+	GuestAllergies  []string             `json:"guest_allergies"`   // checked against every item on the kitchen ticket
+	Covers          *int                 `json:"covers"`            // guests seated with a dine-in order
+	OverrideDaypart bool                 `json:"override_daypart"`  // managers only: order products outside the active daypart
+	ReservationID   *uuid.UUID           `json:"reservation_id"`    // seated reservation the order is for
+	WaitlistEntryID *uuid.UUID           `json:"waitlist_entry_id"` // seated waitlist party the order is for
 }
 
 // CreateOrderItem represents an item in the order creation request
 type CreateOrderItem struct {
-	ProductID           uuid.UUID `json:"product_id"`
-	Quantity            int       `json:"quantity"`
-	SpecialInstructions *string   `json:"special_instructions"`
+	ProductID           uuid.UUID   `json:"product_id"`
+	Quantity            int         `json:"quantity"`
+	SpecialInstructions *string     `json:"special_instructions"`
+	ModifierIDs         []uuid.UUID `json:"modifier_ids"`
+}
+
+// GuestOrderRequest is an order a guest places from the QR code on their table
+type GuestOrderRequest struct {
+	CustomerName   *string           `json:"customer_name"`
+	Items          []CreateOrderItem `json:"items" binding:"required,min=1"`
+	Notes          *string           `json:"notes"`
+	GuestAllergies []string          `json:"guest_allergies"`
+	Covers         *int              `json:"covers"`
+}
+
+// GuestPaymentRequest is a payment a guest makes from their check link. Without
+// an amount or split the guest pays the whole remaining balance.
+type GuestPaymentRequest struct {
+	PaymentMethod string   `json:"payment_method" binding:"required"` // credit_card, debit_card, digital_wallet
+	Amount        *float64 `json:"amount"`
+	SplitWays     *int     `json:"split_ways"` // pay an even share of the check
+	TipAmount     *float64 `json:"tip_amount"`
+	TipPercent    *float64 `json:"tip_percent"` // of the amount paid
+}
+
+// GuestCheck is the live check a guest opens from their check link
+type GuestCheck struct {
+	OrderNumber string           `json:"order_number"`
+	TableNumber *string          `json:"table_number"`
+	Status      string           `json:"status"`
+	Currency    string           `json:"currency"`
+	Items       []GuestCheckItem `json:"items"`
+	Summary     PaymentSummary   `json:"summary"`
+	TipOptions  []TipOption      `json:"tip_options"`
+}
+
+// GuestCheckItem is a line of a guest check
+type GuestCheckItem struct {
+	Name       string   `json:"name"`
+	Quantity   int      `json:"quantity"`
+	UnitPrice  float64  `json:"unit_price"`
+	TotalPrice float64  `json:"total_price"`
+	Modifiers  []string `json:"modifiers"`
+}
+
+// TipOption is a suggested tip, as a percentage of the subtotal
+type TipOption struct {
+	Percent float64 `json:"percent"`
+	Amount  float64 `json:"amount"`
+}
+
+// CheckLink is the signed link to the check of an order
+type CheckLink struct {
+	OrderID     uuid.UUID `json:"order_id"`
+	OrderNumber string    `json:"order_number"`
+	Token       string    `json:"token"`
+	URL         string    `json:"url"`
+}
+
+// MenuCategory is a category of the menu with the products that can be ordered from it
+type MenuCategory struct {
+	Category
+	Products []Product `json:"products"`
+}
+
+// TableQRCode is the signed link printed on a table's QR code
+type TableQRCode struct {
+	TableID     uuid.UUID  `json:"table_id"`
+	TableNumber string     `json:"table_number"`
+	Token       string     `json:"token"`
+	URL         string     `json:"url"`
+	RotatedAt   *time.Time `json:"rotated_at"`
+}
+
+// UpdateGuestAllergiesRequest represents the request to replace the guest allergies flagged on an order
+type UpdateGuestAllergiesRequest struct {
+	GuestAllergies []string `json:"guest_allergies"`
+}
+
+// CreateGiftCardItem sells a gift card as a non-revenue order line. Without a
+// code a new card is issued; with the code of an active card it is reloaded.
+// The value is credited once the order is fully paid.
+type CreateGiftCardItem struct {
+	Code   *string `json:"code"`
+	Amount float64 `json:"amount"`
 }
 
 // UpdateOrderStatusRequest represents the request to update order status
@@ -166,9 +682,286 @@ type UpdateOrderStatusRequest struct {
 
 // ProcessPaymentRequest represents the request to process a payment
 type ProcessPaymentRequest struct {
-	PaymentMethod   string  `json:"payment_method"`
-	Amount          float64 `json:"amount"`
-	ReferenceNumber *string `json:"reference_number"`
+	PaymentMethod   string   `json:"payment_method"`
+	Amount          float64  `json:"amount"`
+	TenderedAmount  *float64 `json:"tendered_amount"` // cash handed over; the difference is returned as change
+	ReferenceNumber *string  `json:"reference_number"`
+	GiftCardCode    *string  `json:"gift_card_code"`
+}
+
+// PaymentSummary is the balance of an order and what has been paid towards it
+type PaymentSummary struct {
+	OrderID             uuid.UUID `json:"order_id"`
+	Subtotal            float64   `json:"subtotal"`
+	DiscountAmount      float64   `json:"discount_amount"`
+	ServiceChargeAmount float64   `json:"service_charge_amount"`
+	TaxAmount           float64   `json:"tax_amount"`
+	TotalAmount         float64   `json:"total_amount"`
+	TotalPaid           float64   `json:"total_paid"`
+	PendingAmount       float64   `json:"pending_amount"` // still with the payment provider
+	RemainingAmount     float64   `json:"remaining_amount"`
+	TipAmount           float64   `json:"tip_amount"` // tipped on completed payments
+	IsFullyPaid         bool      `json:"is_fully_paid"`
+	PaymentCount        int       `json:"payment_count"`
+}
+
+// RefundPaymentRequest represents the request to refund a payment; a nil amount refunds the remainder
+type RefundPaymentRequest struct {
+	Amount *float64 `json:"amount"`
+	Reason *string  `json:"reason"`
+}
+
+// CreatePrinterRequest represents the request to register a printer
+type CreatePrinterRequest struct {
+	Name        string      `json:"name" binding:"required"`
+	Host        string      `json:"host" binding:"required"`
+	Port        *int        `json:"port"`
+	Role        string      `json:"role" binding:"required"`
+	CategoryIDs []uuid.UUID `json:"category_ids"`
+	IsActive    *bool       `json:"is_active"`
+}
+
+// UpdatePrinterRequest represents the request to update a printer; a non-nil
+// CategoryIDs replaces the routing
+type UpdatePrinterRequest struct {
+	Name        *string      `json:"name"`
+	Host        *string      `json:"host"`
+	Port        *int         `json:"port"`
+	Role        *string      `json:"role"`
+	CategoryIDs *[]uuid.UUID `json:"category_ids"`
+	IsActive    *bool        `json:"is_active"`
+}
+
+// CreateKitchenStationRequest represents the request to create a kitchen station
+type CreateKitchenStationRequest struct {
+	Name      string  `json:"name" binding:"required"`
+	Color     *string `json:"color"`
+	SortOrder int     `json:"sort_order"`
+	IsActive  *bool   `json:"is_active"`
+}
+
+// UpdateKitchenStationRequest represents the request to update a kitchen station
+type UpdateKitchenStationRequest struct {
+	Name      *string `json:"name"`
+	Color     *string `json:"color"`
+	SortOrder *int    `json:"sort_order"`
+	IsActive  *bool   `json:"is_active"`
+}
+
+// CreateMenuRequest represents the request to create a menu
+type CreateMenuRequest struct {
+	Name        string  `json:"name" binding:"required"`
+	Description *string `json:"description"`
+	SortOrder   int     `json:"sort_order"`
+	IsActive    *bool   `json:"is_active"`
+}
+
+// UpdateMenuRequest represents the request to update a menu
+type UpdateMenuRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	SortOrder   *int    `json:"sort_order"`
+	IsActive    *bool   `json:"is_active"`
+}
+
+// CreateDaypartRequest represents the request to add a daypart to a menu
+type CreateDaypartRequest struct {
+	Name       string `json:"name" binding:"required"`
+	DaysOfWeek []int  `json:"days_of_week"` // empty means every day
+	StartTime  string `json:"start_time" binding:"required"`
+	EndTime    string `json:"end_time" binding:"required"`
+	IsActive   *bool  `json:"is_active"`
+}
+
+// UpdateDaypartRequest represents the request to update a daypart
+type UpdateDaypartRequest struct {
+	Name       *string `json:"name"`
+	DaysOfWeek []int   `json:"days_of_week"`
+	StartTime  *string `json:"start_time"`
+	EndTime    *string `json:"end_time"`
+	IsActive   *bool   `json:"is_active"`
+}
+
+// AssignDaypartsRequest replaces the dayparts of a category or product; an
+// empty list puts it back on the menu all day
+type AssignDaypartsRequest struct {
+	DaypartIDs []uuid.UUID `json:"daypart_ids"`
+}
+
+// CreatePriceRuleRequest represents the request to create a price rule
+type CreatePriceRuleRequest struct {
+	Name            string     `json:"name" binding:"required"`
+	DaysOfWeek      []int      `json:"days_of_week"` // empty means every day
+	StartTime       string     `json:"start_time" binding:"required"`
+	EndTime         string     `json:"end_time" binding:"required"`
+	CategoryID      *uuid.UUID `json:"category_id"`
+	ProductID       *uuid.UUID `json:"product_id"`
+	AdjustmentType  string     `json:"adjustment_type" binding:"required"`
+	AdjustmentValue float64    `json:"adjustment_value"`
+	IsActive        *bool      `json:"is_active"`
+}
+
+// UpdatePriceRuleRequest represents the request to update a price rule
+type UpdatePriceRuleRequest struct {
+	Name            *string  `json:"name"`
+	DaysOfWeek      []int    `json:"days_of_week"`
+	StartTime       *string  `json:"start_time"`
+	EndTime         *string  `json:"end_time"`
+	AdjustmentType  *string  `json:"adjustment_type"`
+	AdjustmentValue *float64 `json:"adjustment_value"`
+	IsActive        *bool    `json:"is_active"`
+}
+
+// MenuRow is a category, product or modifier in a menu export or import, as
+// a CSV line or a JSON object. Fields that do not apply to the row's type are
+// left empty.
+type MenuRow struct {
+	Type            string   `json:"type"` // category, product, modifier
+	Name            string   `json:"name"`
+	SKU             string   `json:"sku,omitempty"`         // products are matched by SKU
+	Category        string   `json:"category,omitempty"`    // category name of a product
+	ProductSKU      string   `json:"product_sku,omitempty"` // product of a modifier; empty for every product
+	Description     string   `json:"description,omitempty"`
+	Price           float64  `json:"price"`
+	Barcode         string   `json:"barcode,omitempty"`
+	ImageURL        string   `json:"image_url,omitempty"`
+	Color           string   `json:"color,omitempty"`
+	PreparationTime int      `json:"preparation_time"`
+	SortOrder       int      `json:"sort_order"`
+	IsAvailable     *bool    `json:"is_available"` // is_active of a category; empty means true
+	Allergens       []string `json:"allergens,omitempty"`
+	DietaryTags     []string `json:"dietary_tags,omitempty"`
+}
+
+// MenuImportRequest represents a JSON menu import, in the shape of an export
+type MenuImportRequest struct {
+	Rows []MenuRow `json:"rows" binding:"required"`
+}
+
+// MenuImportRowResult is what importing a row did, or would do in a dry run
+type MenuImportRowResult struct {
+	Row    int     `json:"row"` // CSV line or position in the JSON rows, from 1
+	Type   string  `json:"type"`
+	Key    string  `json:"key"`    // SKU of a product, name of a category or modifier
+	Action string  `json:"action"` // create, update, error
+	Error  *string `json:"error,omitempty"`
+}
+
+// MenuImportResult sums up a menu import. Nothing is applied when a row fails.
+type MenuImportResult struct {
+	DryRun  bool                  `json:"dry_run"`
+	Applied bool                  `json:"applied"`
+	Created int                   `json:"created"`
+	Updated int                   `json:"updated"`
+	Failed  int                   `json:"failed"`
+	Rows    []MenuImportRowResult `json:"rows"`
+}
+
+// RecallTicketRequest represents the request to bring a bumped ticket back to the kitchen
+type RecallTicketRequest struct {
+	Reason *string `json:"reason"`
+}
+
+// RemakeItemsRequest represents the request to send items of an order to the kitchen again
+type RemakeItemsRequest struct {
+	Items  []RemakeItem `json:"items" binding:"required"`
+	Reason string       `json:"reason" binding:"required"`
+}
+
+// RemakeItem is one item to remake; without a quantity the whole line is remade
+type RemakeItem struct {
+	ItemID   uuid.UUID `json:"item_id"`
+	Quantity *int      `json:"quantity"`
+}
+
+// EightySixedProduct is an entry on the kitchen's 86 list
+type EightySixedProduct struct {
+	ProductID      uuid.UUID  `json:"product_id"`
+	ProductName    string     `json:"product_name"`
+	SoldOut        bool       `json:"sold_out"`        // false while limited portions remain
+	RemainingCount *int       `json:"remaining_count"` // portions left, nil when 86'd outright
+	RestoreAt      *time.Time `json:"restore_at"`
+	Reason         *string    `json:"reason"`
+	MarkedBy       *uuid.UUID `json:"marked_by"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// EightySixRequest represents the request to 86 a product. With a remaining
+// count the product stays on sale until that many portions have been ordered.
+// RestoreAt or RestoreInMinutes puts it back on the menu automatically.
+type EightySixRequest struct {
+	ProductID        uuid.UUID  `json:"product_id" binding:"required"`
+	RemainingCount   *int       `json:"remaining_count"`
+	RestoreAt        *time.Time `json:"restore_at"`
+	RestoreInMinutes *int       `json:"restore_in_minutes"`
+	Reason           *string    `json:"reason"`
+}
+
+// CreateReservationRequest represents the request to book a table. Without a
+// table ID the smallest free table that fits the party is assigned.
+type CreateReservationRequest struct {
+	GuestName       string     `json:"guest_name" binding:"required"`
+	GuestPhone      *string    `json:"guest_phone"`
+	PartySize       int        `json:"party_size" binding:"required"`
+	ReservedAt      time.Time  `json:"reserved_at" binding:"required"`
+	DurationMinutes *int       `json:"duration_minutes"`
+	TableID         *uuid.UUID `json:"table_id"`
+	Notes           *string    `json:"notes"`
+}
+
+// UpdateReservationRequest represents the request to change a booked reservation
+type UpdateReservationRequest struct {
+	GuestName       *string    `json:"guest_name"`
+	GuestPhone      *string    `json:"guest_phone"`
+	PartySize       *int       `json:"party_size"`
+	ReservedAt      *time.Time `json:"reserved_at"`
+	DurationMinutes *int       `json:"duration_minutes"`
+	TableID         *uuid.UUID `json:"table_id"`
+	Notes           *string    `json:"notes"`
+}
+
+// SeatReservationRequest represents the request to seat a reservation; a table
+// ID moves the party to another table
+type SeatReservationRequest struct {
+	TableID *uuid.UUID `json:"table_id"`
+}
+
+type CreateWaitlistEntryRequest struct {
+	PartyName string  `json:"party_name" binding:"required"`
+	PartySize int     `json:"party_size" binding:"required"`
+	Phone     *string `json:"phone"`
+	Notes     *string `json:"notes"`
+}
+
+type NotifyWaitlistEntryRequest struct {
+	Message *string `json:"message"` // replaces the default "your table is ready" text
+}
+
+type SeatWaitlistEntryRequest struct {
+	TableID uuid.UUID `json:"table_id" binding:"required"`
+}
+
+type RemoveWaitlistEntryRequest struct {
+	Reason string `json:"reason"` // left, no_response, declined or other; defaults to left
+}
+
+// PrintOrderRequest represents the request to send an order document to its printers
+type PrintOrderRequest struct {
+	Kind string `json:"kind"` // receipt or kitchen
+}
+
+// SendReceiptEmailRequest represents the request to email an order receipt; without
+// an email the order's customer email is used
+type SendReceiptEmailRequest struct {
+	Email *string `json:"email"`
+}
+
+// UpdateEmailTemplateRequest represents the request to customize an email template
+type UpdateEmailTemplateRequest struct {
+	Subject  string `json:"subject" binding:"required"`
+	TextBody string `json:"text_body" binding:"required"`
+	HTMLBody string `json:"html_body" binding:"required"`
 }
 
 // LoginRequest represents the login request
@@ -226,8 +1019,10 @@ type Settings struct {
 	DefaultOrderType      string    `json:"default_order_type"`
 	AutoPrintReceipts     bool      `json:"auto_print_receipts"`
 	AutoPrintKitchen      bool      `json:"auto_print_kitchen"`
+	AutoEmailReceipts     bool      `json:"auto_email_receipts"`
 	ReceiptFooter         *string   `json:"receipt_footer"`
 	IsActive              bool      `json:"is_active"`
+	GuestOrderApproval    bool      `json:"guest_order_approval"` // guest orders wait for a server before reaching the kitchen
 	CreatedAt             time.Time `json:"created_at"`
 	UpdatedAt             time.Time `json:"updated_at"`
 }
@@ -250,6 +1045,8 @@ type UpdateSettingsRequest struct {
 	DefaultOrderType      *string  `json:"default_order_type"`
 	AutoPrintReceipts     *bool    `json:"auto_print_receipts"`
 	AutoPrintKitchen      *bool    `json:"auto_print_kitchen"`
+	AutoEmailReceipts     *bool    `json:"auto_email_receipts"`
 	ReceiptFooter         *string  `json:"receipt_footer"`
 	IsActive              *bool    `json:"is_active"`
+	GuestOrderApproval    *bool    `json:"guest_order_approval"`
 }
\ No newline at end of file
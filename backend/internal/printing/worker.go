@@ -0,0 +1,157 @@
+package printing
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"time"
+
+	"pos-backend/internal/util"
+
+	"github.com/google/uuid"
+)
+
+// Print job statuses shared with the print_jobs table
+const (
+	StatusPending   = "pending"
+	StatusPrinting  = "printing"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// DefaultPort is the raw TCP port used by network ESC/POS printers
+const DefaultPort = 9100
+
+// Send writes a raw payload to a network printer
+func Send(host string, port int, payload []byte, timeout time.Duration) error {
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := conn.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+	_, err = conn.Write(payload)
+	return err
+}
+
+// Worker drains the print_jobs queue. Jobs are claimed with SKIP LOCKED so
+// several API instances can run a worker against the same database.
+type Worker struct {
+	db       *sql.DB
+	Interval time.Duration
+	Timeout  time.Duration
+}
+
+// NewWorker creates a worker configured from PRINT_POLL_INTERVAL and PRINT_TIMEOUT
+func NewWorker(db *sql.DB) *Worker {
+	return &Worker{
+		db:       db,
+		Interval: util.DurationFromEnv("PRINT_POLL_INTERVAL", 2*time.Second),
+		Timeout:  util.DurationFromEnv("PRINT_TIMEOUT", 5*time.Second),
+	}
+}
+
+// Start runs the worker in the background until ctx is cancelled
+func (w *Worker) Start(ctx context.Context) {
+	// Jobs left in printing by a crashed process are handed back to the queue
+	if _, err := w.db.Exec("UPDATE print_jobs SET status = 'pending', updated_at = CURRENT_TIMESTAMP WHERE status = 'printing'"); err != nil {
+		log.Printf("print worker: failed to requeue interrupted jobs: %v", err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(w.Interval)
+		defer ticker.Stop()
+
+		for {
+			for {
+				processed, err := w.processNext()
+				if err != nil {
+					log.Printf("print worker: %v", err)
+					break
+				}
+				if !processed {
+					break
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// processNext claims and prints a single due job, reporting whether one was found
+func (w *Worker) processNext() (bool, error) {
+	var jobID uuid.UUID
+	var payload []byte
+	var attempts, maxAttempts, port int
+	var host string
+
+	err := w.db.QueryRow(`
+		UPDATE print_jobs j
+		SET status = 'printing', attempts = j.attempts + 1, updated_at = CURRENT_TIMESTAMP
+		FROM printers p
+		WHERE p.id = j.printer_id
+		  AND j.id = (
+		      SELECT pj.id
+		      FROM print_jobs pj
+		      JOIN printers pr ON pr.id = pj.printer_id
+		      WHERE pj.status = 'pending' AND pj.next_attempt_at <= CURRENT_TIMESTAMP AND pr.is_active = true
+		      ORDER BY pj.created_at
+		      LIMIT 1
+		      FOR UPDATE OF pj SKIP LOCKED
+		  )
+		RETURNING j.id, j.payload, j.attempts, j.max_attempts, p.host, p.port
+	`).Scan(&jobID, &payload, &attempts, &maxAttempts, &host, &port)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to claim print job: %w", err)
+	}
+
+	if err := Send(host, port, payload, w.Timeout); err != nil {
+		return true, w.fail(jobID, attempts, maxAttempts, err)
+	}
+
+	_, err = w.db.Exec(`
+		UPDATE print_jobs
+		SET status = 'completed', last_error = NULL, printed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, jobID)
+	if err != nil {
+		return true, fmt.Errorf("failed to complete print job %s: %w", jobID, err)
+	}
+	return true, nil
+}
+
+// fail schedules a retry with quadratic backoff, or gives up after the last attempt
+func (w *Worker) fail(jobID uuid.UUID, attempts, maxAttempts int, cause error) error {
+	status := StatusPending
+	if attempts >= maxAttempts {
+		status = StatusFailed
+	}
+	backoff := time.Duration(attempts*attempts) * 5 * time.Second
+
+	_, err := w.db.Exec(`
+		UPDATE print_jobs
+		SET status = $2, last_error = $3, next_attempt_at = CURRENT_TIMESTAMP + $4::interval,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, jobID, status, cause.Error(), fmt.Sprintf("%d seconds", int(backoff.Seconds())))
+	if err != nil {
+		return fmt.Errorf("failed to reschedule print job %s: %w", jobID, err)
+	}
+	return nil
+}
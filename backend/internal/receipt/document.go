@@ -0,0 +1,308 @@
+package receipt
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"pos-backend/internal/models"
+)
+
+// DefaultWidth is the number of characters per line on an 80mm printer
+const DefaultWidth = 42
+
+// Field is a label/value pair rendered on a single line
+type Field struct {
+	Label string
+	Value string
+	Bold  bool
+}
+
+// Item is an order line with its modifiers and instructions
+type Item struct {
+	Quantity  int
+	Name      string
+	Amount    string
+	Modifiers []string
+}
+
+// Document is the printer-independent layout of a receipt or kitchen ticket
+type Document struct {
+	Title    string
+	Copy     bool
+	Header   []string
+	Meta     []Field
+	Items    []Item
+	Totals   []Field
+	Payments []Field
+	Footer   []string
+	Large    bool // kitchen tickets are printed in double size
+}
+
+// currencyFormats maps ISO codes to their symbol and number of minor digits
+var currencyFormats = map[string]struct {
+	symbol   string
+	decimals int
+}{
+	"USD": {"$", 2},
+	"CAD": {"$", 2},
+	"AUD": {"$", 2},
+	"EUR": {"€", 2},
+	"GBP": {"£", 2},
+	"JPY": {"¥", 0},
+	"VND": {"₫", 0},
+	"KRW": {"₩", 0},
+	"INR": {"₹", 2},
+	"THB": {"฿", 2},
+}
+
+// FormatMoney formats an amount using the store currency
+func FormatMoney(amount float64, currency string) string {
+	format, ok := currencyFormats[strings.ToUpper(currency)]
+	if !ok {
+		return fmt.Sprintf("%s %.2f", currency, amount)
+	}
+
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	scale := math.Pow(10, float64(format.decimals))
+	amount = math.Round(amount*scale) / scale
+
+	whole := int64(amount)
+	digits := groupThousands(whole)
+	if format.decimals == 0 {
+		return sign + format.symbol + digits
+	}
+
+	fraction := int64(math.Round((amount - float64(whole)) * scale))
+	return fmt.Sprintf("%s%s%s.%0*d", sign, format.symbol, digits, format.decimals, fraction)
+}
+
+func groupThousands(n int64) string {
+	s := fmt.Sprintf("%d", n)
+	if len(s) <= 3 {
+		return s
+	}
+	var b strings.Builder
+	pre := len(s) % 3
+	if pre > 0 {
+		b.WriteString(s[:pre])
+	}
+	for i := pre; i < len(s); i += 3 {
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(s[i : i+3])
+	}
+	return b.String()
+}
+
+// NewReceipt lays out a customer receipt for an order
+func NewReceipt(order *models.Order, settings *models.Settings, reprint bool) *Document {
+	money := func(amount float64) string {
+		return FormatMoney(amount, settings.Currency)
+	}
+
+	doc := &Document{
+		Title:  "RECEIPT",
+		Copy:   reprint,
+		Header: storeHeader(settings),
+		Meta:   orderMeta(order, settings),
+	}
+	if order.FiscalNumber != nil {
+		doc.Meta = append(doc.Meta, Field{Label: "Fiscal No", Value: fmt.Sprintf("%08d", *order.FiscalNumber), Bold: true})
+	}
+
+	for _, item := range order.Items {
+		// Remakes replace food that was already charged
+		if item.IsRemake {
+			continue
+		}
+		doc.Items = append(doc.Items, Item{
+			Quantity:  item.Quantity,
+			Name:      itemName(item),
+			Amount:    money(item.TotalPrice),
+			Modifiers: itemModifiers(item),
+		})
+	}
+
+	doc.Totals = append(doc.Totals, Field{Label: "Subtotal", Value: money(order.Subtotal)})
+	if order.DiscountAmount > 0 {
+		doc.Totals = append(doc.Totals, Field{Label: "Discount", Value: money(-order.DiscountAmount)})
+	}
+	if order.ServiceChargeAmount > 0 {
+		doc.Totals = append(doc.Totals, Field{Label: "Service charge", Value: money(order.ServiceChargeAmount)})
+	}
+	taxable := order.Subtotal - order.DiscountAmount
+	if taxable > 0 && order.TaxAmount > 0 {
+		rate := math.Round(order.TaxAmount/taxable*10000) / 100
+		doc.Totals = append(doc.Totals, Field{Label: fmt.Sprintf("Tax (%g%%)", rate), Value: money(order.TaxAmount)})
+	}
+	doc.Totals = append(doc.Totals, Field{Label: "TOTAL", Value: money(order.TotalAmount), Bold: true})
+
+	var paid, change, tips float64
+	for _, payment := range order.Payments {
+		if payment.Status != "completed" && payment.Status != "refunded" {
+			continue
+		}
+		paid += payment.Amount
+		doc.Payments = append(doc.Payments, Field{Label: paymentLabel(payment), Value: money(tenderedAmount(payment))})
+		if payment.RefundedAmount > 0 {
+			doc.Payments = append(doc.Payments, Field{Label: "  Refunded", Value: money(-payment.RefundedAmount)})
+		}
+		change += tenderedAmount(payment) - payment.Amount
+		tips += payment.TipAmount
+	}
+	if tips > 0 {
+		doc.Payments = append(doc.Payments, Field{Label: "Tip", Value: money(tips)})
+	}
+	if change > 0 {
+		doc.Payments = append(doc.Payments, Field{Label: "Change", Value: money(change), Bold: true})
+	}
+	if balance := order.TotalAmount - paid; balance > 0.005 {
+		doc.Payments = append(doc.Payments, Field{Label: "Balance Due", Value: money(balance), Bold: true})
+	}
+
+	if settings.ReceiptFooter != nil && *settings.ReceiptFooter != "" {
+		doc.Footer = strings.Split(*settings.ReceiptFooter, "\n")
+	}
+
+	return doc
+}
+
+// NewKitchenTicket lays out a kitchen ticket: no prices, large print, instructions
+// highlighted. Guest allergies are printed at the top and items containing one
+// are flagged.
+func NewKitchenTicket(order *models.Order, settings *models.Settings, reprint bool) *Document {
+	doc := &Document{
+		Title: "KITCHEN",
+		Copy:  reprint,
+		Meta:  orderMeta(order, settings),
+		Large: true,
+	}
+	if len(order.GuestAllergies) > 0 {
+		doc.Meta = append(doc.Meta, Field{Label: "ALLERGY", Value: strings.ToUpper(strings.Join(order.GuestAllergies, ", ")), Bold: true})
+	}
+
+	for _, item := range order.Items {
+		if item.ItemType == "gift_card" {
+			continue
+		}
+		name := itemName(item)
+		modifiers := itemModifiers(item)
+		if item.IsRemake {
+			name = "REMAKE " + name
+			if item.RemakeReason != nil && *item.RemakeReason != "" {
+				modifiers = append(modifiers, "Reason: "+*item.RemakeReason)
+			}
+		}
+		if len(item.AllergenConflicts) > 0 {
+			name = "!! " + name
+			modifiers = append(modifiers, "CONTAINS "+strings.ToUpper(strings.Join(item.AllergenConflicts, ", ")))
+		}
+		doc.Items = append(doc.Items, Item{
+			Quantity:  item.Quantity,
+			Name:      name,
+			Modifiers: modifiers,
+		})
+	}
+
+	if order.Notes != nil && *order.Notes != "" {
+		doc.Footer = append(doc.Footer, "NOTE: "+*order.Notes)
+	}
+
+	return doc
+}
+
+func storeHeader(settings *models.Settings) []string {
+	header := []string{settings.Name}
+	for _, value := range []*string{settings.Address, settings.Phone, settings.Website} {
+		if value != nil && *value != "" {
+			header = append(header, strings.Split(*value, "\n")...)
+		}
+	}
+	return header
+}
+
+func orderMeta(order *models.Order, settings *models.Settings) []Field {
+	createdAt := order.CreatedAt
+	if location, err := time.LoadLocation(settings.Timezone); err == nil {
+		createdAt = createdAt.In(location)
+	}
+
+	meta := []Field{
+		{Label: "Order", Value: order.OrderNumber, Bold: true},
+		{Label: "Date", Value: createdAt.Format("2006-01-02 15:04")},
+		{Label: "Type", Value: strings.ReplaceAll(order.OrderType, "_", " ")},
+	}
+	if order.Table != nil {
+		meta = append(meta, Field{Label: "Table", Value: order.Table.TableNumber, Bold: true})
+	}
+	if order.CustomerName != nil && *order.CustomerName != "" {
+		meta = append(meta, Field{Label: "Customer", Value: *order.CustomerName})
+	}
+	if order.User != nil {
+		meta = append(meta, Field{Label: "Server", Value: strings.TrimSpace(order.User.FirstName + " " + order.User.LastName)})
+	}
+	return meta
+}
+
+func itemName(item models.OrderItem) string {
+	if item.ItemType == "gift_card" {
+		if item.GiftCardCode != nil {
+			return "Gift Card " + maskCode(*item.GiftCardCode)
+		}
+		return "Gift Card"
+	}
+	if item.Product != nil {
+		return item.Product.Name
+	}
+	return "Item"
+}
+
+func itemModifiers(item models.OrderItem) []string {
+	var modifiers []string
+	for _, modifier := range item.Modifiers {
+		modifiers = append(modifiers, modifier.Name)
+	}
+	if item.SpecialInstructions == nil || *item.SpecialInstructions == "" {
+		return modifiers
+	}
+	for _, modifier := range strings.Split(*item.SpecialInstructions, ",") {
+		if modifier = strings.TrimSpace(modifier); modifier != "" {
+			modifiers = append(modifiers, modifier)
+		}
+	}
+	return modifiers
+}
+
+func paymentLabel(payment models.Payment) string {
+	words := strings.Fields(strings.ReplaceAll(payment.PaymentMethod, "_", " "))
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	label := strings.Join(words, " ")
+	if payment.ReferenceNumber != nil && *payment.ReferenceNumber != "" {
+		label += " " + maskCode(*payment.ReferenceNumber)
+	}
+	return label
+}
+
+func tenderedAmount(payment models.Payment) float64 {
+	if payment.TenderedAmount != nil && *payment.TenderedAmount > payment.Amount {
+		return *payment.TenderedAmount
+	}
+	return payment.Amount
+}
+
+func maskCode(code string) string {
+	if len(code) <= 4 || strings.HasPrefix(code, "****") {
+		return code
+	}
+	return "****" + code[len(code)-4:]
+}
@@ -0,0 +1,78 @@
+package receipt
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// ESC/POS control sequences
+var (
+	escInit        = []byte{0x1b, 0x40}
+	escAlignLeft   = []byte{0x1b, 0x61, 0x00}
+	escAlignCenter = []byte{0x1b, 0x61, 0x01}
+	escBoldOn      = []byte{0x1b, 0x45, 0x01}
+	escBoldOff     = []byte{0x1b, 0x45, 0x00}
+	escSizeNormal  = []byte{0x1d, 0x21, 0x00}
+	escSizeDouble  = []byte{0x1d, 0x21, 0x11}
+	escFeedAndCut  = []byte{0x1b, 0x64, 0x04, 0x1d, 0x56, 0x42, 0x00}
+)
+
+// asciiFallbacks replaces symbols missing from the printer's default code page
+var asciiFallbacks = map[rune]string{
+	'€': "EUR",
+	'£': "GBP",
+	'¥': "JPY",
+	'₫': "VND",
+	'₩': "KRW",
+	'₹': "INR",
+	'฿': "THB",
+}
+
+// RenderESCPOS renders the document as raw ESC/POS bytes ready to send to a printer
+func RenderESCPOS(doc *Document, width int) []byte {
+	var b bytes.Buffer
+	b.Write(escInit)
+
+	layout(doc, width, func(line string, style lineStyle) {
+		if style.align == alignCenter {
+			b.Write(escAlignCenter)
+		} else {
+			b.Write(escAlignLeft)
+		}
+		if style.bold {
+			b.Write(escBoldOn)
+		}
+		if style.large {
+			b.Write(escSizeDouble)
+		}
+
+		b.WriteString(toASCII(line))
+		b.WriteByte('\n')
+
+		if style.large {
+			b.Write(escSizeNormal)
+		}
+		if style.bold {
+			b.Write(escBoldOff)
+		}
+	})
+
+	b.Write(escAlignLeft)
+	b.Write(escFeedAndCut)
+	return b.Bytes()
+}
+
+func toASCII(s string) string {
+	var b bytes.Buffer
+	for _, r := range s {
+		switch {
+		case r < utf8.RuneSelf:
+			b.WriteRune(r)
+		case asciiFallbacks[r] != "":
+			b.WriteString(asciiFallbacks[r])
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,69 @@
+package receipt
+
+import (
+	"bytes"
+	"html/template"
+)
+
+var htmlTemplate = template.Must(template.New("receipt").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+  body { font-family: monospace; max-width: 380px; margin: 0 auto; padding: 16px; }
+  .center { text-align: center; }
+  .copy { text-align: center; font-weight: bold; border: 1px dashed #000; margin: 8px 0; }
+  .large { font-size: 1.4em; }
+  table { width: 100%; border-collapse: collapse; }
+  td.amount { text-align: right; white-space: nowrap; }
+  td.modifier { padding-left: 24px; font-size: 0.9em; }
+  .bold { font-weight: bold; }
+  hr { border: 0; border-top: 1px dashed #000; }
+</style>
+</head>
+<body{{if .Large}} class="large"{{end}}>
+{{if .LogoURL}}<div class="center"><img src="{{.LogoURL}}" alt="" style="max-width: 200px"></div>{{end}}
+{{range $i, $line := .Header}}<div class="center{{if eq $i 0}} bold{{end}}">{{$line}}</div>
+{{end}}{{if .Title}}<h2 class="center">{{.Title}}</h2>{{end}}
+{{if .Copy}}<div class="copy">COPY</div>{{end}}
+<hr>
+<table>
+{{range .Meta}}<tr{{if .Bold}} class="bold"{{end}}><td>{{.Label}}</td><td class="amount">{{.Value}}</td></tr>
+{{end}}</table>
+<hr>
+<table>
+{{range .Items}}<tr><td>{{.Quantity}} x {{.Name}}</td><td class="amount">{{.Amount}}</td></tr>
+{{range .Modifiers}}<tr><td class="modifier" colspan="2">+ {{.}}</td></tr>
+{{end}}{{end}}</table>
+{{if .Totals}}<hr>
+<table>
+{{range .Totals}}<tr{{if .Bold}} class="bold"{{end}}><td>{{.Label}}</td><td class="amount">{{.Value}}</td></tr>
+{{end}}</table>{{end}}
+{{if .Payments}}<hr>
+<table>
+{{range .Payments}}<tr{{if .Bold}} class="bold"{{end}}><td>{{.Label}}</td><td class="amount">{{.Value}}</td></tr>
+{{end}}</table>{{end}}
+{{if .Footer}}<hr>
+{{range .Footer}}<div class="center">{{.}}</div>
+{{end}}{{end}}
+</body>
+</html>
+`))
+
+// RenderHTML renders the document as a standalone HTML page
+func RenderHTML(doc *Document, logoURL *string) ([]byte, error) {
+	data := struct {
+		*Document
+		LogoURL string
+	}{Document: doc}
+	if logoURL != nil {
+		data.LogoURL = *logoURL
+	}
+
+	var b bytes.Buffer
+	if err := htmlTemplate.Execute(&b, data); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,128 @@
+package receipt
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type alignment int
+
+const (
+	alignLeft alignment = iota
+	alignCenter
+)
+
+// lineStyle describes how a laid out line should be emphasized by a renderer
+type lineStyle struct {
+	align alignment
+	bold  bool
+	large bool
+}
+
+// layout walks the document in print order and emits width-limited lines.
+// Every renderer shares it so text, ESC/POS and kitchen tickets line up the same.
+func layout(doc *Document, width int, emit func(string, lineStyle)) {
+	rule := strings.Repeat("-", width)
+	itemWidth := width
+	if doc.Large {
+		// Double-width characters halve the usable columns
+		itemWidth = width / 2
+	}
+
+	for i, line := range doc.Header {
+		emit(truncate(line, width), lineStyle{align: alignCenter, bold: i == 0})
+	}
+	if doc.Title != "" {
+		emit(doc.Title, lineStyle{align: alignCenter, bold: true, large: doc.Large})
+	}
+	if doc.Copy {
+		emit("*** COPY ***", lineStyle{align: alignCenter, bold: true})
+	}
+	emit(rule, lineStyle{})
+
+	for _, field := range doc.Meta {
+		emit(columns(field.Label+":", field.Value, width), lineStyle{bold: field.Bold})
+	}
+	emit(rule, lineStyle{})
+
+	for _, item := range doc.Items {
+		name := strconv.Itoa(item.Quantity) + " x " + item.Name
+		if item.Amount == "" {
+			emit(truncate(name, itemWidth), lineStyle{bold: true, large: doc.Large})
+		} else {
+			emit(columns(name, item.Amount, width), lineStyle{})
+		}
+		for _, modifier := range item.Modifiers {
+			emit(truncate("   + "+modifier, itemWidth), lineStyle{large: doc.Large})
+		}
+	}
+
+	if len(doc.Totals) > 0 {
+		emit(rule, lineStyle{})
+		for _, field := range doc.Totals {
+			emit(columns(field.Label, field.Value, width), lineStyle{bold: field.Bold})
+		}
+	}
+
+	if len(doc.Payments) > 0 {
+		emit(rule, lineStyle{})
+		for _, field := range doc.Payments {
+			emit(columns(field.Label, field.Value, width), lineStyle{bold: field.Bold})
+		}
+	}
+
+	if len(doc.Footer) > 0 {
+		emit(rule, lineStyle{})
+		for _, line := range doc.Footer {
+			for _, wrapped := range wrap(line, width) {
+				emit(wrapped, lineStyle{align: alignCenter})
+			}
+		}
+	}
+}
+
+// columns puts left and right on one line, truncating the left side if needed
+func columns(left, right string, width int) string {
+	space := width - utf8.RuneCountInString(right) - 1
+	if space < 1 {
+		return truncate(left+" "+right, width)
+	}
+	left = truncate(left, space)
+	padding := width - utf8.RuneCountInString(left) - utf8.RuneCountInString(right)
+	return left + strings.Repeat(" ", padding) + right
+}
+
+func center(s string, width int) string {
+	n := utf8.RuneCountInString(s)
+	if n >= width {
+		return s
+	}
+	return strings.Repeat(" ", (width-n)/2) + s
+}
+
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	return string([]rune(s)[:width])
+}
+
+func wrap(s string, width int) []string {
+	var lines []string
+	var current string
+	for _, word := range strings.Fields(s) {
+		if current == "" {
+			current = word
+		} else if utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) <= width {
+			current += " " + word
+		} else {
+			lines = append(lines, truncate(current, width))
+			current = word
+		}
+	}
+	if current != "" {
+		lines = append(lines, truncate(current, width))
+	}
+	return lines
+}
@@ -0,0 +1,119 @@
+package receipt
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestRenderText(t *testing.T) {
+	doc := &Document{
+		Title:  "RECEIPT",
+		Copy:   true,
+		Header: []string{"Corner Cafe", "1 Main Street"},
+		Meta:   []Field{{Label: "Order", Value: "ORD-42"}},
+		Items: []Item{
+			{Quantity: 2, Name: "Flat White", Amount: "$9.00", Modifiers: []string{"Oat milk"}},
+			{Quantity: 1, Name: "Blueberry Muffin Extra Large", Amount: "$4.50"},
+		},
+		Totals:   []Field{{Label: "TOTAL", Value: "$13.50", Bold: true}},
+		Payments: []Field{{Label: "Cash", Value: "$20.00"}},
+		Footer:   []string{"Thank you for visiting us today"},
+	}
+
+	want := strings.Join([]string{
+		"    Corner Cafe",
+		"   1 Main Street",
+		"      RECEIPT",
+		"    *** COPY ***",
+		"--------------------",
+		"Order:        ORD-42",
+		"--------------------",
+		"2 x Flat White $9.00",
+		"   + Oat milk",
+		"1 x Blueberry  $4.50",
+		"--------------------",
+		"TOTAL         $13.50",
+		"--------------------",
+		"Cash          $20.00",
+		"--------------------",
+		"   Thank you for",
+		" visiting us today",
+		"",
+	}, "\n")
+
+	if got := string(RenderText(doc, 20)); got != want {
+		t.Errorf("RenderText =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestLayoutKitchenTicketHalvesItemWidth(t *testing.T) {
+	doc := &Document{
+		Title: "KITCHEN",
+		Large: true,
+		Items: []Item{{Quantity: 1, Name: "Margherita Pizza", Modifiers: []string{"Extra basil"}}},
+	}
+
+	var lines []string
+	layout(doc, 20, func(line string, style lineStyle) {
+		if style.large {
+			lines = append(lines, line)
+		}
+	})
+
+	want := []string{"KITCHEN", "1 x Marghe", "   + Extra"}
+	if strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Errorf("large lines = %q, want %q", lines, want)
+	}
+}
+
+func TestColumns(t *testing.T) {
+	tests := []struct {
+		left, right string
+		width       int
+		want        string
+	}{
+		{"Total", "$5.00", 14, "Total    $5.00"},
+		{"Crème brûlée", "€7.00", 18, "Crème brûlée €7.00"},
+		{"Very long item name", "$5.00", 14, "Very lon $5.00"},
+		{"Total", "$1,234,567.00", 10, "Total $1,2"},
+	}
+
+	for _, tt := range tests {
+		got := columns(tt.left, tt.right, tt.width)
+		if got != tt.want {
+			t.Errorf("columns(%q, %q, %d) = %q, want %q", tt.left, tt.right, tt.width, got, tt.want)
+		}
+		if n := utf8.RuneCountInString(got); n > tt.width {
+			t.Errorf("columns(%q, %q, %d) is %d wide", tt.left, tt.right, tt.width, n)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	got := wrap("Please come again   soon Supercalifragilistic", 10)
+	want := []string{"Please", "come again", "soon", "Supercalif"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("wrap = %q, want %q", got, want)
+	}
+}
+
+func TestFormatMoney(t *testing.T) {
+	tests := []struct {
+		amount   float64
+		currency string
+		want     string
+	}{
+		{1234.5, "USD", "$1,234.50"},
+		{-3.456, "EUR", "-€3.46"},
+		{1500000, "VND", "₫1,500,000"},
+		{0.995, "usd", "$1.00"},
+		{12, "CHF", "CHF 12.00"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatMoney(tt.amount, tt.currency); got != tt.want {
+			t.Errorf("FormatMoney(%v, %s) = %q, want %q", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
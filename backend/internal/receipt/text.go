@@ -0,0 +1,18 @@
+package receipt
+
+import "strings"
+
+// RenderText renders the document as fixed-width plain text
+func RenderText(doc *Document, width int) []byte {
+	var b strings.Builder
+
+	layout(doc, width, func(line string, style lineStyle) {
+		if style.align == alignCenter {
+			line = center(line, width)
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+	})
+
+	return []byte(b.String())
+}
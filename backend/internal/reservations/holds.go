@@ -0,0 +1,92 @@
+package reservations
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"pos-backend/internal/util"
+)
+
+// Holder marks a table reserved when its next booking is less than HoldBefore
+// away, so it is not given to walk-ins, and frees it again once the booking is
+// seated, cancelled or more than Grace late.
+type Holder struct {
+	db         *sql.DB
+	Interval   time.Duration
+	HoldBefore time.Duration
+	Grace      time.Duration
+}
+
+// NewHolder creates a holder configured from RESERVATION_HOLD_INTERVAL,
+// RESERVATION_HOLD_BEFORE and RESERVATION_GRACE
+func NewHolder(db *sql.DB) *Holder {
+	return &Holder{
+		db:         db,
+		Interval:   util.DurationFromEnv("RESERVATION_HOLD_INTERVAL", time.Minute),
+		HoldBefore: util.DurationFromEnv("RESERVATION_HOLD_BEFORE", 30*time.Minute),
+		Grace:      util.DurationFromEnv("RESERVATION_GRACE", 15*time.Minute),
+	}
+}
+
+// Start runs the holder in the background until ctx is cancelled
+func (h *Holder) Start(ctx context.Context) {
+	util.RunEvery(ctx, h.Interval, "reservation holder", h.sync)
+}
+
+// sync releases holds whose reservation no longer needs the table and holds
+// free tables for the next booking due within HoldBefore
+func (h *Holder) sync() error {
+	tx, err := h.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE dining_tables t
+		SET status = CASE WHEN t.status = 'reserved' THEN 'available' ELSE t.status END,
+		    reserved_for = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE t.reserved_for IS NOT NULL
+		  AND NOT EXISTS (
+			SELECT 1 FROM reservations r
+			WHERE r.id = t.reserved_for
+			  AND r.table_id = t.id
+			  AND r.status = 'booked'
+			  AND r.reserved_at + $1::float8 * INTERVAL '1 second' > CURRENT_TIMESTAMP
+		  )
+	`, h.Grace.Seconds())
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`
+		UPDATE dining_tables t
+		SET status = 'reserved', reserved_for = next.id, updated_at = CURRENT_TIMESTAMP
+		FROM (
+			SELECT DISTINCT ON (table_id) id, table_id
+			FROM reservations
+			WHERE status = 'booked'
+			  AND table_id IS NOT NULL
+			  AND reserved_at <= CURRENT_TIMESTAMP + $1::float8 * INTERVAL '1 second'
+			  AND reserved_at + $2::float8 * INTERVAL '1 second' > CURRENT_TIMESTAMP
+			ORDER BY table_id, reserved_at
+		) next
+		WHERE t.id = next.table_id
+		  AND t.status = 'available'
+		  AND t.reserved_for IS NULL
+	`, h.HoldBefore.Seconds(), h.Grace.Seconds())
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if held, _ := result.RowsAffected(); held > 0 {
+		log.Printf("reservation holder: held %d table(s) for upcoming reservations", held)
+	}
+	return nil
+}
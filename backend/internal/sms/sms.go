@@ -0,0 +1,92 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"pos-backend/internal/util"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidRecipient is returned when a message has no phone number to go to
+var ErrInvalidRecipient = errors.New("sms recipient phone number is required")
+
+// Message is a text message to a guest
+type Message struct {
+	To   string
+	Body string
+}
+
+// Result is the provider's answer to a send
+type Result struct {
+	MessageID string
+	Status    string // queued, sent, failed
+}
+
+// Provider is implemented by every SMS gateway integration
+type Provider interface {
+	// Name identifies the provider in stored notifications
+	Name() string
+	// Send delivers a message to the recipient
+	Send(ctx context.Context, msg Message) (*Result, error)
+}
+
+// LogProvider is a local stand-in for an SMS gateway. Messages are written to
+// the log and kept in memory instead of being delivered.
+type LogProvider struct {
+	mu   sync.Mutex
+	sent []SentMessage
+}
+
+// SentMessage is a message recorded by the LogProvider
+type SentMessage struct {
+	Message
+	MessageID string
+	SentAt    time.Time
+}
+
+// NewLogProvider creates a provider that only logs messages
+func NewLogProvider() *LogProvider {
+	return &LogProvider{}
+}
+
+// NewDefaultProvider returns the provider selected by SMS_PROVIDER. Only the
+// local "log" provider ships with the POS, so unknown names fall back to it.
+func NewDefaultProvider() Provider {
+	name := util.FromEnv("SMS_PROVIDER", "log")
+	if name != "log" {
+		log.Printf("sms: unknown provider %q, using log provider", name)
+	}
+	return NewLogProvider()
+}
+
+func (p *LogProvider) Name() string {
+	return "log"
+}
+
+func (p *LogProvider) Send(ctx context.Context, msg Message) (*Result, error) {
+	if strings.TrimSpace(msg.To) == "" {
+		return nil, ErrInvalidRecipient
+	}
+
+	sent := SentMessage{Message: msg, MessageID: "log_" + uuid.New().String(), SentAt: time.Now()}
+	log.Printf("sms to %s: %s", msg.To, msg.Body)
+
+	p.mu.Lock()
+	p.sent = append(p.sent, sent)
+	p.mu.Unlock()
+
+	return &Result{MessageID: sent.MessageID, Status: "sent"}, nil
+}
+
+// Sent returns the messages recorded so far
+func (p *LogProvider) Sent() []SentMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]SentMessage(nil), p.sent...)
+}
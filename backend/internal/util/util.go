@@ -1,8 +1,11 @@
 package util
 
 import (
+	"context"
+	"database/sql"
 	"log"
 	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -45,6 +48,66 @@ func FromEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// DurationFromEnv reads a duration such as "30s" from the environment, using
+// defaultValue when it is not set or cannot be parsed
+func DurationFromEnv(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(FromEnv(key, defaultValue.String()))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// RunEvery calls fn right away and then every interval in the background until
+// ctx is cancelled. Errors are logged with name and do not stop the loop.
+func RunEvery(ctx context.Context, interval time.Duration, name string, fn func() error) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := fn(); err != nil {
+				log.Printf("%s: %v", name, err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RowQueryer is satisfied by *sql.DB and *sql.Tx
+type RowQueryer interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// StoreLocation returns the time zone set in the store settings, or UTC when
+// none is set or it is not a known zone
+func StoreLocation(q RowQueryer) (*time.Location, error) {
+	var timezone sql.NullString
+	err := q.QueryRow("SELECT timezone FROM settings ORDER BY created_at DESC LIMIT 1").Scan(&timezone)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	return LocationOrUTC(timezone.String), nil
+}
+
+// LocationOrUTC loads the named time zone, falling back to UTC when the name
+// is empty or unknown
+func LocationOrUTC(name string) *time.Location {
+	if name == "" {
+		return time.UTC
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
 func LoadEnv(dir string) {
 	if err := godotenv.Load(dir + ".env"); err != nil {
 		log.Fatalf("Error loading .env file: %v", err)
@@ -1,11 +1,18 @@
 package main
 
 import (
+	"context"
 	"log"
+	"strings"
 
 	"pos-backend/internal/api"
 	"pos-backend/internal/database"
+	"pos-backend/internal/events"
+	"pos-backend/internal/kitchen"
+	"pos-backend/internal/mailer"
 	"pos-backend/internal/middleware"
+	"pos-backend/internal/printing"
+	"pos-backend/internal/reservations"
 	"pos-backend/internal/util"
 
 	"github.com/gin-contrib/cors"
@@ -44,10 +51,35 @@ func main() {
 		log.Fatalf("Failed to ping database: %v", err)
 	}
 
+	// Start background workers
+	printing.NewWorker(db).Start(context.Background())
+	mailer.NewWorker(db, mailer.NewSMTPTransportFromEnv()).Start(context.Background())
+	kitchen.NewWatcher(db).Start(context.Background())
+	kitchen.NewRestorer(db).Start(context.Background())
+	reservations.NewHolder(db).Start(context.Background())
+
+	// Order events are shared between instances through LISTEN/NOTIFY
+	broker := events.NewBroker(db, dbConfig.DSN())
+	if err := broker.Start(context.Background()); err != nil {
+		log.Fatalf("Failed to start event broker: %v", err)
+	}
+
 	// Initialize Gin router
 	gin.SetMode(util.FromEnv("GIN_MODE", "release"))
 	router := gin.New()
 
+	// Only believe X-Forwarded-For from our own proxies; otherwise guests could
+	// pick their own client IP and get around the rate limits
+	var trustedProxies []string
+	for _, proxy := range strings.Split(util.FromEnv("TRUSTED_PROXIES", ""), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			trustedProxies = append(trustedProxies, proxy)
+		}
+	}
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
 	// Add middleware
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
@@ -68,7 +100,7 @@ func main() {
 
 	// Initialize API routes
 	apiRoutes := router.Group("/api/v1")
-	api.SetupRoutes(apiRoutes, db, authMiddleware)
+	api.SetupRoutes(apiRoutes, db, broker, authMiddleware)
 
 	// Start server
 	port := util.FromEnv("PORT", "8080")